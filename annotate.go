@@ -0,0 +1,138 @@
+package Netpbm
+
+import (
+	"math"
+	"strconv"
+)
+
+// Lengths, in pixels, of the minor and major ticks drawn by DrawRuler.
+const (
+	rulerMinorTick = 3
+	rulerMajorTick = 7
+	// rulerMajorEvery is the number of ticks between two major ticks.
+	rulerMajorEvery = 5
+)
+
+// DrawGrid draws vertical and horizontal lines every spacing pixels over the PPM image,
+// starting at the top-left corner, with the specified color.
+func (ppm *PPM) DrawGrid(spacing int, color Pixel) {
+	if spacing <= 0 {
+		return
+	}
+	plot := ppm.plotter(color)
+	for x := 0; x < ppm.width; x += spacing {
+		bresenham(Point{x, 0}, Point{x, ppm.height - 1}, plot)
+	}
+	for y := 0; y < ppm.height; y += spacing {
+		bresenham(Point{0, y}, Point{ppm.width - 1, y}, plot)
+	}
+}
+
+// DrawRuler draws tick marks every spacing pixels along the top and left edges of the PPM
+// image with the specified color. Every fifth tick is longer and labeled with its
+// coordinate.
+func (ppm *PPM) DrawRuler(spacing int, color Pixel) {
+	if spacing <= 0 {
+		return
+	}
+	for i, x := 0, 0; x < ppm.width; i, x = i+1, x+spacing {
+		if i%rulerMajorEvery != 0 {
+			ppm.DrawLine(Point{x, 0}, Point{x, rulerMinorTick - 1}, color)
+			continue
+		}
+		ppm.DrawLine(Point{x, 0}, Point{x, rulerMajorTick - 1}, color)
+		if x > 0 {
+			ppm.DrawText(Point{x + 2, rulerMinorTick}, strconv.Itoa(x), color, 1)
+		}
+	}
+	for i, y := 0, 0; y < ppm.height; i, y = i+1, y+spacing {
+		if i%rulerMajorEvery != 0 {
+			ppm.DrawLine(Point{0, y}, Point{rulerMinorTick - 1, y}, color)
+			continue
+		}
+		ppm.DrawLine(Point{0, y}, Point{rulerMajorTick - 1, y}, color)
+		if y > 0 {
+			ppm.DrawText(Point{rulerMinorTick, y + 2}, strconv.Itoa(y), color, 1)
+		}
+	}
+}
+
+// DrawCrosshair draws a horizontal and a vertical line through p across the whole PPM image
+// with the specified color, leaving p itself untouched so the marked pixel stays visible.
+func (ppm *PPM) DrawCrosshair(p Point, color Pixel) {
+	plot := ppm.plotter(color)
+	for x := 0; x < ppm.width; x++ {
+		if x != p.X {
+			plot(x, p.Y)
+		}
+	}
+	for y := 0; y < ppm.height; y++ {
+		if y != p.Y {
+			plot(p.X, y)
+		}
+	}
+}
+
+// DrawArrow draws a line from p1 to p2 on the PPM image ending with a filled arrowhead of
+// the given length at p2, with the specified color.
+func (ppm *PPM) DrawArrow(p1, p2 Point, headSize int, color Pixel) {
+	plot := ppm.plotter(color)
+	bresenham(p1, p2, plot)
+	dx, dy := float64(p2.X-p1.X), float64(p2.Y-p1.Y)
+	length := math.Hypot(dx, dy)
+	if length == 0 || headSize <= 0 {
+		return
+	}
+	ux, uy := dx/length, dy/length
+	// The head is half as wide as it is long.
+	h, w := float64(headSize), float64(headSize)/2
+	bx, by := float64(p2.X)-ux*h, float64(p2.Y)-uy*h
+	left := Point{int(math.Round(bx - uy*w)), int(math.Round(by + ux*w))}
+	right := Point{int(math.Round(bx + uy*w)), int(math.Round(by - ux*w))}
+	filledPolygon([]Point{p2, left, right}, plot)
+}
+
+// MarkerShape selects the symbol drawn by DrawMarker.
+type MarkerShape int
+
+const (
+	// MarkerPlus is a + sign.
+	MarkerPlus MarkerShape = iota
+	// MarkerCross is an x sign.
+	MarkerCross
+	// MarkerCircle is the outline of a circle.
+	MarkerCircle
+	// MarkerDot is a filled circle.
+	MarkerDot
+	// MarkerSquare is the outline of a square.
+	MarkerSquare
+	// MarkerDiamond is the outline of a square standing on a corner.
+	MarkerDiamond
+	// MarkerTriangle is the outline of a triangle pointing up.
+	MarkerTriangle
+)
+
+// DrawMarker draws a symbol of the given size, in pixels across, centered on p on the PPM
+// image with the specified color, to mark detected features such as corners and blobs.
+func (ppm *PPM) DrawMarker(p Point, shape MarkerShape, size int, color Pixel) {
+	r := max(size/2, 1)
+	plot := ppm.plotter(color)
+	switch shape {
+	case MarkerPlus:
+		bresenham(Point{p.X - r, p.Y}, Point{p.X + r, p.Y}, plot)
+		bresenham(Point{p.X, p.Y - r}, Point{p.X, p.Y + r}, plot)
+	case MarkerCross:
+		bresenham(Point{p.X - r, p.Y - r}, Point{p.X + r, p.Y + r}, plot)
+		bresenham(Point{p.X - r, p.Y + r}, Point{p.X + r, p.Y - r}, plot)
+	case MarkerCircle:
+		circle(p, r, plot)
+	case MarkerDot:
+		filledCircle(p, r, plot)
+	case MarkerSquare:
+		rectangle(Point{p.X - r, p.Y - r}, 2*r, 2*r, plot)
+	case MarkerDiamond:
+		polygon([]Point{{p.X, p.Y - r}, {p.X + r, p.Y}, {p.X, p.Y + r}, {p.X - r, p.Y}}, plot)
+	case MarkerTriangle:
+		polygon([]Point{{p.X, p.Y - r}, {p.X + r, p.Y + r}, {p.X - r, p.Y + r}}, plot)
+	}
+}
@@ -0,0 +1,124 @@
+package Netpbm
+
+import "math"
+
+// blend mixes color into the pixel (x, y) with the given coverage, between 0 and 1. Pixels
+// outside the image are handled as told by its bounds policy, those outside the clip
+// rectangle are ignored.
+func (d *drawPass) blend(x, y int, color Pixel, alpha float64) {
+	ppm := d.ppm
+	x, y, ok := ppm.bounds.resolve(x, y, ppm.width, ppm.height)
+	if !ok || !ppm.visible(x, y) || alpha <= 0 {
+		return
+	}
+	if ppm.mode != DrawCopy {
+		// Blending cannot be undone by drawing again.
+		if alpha >= 0.5 {
+			d.plot(x, y, color)
+		}
+		return
+	}
+	ppm.plot(x, y, mixPixel(ppm.data[y*ppm.width+x], color, math.Min(alpha, 1)))
+}
+
+// wuLine calls plot with the coverage of every pixel of the anti-aliased line between
+// (x0, y0) and (x1, y1), using Xiaolin Wu's algorithm.
+func wuLine(x0, y0, x1, y1 float64, plot func(x, y int, alpha float64)) {
+	fpart := func(v float64) float64 { return v - math.Floor(v) }
+	rfpart := func(v float64) float64 { return 1 - fpart(v) }
+
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	point := func(x, y int, alpha float64) {
+		if steep {
+			x, y = y, x
+		}
+		plot(x, y, alpha)
+	}
+
+	gradient := 1.0
+	if dx := x1 - x0; dx != 0 {
+		gradient = (y1 - y0) / dx
+	}
+
+	// Ends are weighted by the part of their pixel the line covers horizontally.
+	xEnd := math.Round(x0)
+	yEnd := y0 + gradient*(xEnd-x0)
+	gap := rfpart(x0 + 0.5)
+	xStart := int(xEnd)
+	point(xStart, int(math.Floor(yEnd)), rfpart(yEnd)*gap)
+	point(xStart, int(math.Floor(yEnd))+1, fpart(yEnd)*gap)
+	intery := yEnd + gradient
+
+	xEnd = math.Round(x1)
+	yEnd = y1 + gradient*(xEnd-x1)
+	gap = fpart(x1 + 0.5)
+	xStop := int(xEnd)
+	if xStop != xStart {
+		point(xStop, int(math.Floor(yEnd)), rfpart(yEnd)*gap)
+		point(xStop, int(math.Floor(yEnd))+1, fpart(yEnd)*gap)
+	}
+
+	for x := xStart + 1; x < xStop; x++ {
+		point(x, int(math.Floor(intery)), rfpart(intery))
+		point(x, int(math.Floor(intery))+1, fpart(intery))
+		intery += gradient
+	}
+}
+
+// DrawLineAA draws an anti-aliased line on the PPM image between two points, blending
+// the color with the existing pixels according to their coverage.
+func (ppm *PPM) DrawLineAA(p1, p2 Point, color Pixel) {
+	ppm.pass().lineAA(p1, p2, color)
+}
+
+// lineAA blends the anti-aliased line between two points in the pass.
+func (d *drawPass) lineAA(p1, p2 Point, color Pixel) {
+	wuLine(float64(p1.X), float64(p1.Y), float64(p2.X), float64(p2.Y), func(x, y int, alpha float64) {
+		d.blend(x, y, color, alpha)
+	})
+}
+
+// DrawCircleAA draws the anti-aliased outline of a circle on the PPM image, blending the
+// color with the existing pixels according to their coverage.
+func (ppm *PPM) DrawCircleAA(center Point, radius int, color Pixel) {
+	d := ppm.pass()
+	if radius <= 0 {
+		d.blend(center.X, center.Y, color, 1)
+		return
+	}
+	r := float64(radius)
+	// Each step covers one octant; the others are obtained by symmetry. The symmetric
+	// points coincide on the axes and the diagonals, where each is blended once.
+	octants := func(dx, dy int, alpha float64) {
+		points := [8]Point{{dx, dy}, {-dx, dy}, {dx, -dy}, {-dx, -dy}, {dy, dx}, {-dy, dx}, {dy, -dx}, {-dy, -dx}}
+		for i, p := range points {
+			duplicate := false
+			for _, q := range points[:i] {
+				duplicate = duplicate || q == p
+			}
+			if !duplicate {
+				d.blend(center.X+p.X, center.Y+p.Y, color, alpha)
+			}
+		}
+	}
+	for dx := 0; float64(dx) <= r/math.Sqrt2; dx++ {
+		y := math.Sqrt(r*r - float64(dx*dx))
+		f := y - math.Floor(y)
+		octants(dx, int(math.Floor(y)), 1-f)
+		octants(dx, int(math.Floor(y))+1, f)
+	}
+}
+
+// DrawPolygonAA draws the anti-aliased outline of a polygon on the PPM image.
+func (ppm *PPM) DrawPolygonAA(points []Point, color Pixel) {
+	d := ppm.pass()
+	for i := range points {
+		d.lineAA(points[i], points[(i+1)%len(points)], color)
+	}
+}
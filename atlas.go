@@ -0,0 +1,115 @@
+package Netpbm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// atlasPadding is the number of empty pixels around every glyph of an atlas, preventing
+// bleeding when the atlas is sampled with filtering.
+const atlasPadding = 1
+
+// GlyphMetrics locates a glyph in a GlyphAtlas and tells how to draw it.
+type GlyphMetrics struct {
+	Char string `json:"char"`
+	// X, Y, Width and Height give the box of the glyph in the atlas image. Glyphs without
+	// ink, such as the space, have an empty box.
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// OffsetX and OffsetY give the position of the box relative to the top-left corner of
+	// the character cell.
+	OffsetX int `json:"offsetX"`
+	OffsetY int `json:"offsetY"`
+	// Advance is the horizontal distance to the next character.
+	Advance int `json:"advance"`
+}
+
+// GlyphAtlas is a set of glyphs packed into a single PGM image, white on black so that
+// the samples can be used as coverage.
+type GlyphAtlas struct {
+	Image      *PGM           `json:"-"`
+	LineHeight int            `json:"lineHeight"`
+	Glyphs     []GlyphMetrics `json:"glyphs"`
+}
+
+// WriteMetrics writes the metrics of the atlas as JSON to w.
+func (a *GlyphAtlas) WriteMetrics(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// glyphBounds returns the box of the set pixels of the glyph of r, relative to its cell.
+func (f *Font) glyphBounds(r rune) (x0, y0, x1, y1 int) {
+	x0, y0 = f.width, f.height
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			if f.Pixel(r, x, y) {
+				x0, y0 = min(x0, x), min(y0, y)
+				x1, y1 = max(x1, x+1), max(y1, y+1)
+			}
+		}
+	}
+	if x0 >= x1 {
+		return 0, 0, 0, 0
+	}
+	return x0, y0, x1, y1
+}
+
+// BuildGlyphAtlas renders the characters of chars with font and packs them, trimmed to
+// their ink, into rows of a roughly square PGM image. Duplicate characters are packed once.
+func BuildGlyphAtlas(font *Font, chars string) (*GlyphAtlas, error) {
+	var runes []rune
+	seen := make(map[rune]bool)
+	for _, r := range chars {
+		if !font.HasGlyph(r) {
+			return nil, fmt.Errorf("font has no glyph for %q", r)
+		}
+		if !seen[r] {
+			seen[r] = true
+			runes = append(runes, r)
+		}
+	}
+	if len(runes) == 0 {
+		return nil, fmt.Errorf("no characters to pack")
+	}
+
+	// Pack on shelves, the atlas width being chosen for a square result.
+	cellW := font.width + atlasPadding
+	columns := int(math.Ceil(math.Sqrt(float64(len(runes)))))
+	atlasWidth := atlasPadding + columns*cellW
+	atlas := &GlyphAtlas{LineHeight: font.LineHeight()}
+	x, y, shelf := atlasPadding, atlasPadding, 0
+	for _, r := range runes {
+		x0, y0, x1, y1 := font.glyphBounds(r)
+		w, h := x1-x0, y1-y0
+		if x+w+atlasPadding > atlasWidth {
+			x, y, shelf = atlasPadding, y+shelf+atlasPadding, 0
+		}
+		atlas.Glyphs = append(atlas.Glyphs, GlyphMetrics{
+			Char: string(r), X: x, Y: y, Width: w, Height: h,
+			OffsetX: x0, OffsetY: y0, Advance: font.Advance(),
+		})
+		if w > 0 {
+			x += w + atlasPadding
+		}
+		shelf = max(shelf, h)
+	}
+	atlasHeight := y + shelf + atlasPadding
+
+	atlas.Image = newPGM(atlasWidth, atlasHeight, "P2", 255)
+	for i, g := range atlas.Glyphs {
+		for dy := 0; dy < g.Height; dy++ {
+			for dx := 0; dx < g.Width; dx++ {
+				if font.Pixel(runes[i], g.OffsetX+dx, g.OffsetY+dy) {
+					atlas.Image.data[(g.Y+dy)*atlas.Image.width+g.X+dx] = 255
+				}
+			}
+		}
+	}
+	return atlas, nil
+}
@@ -0,0 +1,145 @@
+package Netpbm
+
+import (
+	"context"
+	"fmt"
+)
+
+// BinMode selects how the samples of a block are combined by Bin.
+type BinMode int
+
+const (
+	// BinAverage replaces each block with the mean of its samples, keeping the maximum value.
+	BinAverage BinMode = iota
+	// BinSum replaces each block with the sum of its samples, saturating at the maximum value.
+	BinSum
+	// BinSumRescale sums each block and raises the maximum value to hold the full sum,
+	// scaling the result down when the sum cannot be represented in 8 bits.
+	BinSumRescale
+)
+
+// binTarget returns the maximum value after binning and a function combining a block sum into a sample.
+func binTarget(mode BinMode, max uint, count int) (uint, func(sum uint) uint8, error) {
+	switch mode {
+	case BinAverage:
+		return max, func(sum uint) uint8 {
+			return uint8((sum + uint(count)/2) / uint(count))
+		}, nil
+	case BinSum:
+		return max, func(sum uint) uint8 {
+			if sum > max {
+				return uint8(max)
+			}
+			return uint8(sum)
+		}, nil
+	case BinSumRescale:
+		full := max * uint(count)
+		newMax := full
+		if newMax > 255 {
+			newMax = 255
+		}
+		return newMax, func(sum uint) uint8 {
+			if full == 0 {
+				return 0
+			}
+			return uint8((sum*newMax + full/2) / full)
+		}, nil
+	}
+	return 0, nil, fmt.Errorf("invalid bin mode: %d", mode)
+}
+
+// checkBinFactor validates a binning factor against the image dimensions.
+func checkBinFactor(factor, width, height int) error {
+	if factor < 1 {
+		return fmt.Errorf("invalid bin factor: %d", factor)
+	}
+	if factor > width || factor > height {
+		return fmt.Errorf("bin factor %d larger than image %dx%d", factor, width, height)
+	}
+	return nil
+}
+
+// Bin combines each factor x factor block of pixels into a single pixel, as done by camera
+// sensors. Incomplete blocks on the right and bottom edges are discarded.
+func (pgm *PGM) Bin(factor int, mode BinMode) error {
+	return pgm.BinCtx(context.Background(), factor, mode)
+}
+
+// BinCtx is Bin returning the error of ctx, leaving the image unchanged, if ctx is canceled
+// before all the blocks are combined.
+func (pgm *PGM) BinCtx(ctx context.Context, factor int, mode BinMode) error {
+	if err := checkBinFactor(factor, pgm.width, pgm.height); err != nil {
+		return err
+	}
+	newMax, combine, err := binTarget(mode, pgm.max, factor*factor)
+	if err != nil {
+		return err
+	}
+
+	newWidth, newHeight := pgm.width/factor, pgm.height/factor
+	binned := make([]uint8, newWidth*newHeight)
+	err = parallelRowsCtx(ctx, newHeight, func(i int) {
+		for j := 0; j < newWidth; j++ {
+			var sum uint
+			for y := i * factor; y < (i+1)*factor; y++ {
+				for x := j * factor; x < (j+1)*factor; x++ {
+					sum += uint(pgm.data[y*pgm.width+x])
+				}
+			}
+			binned[i*newWidth+j] = combine(sum)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	pgm.record("Bin", "factor", factor, "mode", mode)
+	pgm.data = binned
+	pgm.width, pgm.height = newWidth, newHeight
+	pgm.max = newMax
+	return nil
+}
+
+// Bin combines each factor x factor block of pixels into a single pixel, channel by channel.
+// Incomplete blocks on the right and bottom edges are discarded.
+func (ppm *PPM) Bin(factor int, mode BinMode) error {
+	return ppm.BinCtx(context.Background(), factor, mode)
+}
+
+// BinCtx is Bin returning the error of ctx, leaving the image unchanged, if ctx is canceled
+// before all the blocks are combined.
+func (ppm *PPM) BinCtx(ctx context.Context, factor int, mode BinMode) error {
+	if err := checkBinFactor(factor, ppm.width, ppm.height); err != nil {
+		return err
+	}
+	newMax, combine, err := binTarget(mode, uint(ppm.max), factor*factor)
+	if err != nil {
+		return err
+	}
+
+	newWidth, newHeight := ppm.width/factor, ppm.height/factor
+	binned := make([]Pixel, newWidth*newHeight)
+	err = parallelRowsCtx(ctx, newHeight, func(i int) {
+		for j := 0; j < newWidth; j++ {
+			var r, g, b uint
+			for y := i * factor; y < (i+1)*factor; y++ {
+				for x := j * factor; x < (j+1)*factor; x++ {
+					p := ppm.data[y*ppm.width+x]
+					r += uint(p.R)
+					g += uint(p.G)
+					b += uint(p.B)
+				}
+			}
+			binned[i*newWidth+j] = Pixel{combine(r), combine(g), combine(b)}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	ppm.record("Bin", "factor", factor, "mode", mode)
+	ppm.data = binned
+	ppm.width, ppm.height = newWidth, newHeight
+	ppm.max = uint8(newMax)
+	return nil
+}
@@ -0,0 +1,132 @@
+package Netpbm
+
+import "fmt"
+
+// BoundsPolicy selects what the drawing methods do with the pixels of a shape falling
+// outside the image.
+type BoundsPolicy int
+
+const (
+	// BoundsIgnore skips the pixels outside the image, the default.
+	BoundsIgnore BoundsPolicy = iota
+	// BoundsClamp draws the pixels outside the image on the nearest edge pixel.
+	BoundsClamp
+	// BoundsWrap draws the pixels outside the image on the opposite side, as on a torus,
+	// for tiling textures.
+	BoundsWrap
+	// BoundsPanic panics on the first pixel outside the image, to catch drawing bugs.
+	BoundsPanic
+)
+
+// resolve maps the pixel (x, y) to the pixel of a width x height image it stands for
+// under the policy, reporting false if it must be skipped.
+func (b BoundsPolicy) resolve(x, y, width, height int) (int, int, bool) {
+	if x >= 0 && y >= 0 && x < width && y < height {
+		return x, y, true
+	}
+	if width <= 0 || height <= 0 {
+		return x, y, false
+	}
+	switch b {
+	case BoundsClamp:
+		return clampInt(x, 0, width-1), clampInt(y, 0, height-1), true
+	case BoundsWrap:
+		return (x%width + width) % width, (y%height + height) % height, true
+	case BoundsPanic:
+		checkPixel(x, y, width, height)
+	}
+	return x, y, false
+}
+
+// checkPixel panics with a descriptive message if (x, y) lies outside a width x height
+// image, instead of an index error or, for columns past the width, a silent access to the
+// next row.
+func checkPixel(x, y, width, height int) {
+	if x < 0 || y < 0 || x >= width || y >= height {
+		panic(fmt.Sprintf("Netpbm: pixel (%d, %d) out of bounds of %dx%d image", x, y, width, height))
+	}
+}
+
+// SetBoundsPolicy sets what the drawing methods of the PBM image do with the pixels
+// outside the image.
+func (pbm *PBM) SetBoundsPolicy(policy BoundsPolicy) {
+	pbm.bounds = policy
+}
+
+// BoundsPolicy returns the out-of-bounds policy of the drawing methods of the PBM image.
+func (pbm *PBM) BoundsPolicy() BoundsPolicy {
+	return pbm.bounds
+}
+
+// SetBoundsPolicy sets what the drawing methods of the PGM image do with the pixels
+// outside the image.
+func (pgm *PGM) SetBoundsPolicy(policy BoundsPolicy) {
+	pgm.bounds = policy
+}
+
+// BoundsPolicy returns the out-of-bounds policy of the drawing methods of the PGM image.
+func (pgm *PGM) BoundsPolicy() BoundsPolicy {
+	return pgm.bounds
+}
+
+// SetBoundsPolicy sets what the drawing methods of the PPM image do with the pixels
+// outside the image. The clip rectangle applies after the policy.
+func (ppm *PPM) SetBoundsPolicy(policy BoundsPolicy) {
+	ppm.bounds = policy
+}
+
+// BoundsPolicy returns the out-of-bounds policy of the drawing methods of the PPM image.
+func (ppm *PPM) BoundsPolicy() BoundsPolicy {
+	return ppm.bounds
+}
+
+// AtOK returns the pixel value at position (x, y) of the PBM image, or false if (x, y)
+// lies outside the image.
+func (pbm *PBM) AtOK(x, y int) (bool, bool) {
+	if x < 0 || y < 0 || x >= pbm.width || y >= pbm.height {
+		return false, false
+	}
+	return pbm.data[y*pbm.width+x], true
+}
+
+// SetClamped sets the pixel value at position (x, y) of the PBM image, coordinates
+// outside the image being moved to the nearest edge pixel.
+func (pbm *PBM) SetClamped(x, y int, value bool) {
+	if x, y, ok := BoundsClamp.resolve(x, y, pbm.width, pbm.height); ok {
+		pbm.Set(x, y, value)
+	}
+}
+
+// AtOK returns the pixel value at position (x, y) of the PGM image, or false if (x, y)
+// lies outside the image.
+func (pgm *PGM) AtOK(x, y int) (uint8, bool) {
+	if x < 0 || y < 0 || x >= pgm.width || y >= pgm.height {
+		return 0, false
+	}
+	return pgm.data[y*pgm.width+x], true
+}
+
+// SetClamped sets the pixel value at position (x, y) of the PGM image, coordinates
+// outside the image being moved to the nearest edge pixel.
+func (pgm *PGM) SetClamped(x, y int, value uint8) {
+	if x, y, ok := BoundsClamp.resolve(x, y, pgm.width, pgm.height); ok {
+		pgm.Set(x, y, value)
+	}
+}
+
+// AtOK returns the color of the pixel at position (x, y) of the PPM image, or false if
+// (x, y) lies outside the image.
+func (ppm *PPM) AtOK(x, y int) (Pixel, bool) {
+	if x < 0 || y < 0 || x >= ppm.width || y >= ppm.height {
+		return Pixel{}, false
+	}
+	return ppm.data[y*ppm.width+x], true
+}
+
+// SetClamped sets the color of the pixel at position (x, y) of the PPM image, coordinates
+// outside the image being moved to the nearest edge pixel.
+func (ppm *PPM) SetClamped(x, y int, value Pixel) {
+	if x, y, ok := BoundsClamp.resolve(x, y, ppm.width, ppm.height); ok {
+		ppm.Set(x, y, value)
+	}
+}
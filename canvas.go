@@ -0,0 +1,302 @@
+package Netpbm
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Matrix is a 2D affine transform mapping (x, y) to (A*x + C*y + E, B*x + D*y + F), as in
+// the HTML canvas.
+type Matrix struct {
+	A, B, C, D, E, F float64
+}
+
+// IdentityMatrix returns the transform leaving points unchanged.
+func IdentityMatrix() Matrix {
+	return Matrix{A: 1, D: 1}
+}
+
+// Multiply returns the transform applying n first, then m.
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		A: m.A*n.A + m.C*n.B,
+		B: m.B*n.A + m.D*n.B,
+		C: m.A*n.C + m.C*n.D,
+		D: m.B*n.C + m.D*n.D,
+		E: m.A*n.E + m.C*n.F + m.E,
+		F: m.B*n.E + m.D*n.F + m.F,
+	}
+}
+
+// Apply transforms the point (x, y).
+func (m Matrix) Apply(x, y float64) (float64, float64) {
+	return m.A*x + m.C*y + m.E, m.B*x + m.D*y + m.F
+}
+
+// canvasState is the part of a Canvas saved by Save and restored by Restore.
+type canvasState struct {
+	color     Pixel
+	lineWidth int
+	transform Matrix
+	clip      image.Rectangle
+}
+
+// subpath is a part of the current path of a Canvas, in image coordinates.
+type subpath struct {
+	points []vec2
+	closed bool
+}
+
+// Canvas is a stateful drawing context over a PBM, PGM or PPM image, with a current color,
+// line width, transform and clip rectangle, and the path semantics of the HTML canvas:
+// MoveTo, LineTo and the curve methods build a path that Stroke outlines and Fill fills.
+// Colors are converted to gray levels on PGM images and to black (set) or white on PBM.
+type Canvas struct {
+	canvasState
+	width, height int
+	// begin returns the function plotting the pixels of one Stroke or Fill call, so those
+	// covered several times by the call are only modified once in the XOR and invert modes.
+	begin func() func(x, y int, color Pixel)
+	saved []canvasState
+	path  []subpath
+}
+
+// NewCanvas returns a canvas drawing on img, which must be a *PBM, *PGM or *PPM. The canvas
+// starts drawing in black with one-pixel lines and no transform.
+func NewCanvas(img Image) (*Canvas, error) {
+	c := &Canvas{canvasState: canvasState{lineWidth: 1, transform: IdentityMatrix()}}
+	switch img := img.(type) {
+	case *PPM:
+		c.begin = func() func(x, y int, color Pixel) { return img.pass().plot }
+	case *PGM:
+		maxValue := uint8(min(img.max, 255))
+		plot := func(x, y int, color Pixel) {
+			img.plot(x, y, clampUint8(Rec601Luma(color)*float64(maxValue)/255, maxValue))
+		}
+		c.begin = func() func(x, y int, color Pixel) { return plot }
+	case *PBM:
+		plot := func(x, y int, color Pixel) {
+			img.plot(x, y, Rec601Luma(color) < 128)
+		}
+		c.begin = func() func(x, y int, color Pixel) { return plot }
+	default:
+		return nil, fmt.Errorf("unsupported image type: %T", img)
+	}
+	c.width, c.height = img.Size()
+	c.clip = image.Rect(0, 0, c.width, c.height)
+	return c, nil
+}
+
+// SetColor sets the color used by Stroke and Fill.
+func (c *Canvas) SetColor(color Pixel) {
+	c.color = color
+}
+
+// SetLineWidth sets the width, in pixels, of the lines drawn by Stroke. It is not affected
+// by the transform.
+func (c *Canvas) SetLineWidth(width int) {
+	c.lineWidth = max(width, 1)
+}
+
+// SetTransform replaces the current transform, applied to the points added to the path.
+func (c *Canvas) SetTransform(m Matrix) {
+	c.transform = m
+}
+
+// Transform multiplies the current transform by m, which applies first.
+func (c *Canvas) Transform(m Matrix) {
+	c.transform = c.transform.Multiply(m)
+}
+
+// Translate moves the origin of the coordinates by (tx, ty).
+func (c *Canvas) Translate(tx, ty float64) {
+	c.Transform(Matrix{A: 1, D: 1, E: tx, F: ty})
+}
+
+// Scale scales the coordinates by sx horizontally and sy vertically.
+func (c *Canvas) Scale(sx, sy float64) {
+	c.Transform(Matrix{A: sx, D: sy})
+}
+
+// Rotate rotates the coordinates by angle, in degrees clockwise as the y axis points down.
+func (c *Canvas) Rotate(angle float64) {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	c.Transform(Matrix{A: cos, B: sin, C: -sin, D: cos})
+}
+
+// Clip restricts drawing to the intersection of the current clip rectangle and r, in image
+// coordinates.
+func (c *Canvas) Clip(r image.Rectangle) {
+	c.clip = c.clip.Intersect(r.Canon())
+}
+
+// Save pushes the color, line width, transform and clip rectangle on a stack.
+func (c *Canvas) Save() {
+	c.saved = append(c.saved, c.canvasState)
+}
+
+// Restore pops the state pushed by the last Save. It does nothing if the stack is empty.
+func (c *Canvas) Restore() {
+	if len(c.saved) == 0 {
+		return
+	}
+	c.canvasState = c.saved[len(c.saved)-1]
+	c.saved = c.saved[:len(c.saved)-1]
+}
+
+// BeginPath empties the current path.
+func (c *Canvas) BeginPath() {
+	c.path = c.path[:0]
+}
+
+// MoveTo starts a new subpath at (x, y).
+func (c *Canvas) MoveTo(x, y float64) {
+	px, py := c.transform.Apply(x, y)
+	c.path = append(c.path, subpath{points: []vec2{{px, py}}})
+}
+
+// current returns the subpath being built, starting one at p if there is none.
+func (c *Canvas) current(p vec2) *subpath {
+	if len(c.path) == 0 || c.path[len(c.path)-1].closed {
+		start := p
+		if len(c.path) > 0 {
+			// A closed subpath is followed by one starting at its first point.
+			start = c.path[len(c.path)-1].points[0]
+		}
+		c.path = append(c.path, subpath{points: []vec2{start}})
+	}
+	return &c.path[len(c.path)-1]
+}
+
+// LineTo adds a straight line from the current point to (x, y).
+func (c *Canvas) LineTo(x, y float64) {
+	px, py := c.transform.Apply(x, y)
+	s := c.current(vec2{px, py})
+	s.points = append(s.points, vec2{px, py})
+}
+
+// QuadraticCurveTo adds a quadratic Bezier curve from the current point to (x, y) with
+// control point (cx, cy).
+func (c *Canvas) QuadraticCurveTo(cx, cy, x, y float64) {
+	ctrl, end := c.point(cx, cy), c.point(x, y)
+	s := c.current(ctrl)
+	start := s.points[len(s.points)-1]
+	s.points = flattenCubic(s.points, start, lerp2(start, ctrl, 2.0/3), lerp2(end, ctrl, 2.0/3), end, 0)
+}
+
+// BezierCurveTo adds a cubic Bezier curve from the current point to (x, y) with control
+// points (c1x, c1y) and (c2x, c2y).
+func (c *Canvas) BezierCurveTo(c1x, c1y, c2x, c2y, x, y float64) {
+	c1, c2, end := c.point(c1x, c1y), c.point(c2x, c2y), c.point(x, y)
+	s := c.current(c1)
+	s.points = flattenCubic(s.points, s.points[len(s.points)-1], c1, c2, end, 0)
+}
+
+// Arc adds an arc of the circle of center (cx, cy) and the given radius, from startAngle to
+// endAngle in degrees clockwise from the x axis, joined to the current point by a line.
+func (c *Canvas) Arc(cx, cy, radius, startAngle, endAngle float64) {
+	sweep := (endAngle - startAngle) * math.Pi / 180
+	start := startAngle * math.Pi / 180
+	// Enough segments to keep the chords within curveTolerance of the circle, with the
+	// radius taken after the transform.
+	scale := math.Sqrt(math.Abs(c.transform.A*c.transform.D - c.transform.B*c.transform.C))
+	step := 2 * math.Acos(math.Max(-1, 1-curveTolerance/math.Max(radius*scale, curveTolerance)))
+	n := max(int(math.Ceil(math.Abs(sweep)/step)), 1)
+	for i := 0; i <= n; i++ {
+		a := start + sweep*float64(i)/float64(n)
+		p := c.point(cx+radius*math.Cos(a), cy+radius*math.Sin(a))
+		s := c.current(p)
+		s.points = append(s.points, p)
+	}
+}
+
+// Rect adds the closed subpath of the rectangle of corner (x, y) and size w x h.
+func (c *Canvas) Rect(x, y, w, h float64) {
+	c.MoveTo(x, y)
+	c.LineTo(x+w, y)
+	c.LineTo(x+w, y+h)
+	c.LineTo(x, y+h)
+	c.ClosePath()
+}
+
+// ClosePath closes the current subpath with a line back to its first point.
+func (c *Canvas) ClosePath() {
+	if len(c.path) > 0 {
+		c.path[len(c.path)-1].closed = true
+	}
+}
+
+// point transforms (x, y) to image coordinates.
+func (c *Canvas) point(x, y float64) vec2 {
+	px, py := c.transform.Apply(x, y)
+	return vec2{px, py}
+}
+
+// clippedPlotter returns the function plotting pixels of the current color inside the clip
+// rectangle, for one drawing call.
+func (c *Canvas) clippedPlotter() func(x, y int) {
+	plot, color, clip := c.begin(), c.color, c.clip
+	return func(x, y int) {
+		if image.Pt(x, y).In(clip) {
+			plot(x, y, color)
+		}
+	}
+}
+
+// Stroke draws the outline of the current path with the current color and line width.
+func (c *Canvas) Stroke() {
+	style := StrokeStyle{Width: c.lineWidth, Cap: ButtCap, Join: MiterJoin}
+	plotClipped := c.clippedPlotter()
+	for _, s := range c.path {
+		points := make([]Point, 0, len(s.points))
+		for _, v := range s.points {
+			p := Point{int(math.Round(v[0])), int(math.Round(v[1]))}
+			if len(points) == 0 || points[len(points)-1] != p {
+				points = append(points, p)
+			}
+		}
+		if style.Width <= 1 {
+			strokeThin(points, s.closed, style, plotClipped)
+		} else {
+			strokeWide(points, s.closed, style, plotClipped)
+		}
+	}
+}
+
+// Fill fills the current path with the current color, closing every subpath and following
+// the even–odd rule, so subpaths inside others make holes.
+func (c *Canvas) Fill() {
+	polygons := make([][][2]float64, 0, len(c.path))
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, s := range c.path {
+		if len(s.points) < 3 {
+			continue
+		}
+		polygon := make([][2]float64, len(s.points))
+		for i, v := range s.points {
+			polygon[i] = v
+			minX, maxX = math.Min(minX, v[0]), math.Max(maxX, v[0])
+			minY, maxY = math.Min(minY, v[1]), math.Max(maxY, v[1])
+		}
+		polygons = append(polygons, polygon)
+	}
+	if len(polygons) == 0 {
+		return
+	}
+	plot := c.begin()
+	area := image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1).Intersect(c.clip)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			inside := false
+			for _, polygon := range polygons {
+				if insidePolygon(polygon, float64(x), float64(y)) {
+					inside = !inside
+				}
+			}
+			if inside {
+				plot(x, y, c.color)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package Netpbm
+
+import "fmt"
+
+// Channel identifies one color plane of a PPM image.
+type Channel int
+
+const (
+	// RedChannel is the red plane of a PPM image.
+	RedChannel Channel = iota
+	// GreenChannel is the green plane of a PPM image.
+	GreenChannel
+	// BlueChannel is the blue plane of a PPM image.
+	BlueChannel
+)
+
+// String returns the name of the channel.
+func (c Channel) String() string {
+	switch c {
+	case RedChannel:
+		return "red"
+	case GreenChannel:
+		return "green"
+	case BlueChannel:
+		return "blue"
+	}
+	return fmt.Sprintf("Channel(%d)", int(c))
+}
+
+// get returns the sample of p for the channel.
+func (c Channel) get(p Pixel) uint8 {
+	switch c {
+	case GreenChannel:
+		return p.G
+	case BlueChannel:
+		return p.B
+	}
+	return p.R
+}
+
+// Channel extracts a single color plane of the PPM image as a PGM image.
+func (ppm *PPM) Channel(c Channel) *PGM {
+	pgm := &PGM{
+		width:       ppm.width,
+		height:      ppm.height,
+		magicNumber: "P2",
+		max:         uint(ppm.max),
+		data:        make([]uint8, len(ppm.data)),
+	}
+	for i, p := range ppm.data {
+		pgm.data[i] = c.get(p)
+	}
+	return pgm
+}
+
+// MergeChannels combines three PGM images of the same size into a PPM image,
+// using them as the red, green and blue planes.
+func MergeChannels(r, g, b *PGM) (*PPM, error) {
+	if r.width != g.width || r.width != b.width || r.height != g.height || r.height != b.height {
+		return nil, fmt.Errorf("channel sizes differ: %dx%d, %dx%d, %dx%d", r.width, r.height, g.width, g.height, b.width, b.height)
+	}
+	if r.max != g.max || r.max != b.max {
+		return nil, fmt.Errorf("channel maximum values differ: %d, %d, %d", r.max, g.max, b.max)
+	}
+	if r.max > 255 {
+		return nil, fmt.Errorf("maximum value too large for PPM: %d", r.max)
+	}
+
+	ppm := &PPM{
+		width:       r.width,
+		height:      r.height,
+		magicNumber: "P3",
+		max:         uint8(r.max),
+		data:        make([]Pixel, len(r.data)),
+	}
+	for i := range ppm.data {
+		ppm.data[i] = Pixel{r.data[i], g.data[i], b.data[i]}
+	}
+	return ppm, nil
+}
+
+// SwapChannels reorders the color channels of the PPM image. The order is a permutation
+// of "RGB" naming, for each output channel, the input channel it is taken from; "BGR"
+// exchanges red and blue.
+func (ppm *PPM) SwapChannels(order string) error {
+	sources, err := parseChannelOrder(order)
+	if err != nil {
+		return err
+	}
+	ppm.record("SwapChannels", "order", order)
+	ppm.own()
+
+	parallelRows(ppm.height, func(i int) {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			ppm.data[i*ppm.width+j] = Pixel{sources[0].get(p), sources[1].get(p), sources[2].get(p)}
+		}
+	})
+	return nil
+}
+
+// parseChannelOrder returns the source channel of each output channel of a SwapChannels
+// order.
+func parseChannelOrder(order string) ([3]Channel, error) {
+	var sources [3]Channel
+	if len(order) != 3 {
+		return sources, fmt.Errorf("invalid channel order: %q", order)
+	}
+	var seen [3]bool
+	for i, c := range []byte(order) {
+		switch c {
+		case 'R', 'r':
+			sources[i] = RedChannel
+		case 'G', 'g':
+			sources[i] = GreenChannel
+		case 'B', 'b':
+			sources[i] = BlueChannel
+		default:
+			return sources, fmt.Errorf("invalid channel order: %q", order)
+		}
+		if seen[sources[i]] {
+			return sources, fmt.Errorf("invalid channel order: %q", order)
+		}
+		seen[sources[i]] = true
+	}
+	return sources, nil
+}
+
+// SepiaMatrix is the color matrix producing the classic sepia tone with ApplyColorMatrix.
+var SepiaMatrix = [3][3]float64{
+	{0.393, 0.769, 0.189},
+	{0.349, 0.686, 0.168},
+	{0.272, 0.534, 0.131},
+}
+
+// ApplyColorMatrix transforms every pixel of the PPM image by the linear color matrix m,
+// then adds offset. Each row of m gives the weights of the input R, G and B samples for
+// one output channel; offset is expressed in sample units. Results are clamped to the
+// maximum value.
+func (ppm *PPM) ApplyColorMatrix(m [3][3]float64, offset [3]float64) {
+	ppm.record("ApplyColorMatrix", "matrix", m, "offset", offset)
+	ppm.own()
+	parallelRows(ppm.height, func(i int) {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			in := [3]float64{float64(p.R), float64(p.G), float64(p.B)}
+			var out [3]uint8
+			for c := 0; c < 3; c++ {
+				v := m[c][0]*in[0] + m[c][1]*in[1] + m[c][2]*in[2] + offset[c]
+				out[c] = clampUint8(v, ppm.max)
+			}
+			ppm.data[i*ppm.width+j] = Pixel{out[0], out[1], out[2]}
+		}
+	})
+}
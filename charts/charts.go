@@ -0,0 +1,328 @@
+// Package charts renders bar charts, line plots and histograms onto Netpbm images, with
+// axes, ticks and labels drawn with the built-in bitmap font, so monitoring tools can emit
+// charts without any dependency.
+//
+// Every chart is drawn inside a rectangle of the image, which it clears with the
+// background color first:
+//
+//	img := Netpbm.RandomPPM(320, 200, 0)
+//	err := charts.BarChart(img, image.Rect(0, 0, 320, 200), []string{"a", "b"}, []float64{3, 5}, charts.DefaultOptions())
+package charts
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+
+	Netpbm "nom_du_module"
+)
+
+// Layout of the charts, in pixels.
+const (
+	padding   = 4
+	tickSize  = 3
+	maxTicks  = 6
+	barGap    = 2
+	minPlotPx = 8
+	// xTickSpacing is the smallest distance between the labeled ticks of horizontal axes.
+	xTickSpacing = 24
+)
+
+// Options sets the title and colors of a chart.
+type Options struct {
+	Title string
+	// Background fills the chart area; Foreground draws the axes, ticks and labels.
+	Background, Foreground Netpbm.Pixel
+	// Colors are used in turn for the bars or the series.
+	Colors []Netpbm.Pixel
+}
+
+// DefaultOptions returns black axes and labels on a white background with a palette
+// telling the series apart.
+func DefaultOptions() Options {
+	return Options{
+		Background: Netpbm.Pixel{R: 255, G: 255, B: 255},
+		Colors: []Netpbm.Pixel{
+			{R: 31, G: 119, B: 180}, {R: 255, G: 127, B: 14}, {R: 44, G: 160, B: 44},
+			{R: 214, G: 39, B: 40}, {R: 148, G: 103, B: 189}, {R: 140, G: 86, B: 75},
+		},
+	}
+}
+
+// color returns the color of the i-th bar or series.
+func (o Options) color(i int) Netpbm.Pixel {
+	if len(o.Colors) == 0 {
+		return o.Foreground
+	}
+	return o.Colors[i%len(o.Colors)]
+}
+
+// frame is the plotting area of a chart with its vertical scale.
+type frame struct {
+	img        *Netpbm.PPM
+	plot       image.Rectangle
+	yMin, yMax float64
+}
+
+// y returns the row of the value v.
+func (f frame) y(v float64) int {
+	t := (v - f.yMin) / (f.yMax - f.yMin)
+	return f.plot.Max.Y - 1 - int(math.Round(t*float64(f.plot.Dy()-1)))
+}
+
+// newFrame clears area, draws the title and the vertical axis for values between yMin and
+// yMax, and returns the area left for the data. xLabels reserves room for labels under the
+// horizontal axis.
+func newFrame(img *Netpbm.PPM, area image.Rectangle, yMin, yMax float64, xLabels bool, opts Options) (frame, error) {
+	area = area.Canon()
+	if yMin == yMax {
+		yMin, yMax = yMin-1, yMax+1
+	}
+	_, textHeight := Netpbm.MeasureText("0", 1)
+	top, bottom := area.Min.Y+padding+textHeight/2, area.Max.Y-padding-tickSize-1
+	if opts.Title != "" {
+		top += textHeight + padding
+	}
+	if xLabels {
+		bottom -= textHeight + 1
+	}
+	if bottom-top < minPlotPx {
+		return frame{}, fmt.Errorf("chart area too small: %dx%d", area.Dx(), area.Dy())
+	}
+	// As many ticks as their labels fit in the height.
+	ticks, step := niceTicks(yMin, yMax, clampTicks((bottom-top)/(textHeight+2)+1))
+	if len(ticks) == 0 {
+		return frame{}, fmt.Errorf("cannot scale values between %v and %v", yMin, yMax)
+	}
+	yMin, yMax = math.Min(yMin, ticks[0]), math.Max(yMax, ticks[len(ticks)-1])
+
+	labelWidth := 0
+	for _, t := range ticks {
+		w, _ := Netpbm.MeasureText(formatTick(t, step), 1)
+		labelWidth = max(labelWidth, w)
+	}
+	plot := image.Rect(area.Min.X+padding+labelWidth+tickSize+1, top, area.Max.X-padding, bottom)
+	if plot.Dx() < minPlotPx {
+		return frame{}, fmt.Errorf("chart area too small: %dx%d", area.Dx(), area.Dy())
+	}
+
+	img.DrawFilledRectangle(Netpbm.Point{X: area.Min.X, Y: area.Min.Y}, area.Dx(), area.Dy(), opts.Background)
+	if opts.Title != "" {
+		w, _ := Netpbm.MeasureText(opts.Title, 1)
+		img.DrawText(Netpbm.Point{X: area.Min.X + (area.Dx()-w)/2, Y: area.Min.Y + padding}, opts.Title, opts.Foreground, 1)
+	}
+	f := frame{img: img, plot: plot, yMin: yMin, yMax: yMax}
+	axisX := plot.Min.X - 1
+	img.DrawLine(Netpbm.Point{X: axisX, Y: plot.Min.Y}, Netpbm.Point{X: axisX, Y: plot.Max.Y}, opts.Foreground)
+	img.DrawLine(Netpbm.Point{X: axisX, Y: plot.Max.Y}, Netpbm.Point{X: plot.Max.X - 1, Y: plot.Max.Y}, opts.Foreground)
+	for _, t := range ticks {
+		y := f.y(t)
+		img.DrawLine(Netpbm.Point{X: axisX - tickSize, Y: y}, Netpbm.Point{X: axisX, Y: y}, opts.Foreground)
+		label := formatTick(t, step)
+		w, _ := Netpbm.MeasureText(label, 1)
+		img.DrawText(Netpbm.Point{X: axisX - tickSize - 1 - w, Y: y - textHeight/2}, label, opts.Foreground, 1)
+	}
+	return f, nil
+}
+
+// xTick draws a tick of the horizontal axis at column x with a label centered under it.
+func (f frame) xTick(x int, label string, opts Options) {
+	f.img.DrawLine(Netpbm.Point{X: x, Y: f.plot.Max.Y}, Netpbm.Point{X: x, Y: f.plot.Max.Y + tickSize}, opts.Foreground)
+	w, _ := Netpbm.MeasureText(label, 1)
+	f.img.DrawText(Netpbm.Point{X: x - w/2, Y: f.plot.Max.Y + tickSize + 1}, label, opts.Foreground, 1)
+}
+
+// clampTicks keeps a number of ticks between 2 and maxTicks.
+func clampTicks(n int) int {
+	return min(max(n, 2), maxTicks)
+}
+
+// niceTicks returns about n round values, multiples of 1, 2 or 5 times a power of ten,
+// covering [lo, hi], with the step between them.
+func niceTicks(lo, hi float64, n int) ([]float64, float64) {
+	raw := (hi - lo) / float64(n-1)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * magnitude
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+	var ticks []float64
+	for v := math.Floor(lo/step) * step; v <= hi+step/2; v += step {
+		ticks = append(ticks, v)
+		if v >= hi {
+			break
+		}
+	}
+	return ticks, step
+}
+
+// formatTick formats a tick value with as many decimals as the step needs.
+func formatTick(v, step float64) string {
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	if math.Abs(v) < step/2 {
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// finite reports whether v is neither NaN nor infinite. Non-finite values, such as the NaN
+// gaps of monitoring series, are left out of the charts.
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// valueRange returns the smallest and largest finite values, including zero if withZero is
+// set, or an error if there are no finite values.
+func valueRange(withZero bool, series ...[]float64) (float64, float64, error) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, v := range s {
+			if finite(v) {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("no finite values")
+	}
+	if withZero {
+		lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	}
+	return lo, hi, nil
+}
+
+// BarChart draws one bar per value inside area of the image, labeled with labels if given.
+// Bars start from zero, so negative values go down; NaN and infinite values have no bar.
+func BarChart(img *Netpbm.PPM, area image.Rectangle, labels []string, values []float64, opts Options) error {
+	if len(values) == 0 {
+		return fmt.Errorf("no values")
+	}
+	if labels != nil && len(labels) != len(values) {
+		return fmt.Errorf("%d labels for %d values", len(labels), len(values))
+	}
+	lo, hi, err := valueRange(true, values)
+	if err != nil {
+		return err
+	}
+	f, err := newFrame(img, area, lo, hi, labels != nil, opts)
+	if err != nil {
+		return err
+	}
+	slot := float64(f.plot.Dx()) / float64(len(values))
+	zero := f.y(0)
+	for i, v := range values {
+		x0 := f.plot.Min.X + int(math.Round(float64(i)*slot)) + barGap
+		x1 := f.plot.Min.X + int(math.Round(float64(i+1)*slot)) - barGap
+		if finite(v) {
+			top, bottom := min(zero, f.y(v)), max(zero, f.y(v))
+			img.DrawFilledRectangle(Netpbm.Point{X: x0, Y: top}, max(x1-x0, 1), bottom-top+1, opts.color(i))
+		}
+		if labels != nil {
+			f.xTick((x0+x1)/2, labels[i], opts)
+		}
+	}
+	return nil
+}
+
+// LinePlot draws every series as a line through its values, evenly spaced horizontally,
+// inside area of the image. The horizontal axis is labeled with the indices of the values.
+// Lines are broken at NaN and infinite values.
+func LinePlot(img *Netpbm.PPM, area image.Rectangle, series [][]float64, opts Options) error {
+	n := 0
+	for _, s := range series {
+		n = max(n, len(s))
+	}
+	if n == 0 {
+		return fmt.Errorf("no values")
+	}
+	lo, hi, err := valueRange(false, series...)
+	if err != nil {
+		return err
+	}
+	f, err := newFrame(img, area, lo, hi, true, opts)
+	if err != nil {
+		return err
+	}
+	x := func(i float64) int {
+		if n == 1 {
+			return f.plot.Min.X + f.plot.Dx()/2
+		}
+		return f.plot.Min.X + int(math.Round(i*float64(f.plot.Dx()-1)/float64(n-1)))
+	}
+	ticks, step := niceTicks(0, math.Max(float64(n-1), 1), clampTicks(f.plot.Dx()/xTickSpacing))
+	for _, t := range ticks {
+		if t <= float64(n-1) {
+			f.xTick(x(t), formatTick(t, step), opts)
+		}
+	}
+	for i, s := range series {
+		var points []Netpbm.Point
+		for j, v := range s {
+			if finite(v) {
+				points = append(points, Netpbm.Point{X: x(float64(j)), Y: f.y(v)})
+				continue
+			}
+			img.DrawPolylineStyled(points, Netpbm.StrokeStyle{}, opts.color(i))
+			points = points[:0]
+		}
+		img.DrawPolylineStyled(points, Netpbm.StrokeStyle{}, opts.color(i))
+	}
+	return nil
+}
+
+// Histogram draws the distribution of data in the given number of bins of equal width,
+// between its smallest and largest values, inside area of the image. NaN and infinite
+// values are not counted.
+func Histogram(img *Netpbm.PPM, area image.Rectangle, data []float64, bins int, opts Options) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no values")
+	}
+	if bins <= 0 {
+		return fmt.Errorf("invalid number of bins: %d", bins)
+	}
+	lo, hi, err := valueRange(false, data)
+	if err != nil {
+		return err
+	}
+	counts := make([]float64, bins)
+	for _, v := range data {
+		if !finite(v) {
+			continue
+		}
+		b := bins - 1
+		if hi > lo {
+			b = min(int((v-lo)/(hi-lo)*float64(bins)), bins-1)
+		}
+		counts[b]++
+	}
+	_, maxCount, _ := valueRange(true, counts)
+	f, err := newFrame(img, area, 0, maxCount, true, opts)
+	if err != nil {
+		return err
+	}
+	width := float64(f.plot.Dx()) / float64(bins)
+	for i, c := range counts {
+		x0 := f.plot.Min.X + int(math.Round(float64(i)*width))
+		x1 := f.plot.Min.X + int(math.Round(float64(i+1)*width))
+		if c > 0 {
+			top := f.y(c)
+			img.DrawFilledRectangle(Netpbm.Point{X: x0, Y: top}, max(x1-x0-1, 1), f.plot.Max.Y-top, opts.color(0))
+		}
+	}
+	// Label the horizontal axis with the data values at round positions.
+	if hi > lo {
+		ticks, step := niceTicks(lo, hi, clampTicks(f.plot.Dx()/xTickSpacing))
+		for _, t := range ticks {
+			if t >= lo && t <= hi {
+				f.xTick(f.plot.Min.X+int(math.Round((t-lo)/(hi-lo)*float64(f.plot.Dx()-1))), formatTick(t, step), opts)
+			}
+		}
+	} else {
+		f.xTick(f.plot.Min.X+f.plot.Dx()/2, formatTick(lo, 1), opts)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package Netpbm
+
+import "image"
+
+// SetClip restricts the drawing methods of the PPM image to the rectangle r; pixels drawn
+// outside it are left untouched. Drawing is always clipped to the image bounds.
+func (ppm *PPM) SetClip(r image.Rectangle) {
+	r = r.Canon()
+	ppm.clip = &r
+}
+
+// ResetClip removes the clip rectangle of the PPM image, so drawing covers the whole image.
+func (ppm *PPM) ResetClip() {
+	ppm.clip = nil
+}
+
+// Clip returns the area of the PPM image the drawing methods can modify: the clip rectangle
+// intersected with the image bounds.
+func (ppm *PPM) Clip() image.Rectangle {
+	bounds := image.Rect(0, 0, ppm.width, ppm.height)
+	if ppm.clip == nil {
+		return bounds
+	}
+	return ppm.clip.Intersect(bounds)
+}
+
+// visible reports whether the pixel (x, y) can be drawn.
+func (ppm *PPM) visible(x, y int) bool {
+	if x < 0 || y < 0 || x >= ppm.width || y >= ppm.height {
+		return false
+	}
+	return ppm.clip == nil || image.Pt(x, y).In(*ppm.clip)
+}
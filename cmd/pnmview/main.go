@@ -0,0 +1,374 @@
+// Command pnmview is an interactive terminal viewer for Netpbm images, useful to inspect
+// pipeline output over SSH. It needs a terminal supporting 24-bit colors and the stty
+// command to switch the terminal to raw mode.
+//
+// Usage:
+//
+//	pnmview [-sixel] [-cell WxH] image.ppm
+//
+// With -sixel the image is drawn as a sixel graphic at full resolution instead of with
+// colored characters; -cell gives the size in pixels of a character cell of the terminal,
+// 10x20 by default.
+//
+// Keys: arrows or h/j/k/l pan, + and - zoom, 0 resets the view, i toggles the pixel
+// inspector, g toggles the histogram panel, q quits.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"strings"
+
+	Netpbm "nom_du_module"
+)
+
+// histogramRows is the height, in terminal lines, of the histogram panel.
+const histogramRows = 6
+
+// viewer holds the state of the interactive session.
+type viewer struct {
+	img           *Netpbm.PPM
+	name          string
+	cols, rows    int
+	zoom          int // Terminal cells per image pixel when positive, image pixels per cell when negative
+	centerX       int
+	centerY       int
+	showInspector bool
+	showHistogram bool
+	sixel         bool
+	cellW, cellH  int // Size of a terminal cell in pixels, for sixel rendering
+}
+
+func main() {
+	sixel := flag.Bool("sixel", false, "draw the image as a sixel graphic")
+	cell := flag.String("cell", "10x20", "size in pixels of a terminal cell, for -sixel")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: pnmview [-sixel] [-cell WxH] image.pnm")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var cellW, cellH int
+	if n, _ := fmt.Sscanf(*cell, "%dx%d", &cellW, &cellH); n != 2 || cellW < 1 || cellH < 1 {
+		fmt.Fprintf(os.Stderr, "pnmview: invalid cell size %q\n", *cell)
+		os.Exit(2)
+	}
+	img, err := load(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pnmview: %v\n", err)
+		os.Exit(1)
+	}
+
+	v := &viewer{img: img, name: flag.Arg(0), showInspector: true, sixel: *sixel, cellW: cellW, cellH: cellH}
+	v.reset()
+	if err := v.run(); err != nil {
+		fmt.Fprintf(os.Stderr, "pnmview: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// load reads any supported Netpbm file and converts it to a PPM image.
+func load(filename string) (*Netpbm.PPM, error) {
+	img, err := Netpbm.ReadPNM(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch img := img.(type) {
+	case *Netpbm.PBM:
+		return img.ToPPM(), nil
+	case *Netpbm.PGM:
+		return img.ToPPM(), nil
+	case *Netpbm.PPM:
+		return img, nil
+	}
+	return nil, fmt.Errorf("unsupported image type %T", img)
+}
+
+// terminalSize returns the size of the terminal, falling back to 80x24.
+func terminalSize() (int, int) {
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err == nil {
+		var rows, cols int
+		if _, err := fmt.Sscanf(string(out), "%d %d", &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return cols, rows
+		}
+	}
+	return 80, 24
+}
+
+// stty runs stty with the given arguments on the controlling terminal.
+func stty(args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// reset fits the whole image in the terminal.
+func (v *viewer) reset() {
+	v.cols, v.rows = terminalSize()
+	w, h := v.img.Size()
+	v.centerX, v.centerY = w/2, h/2
+	v.zoom = 1
+	cols, rows := v.viewSize()
+	for v.zoom > -64 && (w > v.span(cols) || h > v.span(2*rows)) {
+		v.zoomOut()
+	}
+}
+
+// viewSize returns the number of terminal cells available for the image.
+func (v *viewer) viewSize() (int, int) {
+	rows := v.rows - 1 // status line
+	if v.showHistogram {
+		rows -= histogramRows + 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	return v.cols, rows
+}
+
+// span converts a number of terminal pixels to image pixels at the current zoom.
+func (v *viewer) span(cells int) int {
+	if v.zoom > 0 {
+		return (cells + v.zoom - 1) / v.zoom
+	}
+	return cells * -v.zoom
+}
+
+// zoomIn enlarges the image, skipping the -1 level which is the same as 1.
+func (v *viewer) zoomIn() {
+	switch {
+	case v.zoom == -2:
+		v.zoom = 1
+	case v.zoom < 32:
+		v.zoom++
+	}
+}
+
+// zoomOut reduces the image, skipping the -1 level which is the same as 1.
+func (v *viewer) zoomOut() {
+	if v.zoom == 1 {
+		v.zoom = -2
+	} else {
+		v.zoom--
+	}
+}
+
+// viewport returns the image region shown on screen.
+func (v *viewer) viewport() image.Rectangle {
+	cols, rows := v.viewSize()
+	w, h := v.span(cols), v.span(2*rows)
+	return image.Rect(v.centerX-w/2, v.centerY-h/2, v.centerX-w/2+w, v.centerY-h/2+h)
+}
+
+// run switches the terminal to raw mode and processes key presses until the user quits.
+func (v *viewer) run() error {
+	if err := stty("raw", "-echo"); err != nil {
+		return fmt.Errorf("cannot switch terminal to raw mode: %v", err)
+	}
+	defer stty("sane")
+	defer fmt.Print("\x1b[?25h\x1b[0m\x1b[2J\x1b[H")
+	fmt.Print("\x1b[?25l")
+
+	input := bufio.NewReader(os.Stdin)
+	for {
+		if err := v.draw(); err != nil {
+			return err
+		}
+		key, err := readKey(input)
+		if err != nil {
+			return err
+		}
+		step := v.span(v.cols) / 8
+		if step < 1 {
+			step = 1
+		}
+		w, h := v.img.Size()
+		switch key {
+		case "q", "\x03":
+			return nil
+		case "left", "h":
+			v.centerX -= step
+		case "right", "l":
+			v.centerX += step
+		case "up", "k":
+			v.centerY -= step
+		case "down", "j":
+			v.centerY += step
+		case "+", "=":
+			v.zoomIn()
+		case "-":
+			v.zoomOut()
+		case "0":
+			v.reset()
+		case "i":
+			v.showInspector = !v.showInspector
+		case "g":
+			v.showHistogram = !v.showHistogram
+		}
+		v.centerX = clamp(v.centerX, 0, w-1)
+		v.centerY = clamp(v.centerY, 0, h-1)
+	}
+}
+
+// readKey reads one key press, decoding arrow key escape sequences.
+func readKey(input *bufio.Reader) (string, error) {
+	c, err := input.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if c != 0x1b || input.Buffered() < 2 {
+		return string(c), nil
+	}
+	seq := make([]byte, 2)
+	if _, err := input.Read(seq); err != nil {
+		return "", err
+	}
+	switch string(seq) {
+	case "[A":
+		return "up", nil
+	case "[B":
+		return "down", nil
+	case "[C":
+		return "right", nil
+	case "[D":
+		return "left", nil
+	}
+	return "", nil
+}
+
+// draw renders the image, the optional histogram panel and the status line.
+func (v *viewer) draw() error {
+	var screen strings.Builder
+	screen.WriteString("\x1b[H")
+
+	cols, rows := v.viewSize()
+	view := v.viewport()
+	w, h := v.img.Size()
+	visible := view.Intersect(image.Rect(0, 0, w, h))
+	if err := v.renderView(&screen, view, visible, cols, rows); err != nil {
+		return err
+	}
+	if v.showHistogram {
+		v.drawHistogram(&screen)
+	}
+
+	status := fmt.Sprintf("%s %dx%d zoom %s", v.name, w, h, zoomLabel(v.zoom))
+	if v.showInspector {
+		p := v.img.At(v.centerX, v.centerY)
+		status += fmt.Sprintf(" | (%d,%d) R=%d G=%d B=%d", v.centerX, v.centerY, p.R, p.G, p.B)
+	}
+	status += " | arrows pan, +/- zoom, i g q"
+	if len(status) > v.cols {
+		status = status[:v.cols]
+	}
+	screen.WriteString("\x1b[7m" + status + "\x1b[0m\x1b[K")
+	_, err := os.Stdout.WriteString(screen.String())
+	return err
+}
+
+// renderView renders the visible part of the viewport, padding areas outside the image.
+func (v *viewer) renderView(screen *strings.Builder, view, visible image.Rectangle, cols, rows int) error {
+	if visible.Empty() {
+		for r := 0; r < rows; r++ {
+			screen.WriteString("\x1b[K\r\n")
+		}
+		return nil
+	}
+	// Cells covered by the visible region, positioned within the viewport.
+	left := (visible.Min.X - view.Min.X) * cols / view.Dx()
+	top := (visible.Min.Y - view.Min.Y) * rows / view.Dy()
+	width := visible.Dx() * cols / view.Dx()
+	height := visible.Dy() * rows / view.Dy()
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	if v.sixel {
+		for r := 0; r < rows; r++ {
+			screen.WriteString("\x1b[K\r\n")
+		}
+		// The graphic is drawn over the cleared cells, the cursor going back below them.
+		fmt.Fprintf(screen, "\x1b7\x1b[%d;%dH", top+1, left+1)
+		if err := v.img.RenderSixel(screen, visible, width*v.cellW, height*v.cellH); err != nil {
+			return err
+		}
+		screen.WriteString("\x1b8")
+		return nil
+	}
+
+	var rendered strings.Builder
+	if err := v.img.RenderANSI(&rendered, visible, width, height); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(rendered.String(), "\r\n"), "\r\n")
+	for r := 0; r < rows; r++ {
+		screen.WriteString("\x1b[K")
+		if i := r - top; i >= 0 && i < len(lines) {
+			screen.WriteString(strings.Repeat(" ", left) + lines[i])
+		}
+		screen.WriteString("\r\n")
+	}
+	return nil
+}
+
+// drawHistogram draws a luminance histogram of the whole image as vertical bars.
+func (v *viewer) drawHistogram(screen *strings.Builder) {
+	bins := make([]int, v.cols)
+	w, h := v.img.Size()
+	peak := 1
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := v.img.At(x, y)
+			lum := (299*int(p.R) + 587*int(p.G) + 114*int(p.B)) / 1000
+			bin := lum * len(bins) / 256
+			bins[bin]++
+			if bins[bin] > peak {
+				peak = bins[bin]
+			}
+		}
+	}
+
+	screen.WriteString("\x1b[K\r\n")
+	for r := histogramRows; r > 0; r-- {
+		screen.WriteString("\x1b[K")
+		for _, count := range bins {
+			if count*histogramRows >= r*peak {
+				screen.WriteString("█")
+			} else {
+				screen.WriteString(" ")
+			}
+		}
+		screen.WriteString("\r\n")
+	}
+}
+
+// zoomLabel formats a zoom level for the status line.
+func zoomLabel(zoom int) string {
+	if zoom > 0 {
+		return fmt.Sprintf("%dx", zoom)
+	}
+	return fmt.Sprintf("1/%d", -zoom)
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,183 @@
+//go:build js && wasm
+
+// Command pnmwasm exposes the Netpbm package to JavaScript when built for WebAssembly:
+//
+//	GOOS=js GOARCH=wasm go build -o netpbm.wasm ./cmd/pnmwasm
+//
+// Once loaded with wasm_exec.js it defines a global netpbm object with two functions
+// working entirely in memory, without filesystem access:
+//
+//	netpbm.convert(data, magicNumber) converts a PBM, PGM or PPM file held in a
+//	Uint8Array to the format of the given magic number, plain ("P1", "P2", "P3") or
+//	raw ("P4", "P5", "P6"). Pixels are kept when only the encoding changes.
+//
+//	netpbm.filter(data, pipeline) applies a JSON pipeline (see Netpbm.LoadPipeline)
+//	to an image and returns it as a PPM file.
+//
+// Both return an object with either a data field holding the resulting file as a
+// Uint8Array or an error field holding a message.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	Netpbm "nom_du_module"
+)
+
+func main() {
+	js.Global().Set("netpbm", map[string]interface{}{
+		"convert": js.FuncOf(convert),
+		"filter":  js.FuncOf(filter),
+	})
+	// Keep the Go runtime alive so the functions stay callable.
+	select {}
+}
+
+// result builds the object returned to JavaScript.
+func result(data []byte, err error) interface{} {
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	array := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(array, data)
+	return map[string]interface{}{"data": array}
+}
+
+// decodeArg decodes the image held in a Uint8Array argument.
+func decodeArg(v js.Value) (Netpbm.Image, error) {
+	if v.Type() != js.TypeObject {
+		return nil, fmt.Errorf("expected a Uint8Array")
+	}
+	data := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(data, v)
+	return Netpbm.DecodeAny(bytes.NewReader(data))
+}
+
+// toPPM converts a decoded PBM, PGM or PPM image to a PPM image. Other types, such as
+// those of formats added with RegisterFormat, are reported as unsupported.
+func toPPM(img Netpbm.Image) (*Netpbm.PPM, error) {
+	switch img := img.(type) {
+	case *Netpbm.PBM:
+		return img.ToPPM(), nil
+	case *Netpbm.PGM:
+		return img.ToPPM(), nil
+	case *Netpbm.PPM:
+		return img, nil
+	}
+	return nil, fmt.Errorf("unsupported image type %T", img)
+}
+
+// convert implements netpbm.convert(data, magicNumber).
+func convert(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return result(nil, fmt.Errorf("convert expects 2 arguments"))
+	}
+	img, err := decodeArg(args[0])
+	if err != nil {
+		return result(nil, err)
+	}
+
+	out, err := convertTo(img, args[1].String())
+	if err != nil {
+		return result(nil, err)
+	}
+
+	var buf bytes.Buffer
+	err = out.Encode(&buf)
+	return result(buf.Bytes(), err)
+}
+
+// convertTo converts img to the format of the given magic number. Images already of the
+// target type keep their pixels and maximum value; PGM images are thresholded by their own
+// maximum value when converted to PBM.
+func convertTo(img Netpbm.Image, target string) (Netpbm.Image, error) {
+	switch target {
+	case "P1", "P4":
+		var pbm *Netpbm.PBM
+		switch img := img.(type) {
+		case *Netpbm.PBM:
+			pbm = img
+		case *Netpbm.PGM:
+			pbm = img.ToPBM()
+		default:
+			ppm, err := toPPM(img)
+			if err != nil {
+				return nil, err
+			}
+			pbm = ppm.ToPBM()
+		}
+		pbm.SetMagicNumber(target)
+		return pbm, nil
+	case "P2", "P5":
+		var pgm *Netpbm.PGM
+		switch img := img.(type) {
+		case *Netpbm.PBM:
+			var err error
+			if pgm, err = pbmToPGM(img); err != nil {
+				return nil, err
+			}
+		case *Netpbm.PGM:
+			pgm = img
+		default:
+			ppm, err := toPPM(img)
+			if err != nil {
+				return nil, err
+			}
+			pgm = ppm.ToPGM()
+		}
+		pgm.SetMagicNumber(target)
+		return pgm, nil
+	case "P3", "P6":
+		ppm, err := toPPM(img)
+		if err != nil {
+			return nil, err
+		}
+		ppm.SetMagicNumber(target)
+		return ppm, nil
+	}
+	return nil, fmt.Errorf("unsupported target format: %s", target)
+}
+
+// pbmToPGM converts a PBM image to a PGM image of maximum value 255, set pixels becoming
+// black and the others white.
+func pbmToPGM(pbm *Netpbm.PBM) (*Netpbm.PGM, error) {
+	width, height := pbm.Size()
+	data := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !pbm.At(x, y) {
+				data[y*width+x] = 255
+			}
+		}
+	}
+	return Netpbm.NewPGMFromData(width, height, 255, data)
+}
+
+// filter implements netpbm.filter(data, pipeline).
+func filter(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return result(nil, fmt.Errorf("filter expects 2 arguments"))
+	}
+	img, err := decodeArg(args[0])
+	if err != nil {
+		return result(nil, err)
+	}
+	pipeline, err := Netpbm.LoadPipeline(strings.NewReader(args[1].String()))
+	if err != nil {
+		return result(nil, err)
+	}
+	ppm, err := toPPM(img)
+	if err != nil {
+		return result(nil, err)
+	}
+	if err := pipeline.Apply(ppm); err != nil {
+		return result(nil, err)
+	}
+
+	var buf bytes.Buffer
+	err = ppm.Encode(&buf)
+	return result(buf.Bytes(), err)
+}
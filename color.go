@@ -0,0 +1,261 @@
+package Netpbm
+
+import "math"
+
+// rgbToHSV converts normalized RGB values (0..1) to hue (degrees), saturation and value.
+func rgbToHSV(r, g, b float64) (h, s, v float64) {
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	delta := maxC - minC
+
+	v = maxC
+	if maxC > 0 {
+		s = delta / maxC
+	}
+	h = hueOf(r, g, b, maxC, delta)
+	return h, s, v
+}
+
+// hsvToRGB converts hue (degrees), saturation and value to normalized RGB values.
+func hsvToRGB(h, s, v float64) (r, g, b float64) {
+	c := v * s
+	return hueToRGB(h, c, v-c)
+}
+
+// rgbToHSL converts normalized RGB values (0..1) to hue (degrees), saturation and lightness.
+func rgbToHSL(r, g, b float64) (h, s, l float64) {
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	delta := maxC - minC
+
+	l = (maxC + minC) / 2
+	if delta > 0 {
+		s = delta / (1 - math.Abs(2*l-1))
+	}
+	h = hueOf(r, g, b, maxC, delta)
+	return h, s, l
+}
+
+// hslToRGB converts hue (degrees), saturation and lightness to normalized RGB values.
+func hslToRGB(h, s, l float64) (r, g, b float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	return hueToRGB(h, c, l-c/2)
+}
+
+// hueOf computes the hue in degrees shared by the HSV and HSL models.
+func hueOf(r, g, b, maxC, delta float64) float64 {
+	if delta == 0 {
+		return 0
+	}
+	var h float64
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+// hueToRGB builds RGB values from a hue, a chroma and the offset m added to every channel.
+func hueToRGB(h, c, m float64) (r, g, b float64) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return r + m, g + m, b + m
+}
+
+// clamp01 restricts v to the range [0, 1].
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// clampUint8 rounds v and restricts it to the range [0, max].
+func clampUint8(v float64, max uint8) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= float64(max) {
+		return max
+	}
+	return uint8(math.Round(v))
+}
+
+// mapHSL applies fn to the HSL representation of every pixel of the PPM image.
+func (ppm *PPM) mapHSL(fn func(h, s, l float64) (float64, float64, float64)) {
+	ppm.own()
+	mapPixel := hslPixel(ppm.max, fn)
+	parallelRows(ppm.height, func(i int) {
+		row := ppm.row(i)
+		for j, p := range row {
+			row[j] = mapPixel(p)
+		}
+	})
+}
+
+// hslPixel returns the function applying fn to the HSL representation of a pixel of an
+// image of maximum value maxValue.
+func hslPixel(maxValue uint8, fn func(h, s, l float64) (float64, float64, float64)) func(p Pixel) Pixel {
+	if maxValue == 0 {
+		return func(p Pixel) Pixel { return p }
+	}
+	max := float64(maxValue)
+	return func(p Pixel) Pixel {
+		h, s, l := rgbToHSL(float64(p.R)/max, float64(p.G)/max, float64(p.B)/max)
+		r, g, b := hslToRGB(fn(h, s, l))
+		return Pixel{
+			R: clampUint8(r*max, maxValue),
+			G: clampUint8(g*max, maxValue),
+			B: clampUint8(b*max, maxValue),
+		}
+	}
+}
+
+// AdjustHue rotates the hue of every pixel of the PPM image by the given number of degrees.
+func (ppm *PPM) AdjustHue(degrees float64) {
+	ppm.record("AdjustHue", "degrees", degrees)
+	ppm.mapHSL(func(h, s, l float64) (float64, float64, float64) {
+		return h + degrees, s, l
+	})
+}
+
+// AdjustSaturation multiplies the saturation of every pixel of the PPM image by factor.
+// A factor of 0 produces a grayscale image and 1 leaves the image unchanged.
+func (ppm *PPM) AdjustSaturation(factor float64) {
+	ppm.record("AdjustSaturation", "factor", factor)
+	ppm.mapHSL(func(h, s, l float64) (float64, float64, float64) {
+		return h, clamp01(s * factor), l
+	})
+}
+
+// AdjustLightness multiplies the lightness of every pixel of the PPM image by factor.
+func (ppm *PPM) AdjustLightness(factor float64) {
+	ppm.record("AdjustLightness", "factor", factor)
+	ppm.mapHSL(func(h, s, l float64) (float64, float64, float64) {
+		return h, s, clamp01(l * factor)
+	})
+}
+
+// WhiteBalanceMethod selects the illuminant estimation used by AutoWhiteBalance.
+type WhiteBalanceMethod int
+
+const (
+	// GrayWorld assumes the average color of the scene is neutral gray.
+	GrayWorld WhiteBalanceMethod = iota
+	// WhitePatch assumes the brightest value of each channel is white.
+	WhitePatch
+)
+
+// AutoWhiteBalance removes a color cast from the PPM image by scaling each channel
+// according to the illuminant estimated with the given method.
+func (ppm *PPM) AutoWhiteBalance(method WhiteBalanceMethod) {
+	ppm.record("AutoWhiteBalance", "method", method)
+	if ppm.width == 0 || ppm.height == 0 {
+		return
+	}
+	var r, g, b float64
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			switch method {
+			case WhitePatch:
+				r = math.Max(r, float64(p.R))
+				g = math.Max(g, float64(p.G))
+				b = math.Max(b, float64(p.B))
+			default:
+				r += float64(p.R)
+				g += float64(p.G)
+				b += float64(p.B)
+			}
+		}
+	}
+
+	var target float64
+	if method == WhitePatch {
+		target = float64(ppm.max)
+	} else {
+		target = (r + g + b) / 3
+	}
+	ppm.scaleChannels(gain(target, r), gain(target, g), gain(target, b))
+}
+
+// gain returns the factor bringing value to target, or 1 when value is zero.
+func gain(target, value float64) float64 {
+	if value == 0 {
+		return 1
+	}
+	return target / value
+}
+
+// scaleChannels multiplies the red, green and blue channels of every pixel by the given factors.
+func (ppm *PPM) scaleChannels(fr, fg, fb float64) {
+	ppm.own()
+	parallelRows(ppm.height, func(i int) {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			ppm.data[i*ppm.width+j] = Pixel{
+				R: clampUint8(float64(p.R)*fr, ppm.max),
+				G: clampUint8(float64(p.G)*fg, ppm.max),
+				B: clampUint8(float64(p.B)*fb, ppm.max),
+			}
+		}
+	})
+}
+
+// referenceTemperature is the color temperature, in kelvin, assumed for unadjusted images.
+const referenceTemperature = 6500
+
+// Temperature shifts the white point of the PPM image by kelvinShift kelvin relative to daylight
+// (6500K). Positive values make the image cooler (bluer), negative values make it warmer.
+func (ppm *PPM) Temperature(kelvinShift float64) {
+	ppm.record("Temperature", "kelvinShift", kelvinShift)
+	rr, rg, rb := blackbodyRGB(referenceTemperature)
+	tr, tg, tb := blackbodyRGB(referenceTemperature + kelvinShift)
+	ppm.scaleChannels(tr/rr, tg/rg, tb/rb)
+}
+
+// blackbodyRGB approximates the normalized RGB color of a black body at the given temperature.
+func blackbodyRGB(kelvin float64) (r, g, b float64) {
+	t := math.Max(1000, math.Min(40000, kelvin)) / 100
+
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	// Keep every channel strictly positive so ratios stay finite.
+	return math.Max(1, math.Min(255, r)) / 255, math.Max(1, math.Min(255, g)) / 255, math.Max(1, math.Min(255, b)) / 255
+}
@@ -0,0 +1,190 @@
+package Netpbm
+
+import "fmt"
+
+// Connectivity selects which neighbors of a pixel are considered connected to it.
+type Connectivity int
+
+const (
+	// FourConnected connects pixels sharing an edge.
+	FourConnected Connectivity = 4
+	// EightConnected also connects pixels sharing a corner.
+	EightConnected Connectivity = 8
+)
+
+// neighbors returns the offsets of the connected neighbors of a pixel.
+func (c Connectivity) neighbors() []Point {
+	four := []Point{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+	if c == EightConnected {
+		return append(four, Point{-1, -1}, Point{1, -1}, Point{-1, 1}, Point{1, 1})
+	}
+	return four
+}
+
+// checkConnectivity validates a connectivity.
+func checkConnectivity(c Connectivity) error {
+	if c != FourConnected && c != EightConnected {
+		return fmt.Errorf("invalid connectivity: %d", c)
+	}
+	return nil
+}
+
+// LabelComponents finds the connected regions of pixels of the PBM image equal to value
+// (true for black). It returns the label of every pixel, 0 for pixels of the other value
+// and 1 to n for the n components, and the area of each component, areas[k-1] being the
+// number of pixels labeled k.
+func (pbm *PBM) LabelComponents(value bool, connectivity Connectivity) ([][]int, []int, error) {
+	if err := checkConnectivity(connectivity); err != nil {
+		return nil, nil, err
+	}
+	neighbors := connectivity.neighbors()
+	labels := make([][]int, pbm.height)
+	for y := range labels {
+		labels[y] = make([]int, pbm.width)
+	}
+
+	var areas []int
+	var stack []Point
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y*pbm.width+x] != value || labels[y][x] != 0 {
+				continue
+			}
+			label := len(areas) + 1
+			area := 0
+			labels[y][x] = label
+			stack = append(stack[:0], Point{x, y})
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				area++
+				for _, o := range neighbors {
+					nx, ny := p.X+o.X, p.Y+o.Y
+					if nx < 0 || ny < 0 || nx >= pbm.width || ny >= pbm.height {
+						continue
+					}
+					if pbm.data[ny*pbm.width+nx] == value && labels[ny][nx] == 0 {
+						labels[ny][nx] = label
+						stack = append(stack, Point{nx, ny})
+					}
+				}
+			}
+			areas = append(areas, area)
+		}
+	}
+	return labels, areas, nil
+}
+
+// RemoveSpeckles turns white the black components of the PBM image (8-connected) with
+// fewer than minArea pixels, cleaning the dust of scanned line art.
+func (pbm *PBM) RemoveSpeckles(minArea int) {
+	pbm.record("RemoveSpeckles", "minArea", minArea)
+	labels, areas, _ := pbm.LabelComponents(true, EightConnected)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if l := labels[y][x]; l != 0 && areas[l-1] < minArea {
+				pbm.data[y*pbm.width+x] = false
+			}
+		}
+	}
+}
+
+// FillSmallHoles turns black the white holes of the PBM image (4-connected regions not
+// touching the border) with at most maxArea pixels.
+func (pbm *PBM) FillSmallHoles(maxArea int) {
+	pbm.record("FillSmallHoles", "maxArea", maxArea)
+	if pbm.width == 0 || pbm.height == 0 {
+		return
+	}
+	labels, areas, _ := pbm.LabelComponents(false, FourConnected)
+	// Regions touching the border are background, not holes.
+	background := make(map[int]bool)
+	for x := 0; x < pbm.width; x++ {
+		background[labels[0][x]] = true
+		background[labels[pbm.height-1][x]] = true
+	}
+	for y := 0; y < pbm.height; y++ {
+		background[labels[y][0]] = true
+		background[labels[y][pbm.width-1]] = true
+	}
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if l := labels[y][x]; l != 0 && !background[l] && areas[l-1] <= maxArea {
+				pbm.data[y*pbm.width+x] = true
+			}
+		}
+	}
+}
+
+// mooreDirections lists the eight neighbors of a pixel clockwise, starting from the left one.
+var mooreDirections = [8]Point{{-1, 0}, {-1, -1}, {0, -1}, {1, -1}, {1, 0}, {1, 1}, {0, 1}, {-1, 1}}
+
+// Contours returns the outer boundary of every black object (8-connected component) of
+// the PBM image, as the ordered list of its boundary pixels traced clockwise with Moore
+// neighbor tracing. Each contour starts at the topmost, then leftmost, pixel of its object;
+// the polygons can be drawn with DrawPolygon after converting to PPM.
+func (pbm *PBM) Contours() [][]Point {
+	labels, areas, _ := pbm.LabelComponents(true, EightConnected)
+	inside := func(p Point, label int) bool {
+		return p.X >= 0 && p.Y >= 0 && p.X < pbm.width && p.Y < pbm.height && labels[p.Y][p.X] == label
+	}
+
+	contours := make([][]Point, 0, len(areas))
+	traced := make([]bool, len(areas)+1)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			label := labels[y][x]
+			if label == 0 || traced[label] {
+				continue
+			}
+			traced[label] = true
+			contours = append(contours, traceContour(Point{x, y}, func(p Point) bool { return inside(p, label) }))
+		}
+	}
+	return contours
+}
+
+// traceContour follows the boundary of the object containing start, which must be its
+// first pixel in raster order, until it comes back to start in the same direction
+// (Jacob's stopping criterion).
+func traceContour(start Point, inside func(p Point) bool) []Point {
+	contour := []Point{start}
+	p, back := start, 0 // The left neighbor of the first pixel is outside.
+	firstDir := -1
+	for {
+		next := -1
+		for k := 1; k <= 8; k++ {
+			d := (back + k) % 8
+			o := mooreDirections[d]
+			if inside(Point{p.X + o.X, p.Y + o.Y}) {
+				next = d
+				break
+			}
+		}
+		if next < 0 {
+			return contour // Isolated pixel.
+		}
+		if p == start {
+			if firstDir == next {
+				return contour
+			}
+			if firstDir < 0 {
+				firstDir = next
+			}
+		}
+		// The last neighbor checked before next is outside: it becomes the backtrack of the
+		// new pixel, expressed relative to it.
+		prev := mooreDirections[(next+7)%8]
+		o := mooreDirections[next]
+		p = Point{p.X + o.X, p.Y + o.Y}
+		rel := Point{prev.X - o.X, prev.Y - o.Y}
+		for d, m := range mooreDirections {
+			if m == rel {
+				back = d
+			}
+		}
+		if p != start {
+			contour = append(contour, p)
+		}
+	}
+}
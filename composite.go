@@ -0,0 +1,186 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// BlendMode selects how Composite combines the colors of two images.
+type BlendMode int
+
+const (
+	// BlendNormal replaces the image by the other one.
+	BlendNormal BlendMode = iota
+	// BlendMultiply multiplies the colors, always darkening.
+	BlendMultiply
+	// BlendScreen multiplies the inverted colors, always lightening.
+	BlendScreen
+	// BlendOverlay multiplies dark areas and screens light areas of the image.
+	BlendOverlay
+	// BlendDarken keeps the darker of the two samples.
+	BlendDarken
+	// BlendLighten keeps the lighter of the two samples.
+	BlendLighten
+	// BlendDifference takes the absolute difference of the samples.
+	BlendDifference
+	// BlendAddition adds the samples, saturating at the maximum value.
+	BlendAddition
+)
+
+// blendChannel combines a base and a blend sample, both normalized to [0, 1].
+func blendChannel(mode BlendMode, a, b float64) float64 {
+	switch mode {
+	case BlendMultiply:
+		return a * b
+	case BlendScreen:
+		return 1 - (1-a)*(1-b)
+	case BlendOverlay:
+		if a < 0.5 {
+			return 2 * a * b
+		}
+		return 1 - 2*(1-a)*(1-b)
+	case BlendDarken:
+		return math.Min(a, b)
+	case BlendLighten:
+		return math.Max(a, b)
+	case BlendDifference:
+		return math.Abs(a - b)
+	case BlendAddition:
+		return math.Min(1, a+b)
+	}
+	return b
+}
+
+// blendPixel combines two pixels with a blend mode, using max as the maximum value of both.
+func blendPixel(mode BlendMode, a, b Pixel, max uint8) Pixel {
+	if max == 0 {
+		return a
+	}
+	m := float64(max)
+	channel := func(x, y uint8) uint8 {
+		return clampUint8(blendChannel(mode, float64(x)/m, float64(y)/m)*m, max)
+	}
+	return Pixel{channel(a.R, b.R), channel(a.G, b.G), channel(a.B, b.B)}
+}
+
+// Composite combines other, of the same size, onto the PPM image with a blend mode, as in
+// the layer modes of image editors.
+func (ppm *PPM) Composite(other *PPM, mode BlendMode) error {
+	if err := ppm.checkSameSize(other); err != nil {
+		return err
+	}
+	if mode < BlendNormal || mode > BlendAddition {
+		return fmt.Errorf("invalid blend mode: %d", mode)
+	}
+	ppm.record("Composite", "mode", mode)
+	ppm.own()
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			ppm.data[i*ppm.width+j] = blendPixel(mode, ppm.data[i*ppm.width+j], other.data[i*other.width+j], ppm.max)
+		}
+	}
+	return nil
+}
+
+// checkSameSize ensures two PPM images have the same dimensions.
+func (ppm *PPM) checkSameSize(other *PPM) error {
+	if ppm.width != other.width || ppm.height != other.height {
+		return fmt.Errorf("image sizes differ: %dx%d and %dx%d", ppm.width, ppm.height, other.width, other.height)
+	}
+	return nil
+}
+
+// mix returns the weighted average of a and b, alpha being the weight of b.
+func mix(a, b uint8, alpha float64) uint8 {
+	return clampUint8(float64(a)*(1-alpha)+float64(b)*alpha, 255)
+}
+
+// mixPixel mixes two pixels channel by channel.
+func mixPixel(a, b Pixel, alpha float64) Pixel {
+	return Pixel{mix(a.R, b.R, alpha), mix(a.G, b.G, alpha), mix(a.B, b.B, alpha)}
+}
+
+// Blend mixes other over the PPM image with the given opacity: 0 keeps the image,
+// 1 replaces it by other. Both images must have the same size.
+func (ppm *PPM) Blend(other *PPM, alpha float64) error {
+	if err := ppm.checkSameSize(other); err != nil {
+		return err
+	}
+	if alpha < 0 || alpha > 1 {
+		return fmt.Errorf("invalid opacity: %v", alpha)
+	}
+	ppm.record("Blend", "alpha", alpha)
+	ppm.own()
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			ppm.data[i*ppm.width+j] = mixPixel(ppm.data[i*ppm.width+j], other.data[i*other.width+j], alpha)
+		}
+	}
+	return nil
+}
+
+// BlendWithMask mixes other over the PPM image with a per-pixel opacity read from mask:
+// black keeps the image, the mask maximum value replaces it by other. All three images
+// must have the same size.
+func (ppm *PPM) BlendWithMask(other *PPM, mask *PGM) error {
+	if err := ppm.checkSameSize(other); err != nil {
+		return err
+	}
+	if mask.width != ppm.width || mask.height != ppm.height {
+		return fmt.Errorf("mask size %dx%d does not match image size %dx%d", mask.width, mask.height, ppm.width, ppm.height)
+	}
+	if mask.max == 0 {
+		return fmt.Errorf("invalid mask maximum value: 0")
+	}
+	ppm.record("BlendWithMask")
+	ppm.own()
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			alpha := clamp01(float64(mask.data[i*mask.width+j]) / float64(mask.max))
+			ppm.data[i*ppm.width+j] = mixPixel(ppm.data[i*ppm.width+j], other.data[i*other.width+j], alpha)
+		}
+	}
+	return nil
+}
+
+// ChromaKey replaces the pixels of the PPM image close to keyColor (such as a green screen)
+// by the pixels of background, which must have the same size. Distances are Euclidean in
+// RGB, normalized so that 1 separates black from white: pixels within tolerance of the key
+// are fully replaced, pixels farther than tolerance+softness are kept, and pixels in
+// between are mixed for feathered edges.
+func (ppm *PPM) ChromaKey(background *PPM, keyColor Pixel, tolerance, softness float64) error {
+	if err := ppm.checkSameSize(background); err != nil {
+		return err
+	}
+	if tolerance < 0 || softness < 0 {
+		return fmt.Errorf("invalid chroma key tolerance or softness: %v, %v", tolerance, softness)
+	}
+	ppm.record("ChromaKey", "keyColor", keyColor, "tolerance", tolerance, "softness", softness)
+	ppm.own()
+
+	scale := float64(ppm.max) * math.Sqrt(3)
+	if scale == 0 {
+		return nil
+	}
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			dr := float64(p.R) - float64(keyColor.R)
+			dg := float64(p.G) - float64(keyColor.G)
+			db := float64(p.B) - float64(keyColor.B)
+			d := math.Sqrt(dr*dr+dg*dg+db*db) / scale
+
+			var alpha float64 // Weight of the background
+			switch {
+			case d <= tolerance:
+				alpha = 1
+			case softness > 0 && d < tolerance+softness:
+				alpha = 1 - (d-tolerance)/softness
+			default:
+				continue
+			}
+			ppm.data[i*ppm.width+j] = mixPixel(p, background.data[i*background.width+j], alpha)
+		}
+	}
+	return nil
+}
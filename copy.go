@@ -0,0 +1,174 @@
+package Netpbm
+
+// clone returns a copy of the history sharing no memory with h.
+func (h history) clone() history {
+	h.ops = append([]Operation(nil), h.ops...)
+	return h
+}
+
+// Clone returns a deep copy of the PBM image, its pixels, magic number and history, so
+// the copy can be processed without affecting the image.
+func (pbm *PBM) Clone() *PBM {
+	c := *pbm
+	c.data = append([]bool(nil), pbm.data...)
+	c.history = pbm.history.clone()
+	return &c
+}
+
+// Clone returns a deep copy of the PGM image, its pixels, magic number, maximum value and
+// history, so the copy can be processed without affecting the image.
+func (pgm *PGM) Clone() *PGM {
+	c := *pgm
+	c.data = append([]uint8(nil), pgm.data...)
+	c.history = pgm.history.clone()
+	return &c
+}
+
+// Clone returns a deep copy of the PPM image, its pixels, magic number, maximum value,
+// history and drawing settings, so the copy can be processed without affecting the image.
+// Unlike Snapshot, the pixels are copied immediately.
+func (ppm *PPM) Clone() *PPM {
+	c := *ppm
+	c.data = append([]Pixel(nil), ppm.data...)
+	c.shared = false
+	c.history = ppm.history.clone()
+	if ppm.clip != nil {
+		clip := *ppm.clip
+		c.clip = &clip
+	}
+	return &c
+}
+
+// Clone returns a deep copy of the packed image.
+func (p *PackedPBM) Clone() *PackedPBM {
+	c := *p
+	c.data = append([]byte(nil), p.data...)
+	return &c
+}
+
+// Inverted returns a copy of the PBM image with its pixels inverted, leaving the image
+// unchanged.
+func (pbm *PBM) Inverted() *PBM {
+	c := pbm.Clone()
+	c.Invert()
+	return c
+}
+
+// Flipped returns a horizontally flipped copy of the PBM image, leaving it unchanged.
+func (pbm *PBM) Flipped() *PBM {
+	c := pbm.Clone()
+	c.Flip()
+	return c
+}
+
+// Flopped returns a vertically flipped copy of the PBM image, leaving it unchanged.
+func (pbm *PBM) Flopped() *PBM {
+	c := pbm.Clone()
+	c.Flop()
+	return c
+}
+
+// Resized returns a copy of the PBM image scaled by Resize, leaving it unchanged.
+func (pbm *PBM) Resized(width, height int) (*PBM, error) {
+	c := pbm.Clone()
+	if err := c.Resize(width, height); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Inverted returns a copy of the PGM image with its values inverted, leaving the image
+// unchanged.
+func (pgm *PGM) Inverted() *PGM {
+	c := pgm.Clone()
+	c.Invert()
+	return c
+}
+
+// Flipped returns a horizontally flipped copy of the PGM image, leaving it unchanged.
+func (pgm *PGM) Flipped() *PGM {
+	c := pgm.Clone()
+	c.Flip()
+	return c
+}
+
+// Flopped returns a vertically flipped copy of the PGM image, leaving it unchanged.
+func (pgm *PGM) Flopped() *PGM {
+	c := pgm.Clone()
+	c.Flop()
+	return c
+}
+
+// Rotated90CW returns a copy of the PGM image turned clockwise by a quarter, leaving it
+// unchanged.
+func (pgm *PGM) Rotated90CW() *PGM {
+	c := pgm.Clone()
+	c.Rotate90CW()
+	return c
+}
+
+// Binned returns a copy of the PGM image reduced by Bin, leaving it unchanged.
+func (pgm *PGM) Binned(factor int, mode BinMode) (*PGM, error) {
+	c := pgm.Clone()
+	if err := c.Bin(factor, mode); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Resized returns a copy of the PGM image scaled by Resize, leaving it unchanged.
+func (pgm *PGM) Resized(width, height int) (*PGM, error) {
+	c := pgm.Clone()
+	if err := c.Resize(width, height); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Inverted returns a copy of the PPM image with its colors inverted, leaving the image
+// unchanged.
+func (ppm *PPM) Inverted() *PPM {
+	c := ppm.Clone()
+	c.Invert()
+	return c
+}
+
+// Flipped returns a horizontally flipped copy of the PPM image, leaving it unchanged.
+func (ppm *PPM) Flipped() *PPM {
+	c := ppm.Clone()
+	c.Flip()
+	return c
+}
+
+// Flopped returns a vertically flipped copy of the PPM image, leaving it unchanged.
+func (ppm *PPM) Flopped() *PPM {
+	c := ppm.Clone()
+	c.Flop()
+	return c
+}
+
+// Rotated90CW returns a copy of the PPM image turned clockwise by a quarter, leaving it
+// unchanged.
+func (ppm *PPM) Rotated90CW() *PPM {
+	c := ppm.Clone()
+	c.Rotate90CW()
+	return c
+}
+
+// Binned returns a copy of the PPM image reduced by Bin, leaving it unchanged.
+func (ppm *PPM) Binned(factor int, mode BinMode) (*PPM, error) {
+	c := ppm.Clone()
+	if err := c.Bin(factor, mode); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Resized returns a copy of the PPM image scaled by Resize, leaving it unchanged.
+func (ppm *PPM) Resized(width, height int) (*PPM, error) {
+	c := ppm.Clone()
+	if err := c.Resize(width, height); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
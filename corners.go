@@ -0,0 +1,169 @@
+package Netpbm
+
+import "fmt"
+
+// CornerMethod selects the corner detector used by Corners.
+type CornerMethod int
+
+const (
+	// HarrisCorners uses the Harris response of the local structure tensor. The threshold is
+	// relative to the strongest response of the image, between 0 and 1.
+	HarrisCorners CornerMethod = iota
+	// FASTCorners uses the FAST-9 segment test: a pixel is a corner when 9 contiguous pixels
+	// of the circle of radius 3 around it are all brighter or all darker than it by more than
+	// the threshold, in sample units.
+	FASTCorners
+)
+
+// harrisK is the sensitivity constant of the Harris response.
+const harrisK = 0.04
+
+// fastCircle lists the 16 pixels of the Bresenham circle of radius 3 used by FAST, in order.
+var fastCircle = [16]Point{
+	{0, -3}, {1, -3}, {2, -2}, {3, -1}, {3, 0}, {3, 1}, {2, 2}, {1, 3},
+	{0, 3}, {-1, 3}, {-2, 2}, {-3, 1}, {-3, 0}, {-3, -1}, {-2, -2}, {-1, -3},
+}
+
+// Corners detects the interest points of the PGM image with the given method, keeping only
+// the local maxima of the corner score, in raster order.
+func (pgm *PGM) Corners(method CornerMethod, threshold float64) ([]Point, error) {
+	var score [][]float64
+	switch method {
+	case HarrisCorners:
+		if threshold < 0 || threshold > 1 {
+			return nil, fmt.Errorf("invalid Harris threshold: %v", threshold)
+		}
+		score = pgm.harrisScore(threshold)
+	case FASTCorners:
+		if threshold < 0 {
+			return nil, fmt.Errorf("invalid FAST threshold: %v", threshold)
+		}
+		score = pgm.fastScore(threshold)
+	default:
+		return nil, fmt.Errorf("invalid corner method: %d", method)
+	}
+	return localMaxima(score), nil
+}
+
+// harrisScore returns the Harris response of every pixel, 0 where it is below threshold
+// times the strongest response.
+func (pgm *PGM) harrisScore(threshold float64) [][]float64 {
+	at := func(x, y int) float64 {
+		return float64(pgm.data[clampInt(y, 0, pgm.height-1)*pgm.width+clampInt(x, 0, pgm.width-1)])
+	}
+	ixx := make([][]float64, pgm.height)
+	iyy := make([][]float64, pgm.height)
+	ixy := make([][]float64, pgm.height)
+	for y := 0; y < pgm.height; y++ {
+		ixx[y] = make([]float64, pgm.width)
+		iyy[y] = make([]float64, pgm.width)
+		ixy[y] = make([]float64, pgm.width)
+		for x := 0; x < pgm.width; x++ {
+			gx := at(x+1, y-1) + 2*at(x+1, y) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x-1, y) - at(x-1, y+1)
+			gy := at(x-1, y+1) + 2*at(x, y+1) + at(x+1, y+1) - at(x-1, y-1) - 2*at(x, y-1) - at(x+1, y-1)
+			ixx[y][x], iyy[y][x], ixy[y][x] = gx*gx, gy*gy, gx*gy
+		}
+	}
+
+	// Sum the structure tensor over a 3x3 window.
+	window := func(m [][]float64, x, y int) float64 {
+		var sum float64
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				sum += m[clampInt(y+dy, 0, pgm.height-1)][clampInt(x+dx, 0, pgm.width-1)]
+			}
+		}
+		return sum
+	}
+	score := make([][]float64, pgm.height)
+	var strongest float64
+	for y := 0; y < pgm.height; y++ {
+		score[y] = make([]float64, pgm.width)
+		for x := 0; x < pgm.width; x++ {
+			a, b, c := window(ixx, x, y), window(iyy, x, y), window(ixy, x, y)
+			r := a*b - c*c - harrisK*(a+b)*(a+b)
+			score[y][x] = r
+			strongest = max(strongest, r)
+		}
+	}
+	for y := range score {
+		for x, r := range score[y] {
+			if r <= 0 || r < threshold*strongest {
+				score[y][x] = 0
+			}
+		}
+	}
+	return score
+}
+
+// fastScore returns, for every pixel passing the FAST-9 segment test, the sum of the
+// differences beyond threshold with the pixels of its circle, and 0 for other pixels.
+func (pgm *PGM) fastScore(threshold float64) [][]float64 {
+	score := make([][]float64, pgm.height)
+	for y := range score {
+		score[y] = make([]float64, pgm.width)
+	}
+	for y := 3; y < pgm.height-3; y++ {
+		for x := 3; x < pgm.width-3; x++ {
+			center := float64(pgm.data[y*pgm.width+x])
+			var diffs [16]float64
+			for i, o := range fastCircle {
+				diffs[i] = float64(pgm.data[(y+o.Y)*pgm.width+x+o.X]) - center
+			}
+			for _, sign := range []float64{1, -1} {
+				run, best := 0, 0
+				// Go around twice to find runs wrapping past the start of the circle.
+				for i := 0; i < 32; i++ {
+					if sign*diffs[i%16] > threshold {
+						run++
+						best = max(best, run)
+					} else {
+						run = 0
+					}
+				}
+				if best < 9 {
+					continue
+				}
+				var s float64
+				for _, d := range diffs {
+					if sign*d > threshold {
+						s += sign*d - threshold
+					}
+				}
+				score[y][x] = max(score[y][x], s)
+			}
+		}
+	}
+	return score
+}
+
+// localMaxima returns the pixels with a positive score not exceeded by any of their eight
+// neighbors, in raster order. Among equal neighbors, only the first in raster order is kept.
+func localMaxima(score [][]float64) []Point {
+	var points []Point
+	for y, row := range score {
+		for x, s := range row {
+			if s <= 0 {
+				continue
+			}
+			isMax := true
+			for dy := -1; dy <= 1 && isMax; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					ny, nx := y+dy, x+dx
+					if (dx == 0 && dy == 0) || ny < 0 || ny >= len(score) || nx < 0 || nx >= len(row) {
+						continue
+					}
+					n := score[ny][nx]
+					if n > s || (n == s && (dy < 0 || (dy == 0 && dx < 0))) {
+						isMax = false
+						break
+					}
+				}
+			}
+			if isMax {
+				points = append(points, Point{x, y})
+			}
+		}
+	}
+	return points
+}
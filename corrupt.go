@@ -0,0 +1,78 @@
+package Netpbm
+
+import (
+	"fmt"
+	"image"
+)
+
+// blockDifferences splits a width x height image into blockSize x blockSize blocks and
+// returns those where the mean absolute difference of the samples, computed by diff for
+// every pixel, exceeds threshold.
+func blockDifferences(width, height, blockSize int, threshold float64, samples int, diff func(x, y int) int) []image.Rectangle {
+	var blocks []image.Rectangle
+	for by := 0; by < height; by += blockSize {
+		for bx := 0; bx < width; bx += blockSize {
+			block := image.Rect(bx, by, min(bx+blockSize, width), min(by+blockSize, height))
+			var sum int
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					sum += diff(x, y)
+				}
+			}
+			if float64(sum)/float64(block.Dx()*block.Dy()*samples) > threshold {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	return blocks
+}
+
+// checkCorruptBlocks validates the parameters of FindCorruptBlocks.
+func checkCorruptBlocks(width, height, refWidth, refHeight, blockSize int, threshold float64) error {
+	if width != refWidth || height != refHeight {
+		return fmt.Errorf("image sizes differ: %dx%d and %dx%d", width, height, refWidth, refHeight)
+	}
+	if blockSize < 1 {
+		return fmt.Errorf("invalid block size: %d", blockSize)
+	}
+	if threshold < 0 {
+		return fmt.Errorf("invalid threshold: %v", threshold)
+	}
+	return nil
+}
+
+// absDiff returns the absolute difference of two samples.
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// FindCorruptBlocks compares the PGM image with a reference copy of the same size, block
+// by block, and returns the blocks whose mean absolute difference exceeds threshold, in
+// sample units. Blocks on the right and bottom edges may be smaller than blockSize.
+// A threshold slightly above 0 tolerates lossy round trips while still catching damaged
+// tiles after storage or transmission.
+func (pgm *PGM) FindCorruptBlocks(reference *PGM, blockSize int, threshold float64) ([]image.Rectangle, error) {
+	if err := checkCorruptBlocks(pgm.width, pgm.height, reference.width, reference.height, blockSize, threshold); err != nil {
+		return nil, err
+	}
+	return blockDifferences(pgm.width, pgm.height, blockSize, threshold, 1, func(x, y int) int {
+		return absDiff(pgm.data[y*pgm.width+x], reference.data[y*reference.width+x])
+	}), nil
+}
+
+// FindCorruptBlocks compares the PPM image with a reference copy of the same size, block
+// by block, and returns the blocks whose mean absolute difference over all channels
+// exceeds threshold, in sample units. Blocks on the right and bottom edges may be smaller
+// than blockSize.
+func (ppm *PPM) FindCorruptBlocks(reference *PPM, blockSize int, threshold float64) ([]image.Rectangle, error) {
+	if err := checkCorruptBlocks(ppm.width, ppm.height, reference.width, reference.height, blockSize, threshold); err != nil {
+		return nil, err
+	}
+	return blockDifferences(ppm.width, ppm.height, blockSize, threshold, 3, func(x, y int) int {
+		p, q := ppm.data[y*ppm.width+x], reference.data[y*reference.width+x]
+		return absDiff(p.R, q.R) + absDiff(p.G, q.G) + absDiff(p.B, q.B)
+	}), nil
+}
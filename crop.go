@@ -0,0 +1,138 @@
+package Netpbm
+
+import (
+	"fmt"
+	"image"
+)
+
+// checkCrop ensures a crop rectangle is not empty and lies within the image.
+func checkCrop(r image.Rectangle, width, height int) error {
+	if r.Empty() || !r.In(image.Rect(0, 0, width, height)) {
+		return fmt.Errorf("invalid crop rectangle %v for image size %dx%d", r, width, height)
+	}
+	return nil
+}
+
+// Crop keeps only the rectangle r of the PBM image.
+func (pbm *PBM) Crop(r image.Rectangle) error {
+	if err := checkCrop(r, pbm.width, pbm.height); err != nil {
+		return err
+	}
+	pbm.record("Crop", "rect", r)
+	data := make([]bool, 0, r.Dx()*r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		data = append(data, pbm.row(y)[r.Min.X:r.Max.X]...)
+	}
+	pbm.data, pbm.width, pbm.height = data, r.Dx(), r.Dy()
+	return nil
+}
+
+// Crop keeps only the rectangle r of the PGM image.
+func (pgm *PGM) Crop(r image.Rectangle) error {
+	if err := checkCrop(r, pgm.width, pgm.height); err != nil {
+		return err
+	}
+	pgm.record("Crop", "rect", r)
+	data := make([]uint8, 0, r.Dx()*r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		data = append(data, pgm.row(y)[r.Min.X:r.Max.X]...)
+	}
+	pgm.data, pgm.width, pgm.height = data, r.Dx(), r.Dy()
+	return nil
+}
+
+// Crop keeps only the rectangle r of the PPM image.
+func (ppm *PPM) Crop(r image.Rectangle) error {
+	if err := checkCrop(r, ppm.width, ppm.height); err != nil {
+		return err
+	}
+	ppm.record("Crop", "rect", r)
+	data := make([]Pixel, 0, r.Dx()*r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		data = append(data, ppm.row(y)[r.Min.X:r.Max.X]...)
+	}
+	// The pixels are a new copy, no longer shared with snapshots.
+	ppm.data, ppm.width, ppm.height, ppm.shared = data, r.Dx(), r.Dy(), false
+	return nil
+}
+
+// borderColor returns the most frequent of the colors of the four corners of an image, the
+// top-left one winning ties.
+func borderColor(corners [4]Pixel) Pixel {
+	best, bestCount := corners[0], 0
+	for _, c := range corners {
+		count := 0
+		for _, d := range corners {
+			if d == c {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = c, count
+		}
+	}
+	return best
+}
+
+// contentBounds returns the bounding box of the pixels for which differs is true, or an
+// empty rectangle if there are none.
+func contentBounds(width, height int, differs func(x, y int) bool) image.Rectangle {
+	var r image.Rectangle
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if differs(x, y) {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// AutoCrop detects the uniform border of the PGM image, such as the margins of a scanner
+// bed, and crops it away. The border color is the most frequent corner color; pixels
+// differing from it by more than tolerance are content. It returns the content rectangle,
+// in the coordinates of the original image, or an empty rectangle, leaving the image
+// unchanged, if the whole image is border.
+func (pgm *PGM) AutoCrop(tolerance uint8) image.Rectangle {
+	if pgm.width == 0 || pgm.height == 0 {
+		return image.Rectangle{}
+	}
+	w, h := pgm.width-1, pgm.height-1
+	gray := func(v uint8) Pixel { return Pixel{v, v, v} }
+	border := borderColor([4]Pixel{gray(pgm.data[0]), gray(pgm.data[w]), gray(pgm.data[h*pgm.width]), gray(pgm.data[h*pgm.width+w])}).R
+	r := contentBounds(pgm.width, pgm.height, func(x, y int) bool {
+		return absDiff(pgm.data[y*pgm.width+x], border) > int(tolerance)
+	})
+	if !r.Empty() {
+		pgm.Crop(r)
+	}
+	return r
+}
+
+// AutoCrop detects the uniform border of the PPM image and crops it away, as PGM.AutoCrop
+// does; a pixel is content if any of its channels differs from the border color by more
+// than tolerance.
+func (ppm *PPM) AutoCrop(tolerance uint8) image.Rectangle {
+	if ppm.width == 0 || ppm.height == 0 {
+		return image.Rectangle{}
+	}
+	w, h := ppm.width-1, ppm.height-1
+	border := borderColor([4]Pixel{ppm.data[0], ppm.data[w], ppm.data[h*ppm.width], ppm.data[h*ppm.width+w]})
+	r := contentBounds(ppm.width, ppm.height, func(x, y int) bool {
+		p := ppm.data[y*ppm.width+x]
+		return max(absDiff(p.R, border.R), absDiff(p.G, border.G), absDiff(p.B, border.B)) > int(tolerance)
+	})
+	if !r.Empty() {
+		ppm.Crop(r)
+	}
+	return r
+}
+
+// BoundingBox returns the smallest rectangle containing all the set (black) pixels of the
+// PBM image, or an empty rectangle if there are none. Passing it to Crop trims the margins
+// of scanned line art.
+func (pbm *PBM) BoundingBox() image.Rectangle {
+	return contentBounds(pbm.width, pbm.height, func(x, y int) bool {
+		return pbm.data[y*pbm.width+x]
+	})
+}
@@ -0,0 +1,120 @@
+package Netpbm
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ExportCSV writes the PGM image as delimited text, one record per row and one field per
+// pixel. Use ',' for CSV or '\t' for TSV.
+func (pgm *PGM) ExportCSV(w io.Writer, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	record := make([]string, pgm.width)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			record[x] = strconv.Itoa(int(pgm.data[y*pgm.width+x]))
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing row %d: %v", y, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportCSV writes the PPM image as delimited text, one record per row and three
+// consecutive fields (R, G, B) per pixel. Use ',' for CSV or '\t' for TSV.
+func (ppm *PPM) ExportCSV(w io.Writer, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	record := make([]string, 3*ppm.width)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y*ppm.width+x]
+			record[3*x] = strconv.Itoa(int(p.R))
+			record[3*x+1] = strconv.Itoa(int(p.G))
+			record[3*x+2] = strconv.Itoa(int(p.B))
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error writing row %d: %v", y, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readCSVSamples reads delimited text as rows of samples, checking that all rows have
+// the same number of fields and that every value fits in 8 bits.
+func readCSVSamples(r io.Reader, delimiter rune) ([][]uint8, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading delimited data: %v", err)
+	}
+
+	rows := make([][]uint8, len(records))
+	for y, record := range records {
+		rows[y] = make([]uint8, len(record))
+		for x, field := range record {
+			value, err := strconv.ParseUint(field, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value at row %d, column %d: %q", y, x, field)
+			}
+			rows[y][x] = uint8(value)
+		}
+	}
+	return rows, nil
+}
+
+// ImportPGMCSV builds a PGM image with a maximum value of 255 from delimited text holding
+// one record per row and one field per pixel.
+func ImportPGMCSV(r io.Reader, delimiter rune) (*PGM, error) {
+	rows, err := readCSVSamples(r, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	width := 0
+	if len(rows) > 0 {
+		width = len(rows[0])
+	}
+	data := make([]uint8, 0, width*len(rows))
+	for _, row := range rows {
+		data = append(data, row...)
+	}
+	return &PGM{
+		data:        data,
+		width:       width,
+		height:      len(rows),
+		magicNumber: "P2",
+		max:         255,
+	}, nil
+}
+
+// ImportPPMCSV builds a PPM image with a maximum value of 255 from delimited text holding
+// one record per row and three consecutive fields (R, G, B) per pixel.
+func ImportPPMCSV(r io.Reader, delimiter rune) (*PPM, error) {
+	rows, err := readCSVSamples(r, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	fields := 0
+	if len(rows) > 0 {
+		fields = len(rows[0])
+	}
+	if fields%3 != 0 {
+		return nil, fmt.Errorf("number of fields per row is not a multiple of 3: %d", fields)
+	}
+
+	ppm := newPPM(fields/3, len(rows), "P3", 255)
+	for y, row := range rows {
+		for x := 0; x < ppm.width; x++ {
+			ppm.data[y*ppm.width+x] = Pixel{row[3*x], row[3*x+1], row[3*x+2]}
+		}
+	}
+	return ppm, nil
+}
@@ -0,0 +1,96 @@
+package Netpbm
+
+import "math"
+
+// curveTolerance is the maximum distance, in pixels, between a curve and the polyline
+// approximating it.
+const curveTolerance = 0.25
+
+// maxCurveDepth bounds the recursion of the curve subdivision.
+const maxCurveDepth = 16
+
+// vec2 is a point with floating-point coordinates.
+type vec2 [2]float64
+
+// lerp2 interpolates linearly between a and b.
+func lerp2(a, b vec2, t float64) vec2 {
+	return vec2{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t}
+}
+
+// toVec2 converts a point to floating-point coordinates.
+func toVec2(p Point) vec2 {
+	return vec2{float64(p.X), float64(p.Y)}
+}
+
+// distanceToChord returns the distance from p to the line through a and b.
+func distanceToChord(p, a, b vec2) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return math.Hypot(p[0]-a[0], p[1]-a[1])
+	}
+	return math.Abs((p[0]-a[0])*dy-(p[1]-a[1])*dx) / length
+}
+
+// flattenCubic appends to points the end points of the segments approximating the cubic
+// Bezier curve p0 p1 p2 p3, splitting it in halves with de Casteljau's algorithm until its
+// control points are close enough to the chord.
+func flattenCubic(points []vec2, p0, p1, p2, p3 vec2, depth int) []vec2 {
+	if depth >= maxCurveDepth || (distanceToChord(p1, p0, p3) <= curveTolerance && distanceToChord(p2, p0, p3) <= curveTolerance) {
+		return append(points, p3)
+	}
+	p01, p12, p23 := lerp2(p0, p1, 0.5), lerp2(p1, p2, 0.5), lerp2(p2, p3, 0.5)
+	p012, p123 := lerp2(p01, p12, 0.5), lerp2(p12, p23, 0.5)
+	mid := lerp2(p012, p123, 0.5)
+	points = flattenCubic(points, p0, p01, p012, mid, depth+1)
+	return flattenCubic(points, mid, p123, p23, p3, depth+1)
+}
+
+// drawFlattened draws the polyline through points, rounded to pixels.
+func (ppm *PPM) drawFlattened(points []vec2, color Pixel) {
+	path := make([]Point, 0, len(points))
+	for _, v := range points {
+		p := Point{int(math.Round(v[0])), int(math.Round(v[1]))}
+		if len(path) == 0 || path[len(path)-1] != p {
+			path = append(path, p)
+		}
+	}
+	ppm.strokePath(path, false, StrokeStyle{}, color)
+}
+
+// DrawQuadraticBezier draws the quadratic Bezier curve from p0 to p2 with control point p1
+// on the PPM image with the specified color.
+func (ppm *PPM) DrawQuadraticBezier(p0, p1, p2 Point, color Pixel) {
+	// A quadratic curve is the cubic curve with control points 2/3 of the way to p1.
+	a, c, b := toVec2(p0), toVec2(p1), toVec2(p2)
+	c1, c2 := lerp2(a, c, 2.0/3), lerp2(b, c, 2.0/3)
+	ppm.drawFlattened(flattenCubic([]vec2{a}, a, c1, c2, b, 0), color)
+}
+
+// DrawCubicBezier draws the cubic Bezier curve from p0 to p3 with control points p1 and p2
+// on the PPM image with the specified color.
+func (ppm *PPM) DrawCubicBezier(p0, p1, p2, p3 Point, color Pixel) {
+	a := toVec2(p0)
+	ppm.drawFlattened(flattenCubic([]vec2{a}, a, toVec2(p1), toVec2(p2), toVec2(p3), 0), color)
+}
+
+// DrawCatmullRomSpline draws a smooth curve passing through all the points on the PPM
+// image with the specified color, using a uniform Catmull-Rom spline.
+func (ppm *PPM) DrawCatmullRomSpline(points []Point, color Pixel) {
+	if len(points) < 3 {
+		ppm.strokePath(points, false, StrokeStyle{}, color)
+		return
+	}
+	at := func(i int) vec2 {
+		return toVec2(points[clampInt(i, 0, len(points)-1)])
+	}
+	flat := []vec2{at(0)}
+	for i := 0; i < len(points)-1; i++ {
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		// Bezier control points of the spline segment from p1 to p2.
+		c1 := vec2{p1[0] + (p2[0]-p0[0])/6, p1[1] + (p2[1]-p0[1])/6}
+		c2 := vec2{p2[0] - (p3[0]-p1[0])/6, p2[1] - (p3[1]-p1[1])/6}
+		flat = flattenCubic(flat, p1, c1, c2, p2, 0)
+	}
+	ppm.drawFlattened(flat, color)
+}
@@ -0,0 +1,136 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// CVDType selects a color vision deficiency.
+type CVDType int
+
+const (
+	// Protanopia is the absence of red-sensitive cones.
+	Protanopia CVDType = iota
+	// Deuteranopia is the absence of green-sensitive cones, the most common deficiency.
+	Deuteranopia
+	// Tritanopia is the absence of blue-sensitive cones.
+	Tritanopia
+)
+
+// String returns the name of the deficiency.
+func (t CVDType) String() string {
+	switch t {
+	case Protanopia:
+		return "protanopia"
+	case Deuteranopia:
+		return "deuteranopia"
+	case Tritanopia:
+		return "tritanopia"
+	}
+	return fmt.Sprintf("CVDType(%d)", int(t))
+}
+
+// cvdMatrices simulate full dichromacy in linear RGB (Machado, Oliveira and Fernandes, 2009).
+var cvdMatrices = map[CVDType][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// srgbToLinear converts a normalized sRGB sample to linear light.
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a normalized linear light sample to sRGB.
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// mapLinear replaces every pixel of the PPM image by the result of fn, which receives and
+// returns the channels as normalized linear light values.
+func (ppm *PPM) mapLinear(fn func(rgb [3]float64) [3]float64) {
+	ppm.own()
+	if ppm.max == 0 {
+		return
+	}
+	m := float64(ppm.max)
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			in := [3]float64{
+				srgbToLinear(float64(p.R) / m),
+				srgbToLinear(float64(p.G) / m),
+				srgbToLinear(float64(p.B) / m),
+			}
+			out := fn(in)
+			var q [3]uint8
+			for c := range out {
+				q[c] = clampUint8(linearToSRGB(clamp01(out[c]))*m, ppm.max)
+			}
+			ppm.data[i*ppm.width+j] = Pixel{q[0], q[1], q[2]}
+		}
+	}
+}
+
+// simulateCVD applies a dichromacy matrix to linear RGB values.
+func simulateCVD(m [3][3]float64, rgb [3]float64) [3]float64 {
+	var out [3]float64
+	for c := 0; c < 3; c++ {
+		out[c] = m[c][0]*rgb[0] + m[c][1]*rgb[1] + m[c][2]*rgb[2]
+	}
+	return out
+}
+
+// SimulateCVD transforms the PPM image to show how it is perceived with the given color
+// vision deficiency, to check the accessibility of graphics.
+func (ppm *PPM) SimulateCVD(t CVDType) error {
+	m, ok := cvdMatrices[t]
+	if !ok {
+		return fmt.Errorf("invalid color vision deficiency: %d", t)
+	}
+	ppm.record("SimulateCVD", "type", t)
+	ppm.mapLinear(func(rgb [3]float64) [3]float64 {
+		return simulateCVD(m, rgb)
+	})
+	return nil
+}
+
+// Daltonize adjusts the colors of the PPM image so that people with the given color vision
+// deficiency can better distinguish them: the information lost by the deficiency is
+// shifted to the channels they still perceive.
+func (ppm *PPM) Daltonize(t CVDType) error {
+	m, ok := cvdMatrices[t]
+	if !ok {
+		return fmt.Errorf("invalid color vision deficiency: %d", t)
+	}
+	ppm.record("Daltonize", "type", t)
+	ppm.mapLinear(func(rgb [3]float64) [3]float64 {
+		sim := simulateCVD(m, rgb)
+		er, eg, eb := rgb[0]-sim[0], rgb[1]-sim[1], rgb[2]-sim[2]
+		// Lost red-green contrast goes to green and blue, lost blue contrast to red and green.
+		if t == Tritanopia {
+			return [3]float64{rgb[0] + 0.7*eb, rgb[1] + 0.7*eb, rgb[2]}
+		}
+		return [3]float64{rgb[0], rgb[1] + 0.7*er + eg, rgb[2] + 0.7*er + eb}
+	})
+	return nil
+}
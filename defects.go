@@ -0,0 +1,87 @@
+package Netpbm
+
+import "fmt"
+
+// defectNeighbors calls fn with the nearest non-defective pixel in each of the four
+// directions from (x, y), together with its inverse-distance weight.
+func defectNeighbors(defects *PBM, x, y int, fn func(nx, ny int, weight float64)) {
+	directions := [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for _, d := range directions {
+		nx, ny := x+d[0], y+d[1]
+		for dist := 1; nx >= 0 && nx < defects.width && ny >= 0 && ny < defects.height; dist++ {
+			if !defects.data[ny*defects.width+nx] {
+				fn(nx, ny, 1/float64(dist))
+				break
+			}
+			nx, ny = nx+d[0], ny+d[1]
+		}
+	}
+}
+
+// checkDefectMap ensures the defect map matches the image dimensions.
+func checkDefectMap(defects *PBM, width, height int) error {
+	if defects.width != width || defects.height != height {
+		return fmt.Errorf("defect map size %dx%d does not match image size %dx%d", defects.width, defects.height, width, height)
+	}
+	return nil
+}
+
+// CorrectDefects replaces every pixel marked in the defect map (dead pixels, columns or rows)
+// with an inverse-distance weighted average of the nearest valid pixels along its row and column.
+// Pixels with no valid neighbor in any direction are left unchanged.
+func (pgm *PGM) CorrectDefects(defects *PBM) error {
+	if err := checkDefectMap(defects, pgm.width, pgm.height); err != nil {
+		return err
+	}
+	pgm.record("CorrectDefects")
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			if !defects.data[y*defects.width+x] {
+				continue
+			}
+			var sum, total float64
+			defectNeighbors(defects, x, y, func(nx, ny int, weight float64) {
+				sum += float64(pgm.data[ny*pgm.width+nx]) * weight
+				total += weight
+			})
+			if total > 0 {
+				pgm.data[y*pgm.width+x] = clampUint8(sum/total, 255)
+			}
+		}
+	}
+	return nil
+}
+
+// CorrectDefects replaces every pixel marked in the defect map with an inverse-distance weighted
+// average of the nearest valid pixels along its row and column, channel by channel.
+// Pixels with no valid neighbor in any direction are left unchanged.
+func (ppm *PPM) CorrectDefects(defects *PBM) error {
+	if err := checkDefectMap(defects, ppm.width, ppm.height); err != nil {
+		return err
+	}
+	ppm.record("CorrectDefects")
+	ppm.own()
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			if !defects.data[y*defects.width+x] {
+				continue
+			}
+			var r, g, b, total float64
+			defectNeighbors(defects, x, y, func(nx, ny int, weight float64) {
+				p := ppm.data[ny*ppm.width+nx]
+				r += float64(p.R) * weight
+				g += float64(p.G) * weight
+				b += float64(p.B) * weight
+				total += weight
+			})
+			if total > 0 {
+				ppm.data[y*ppm.width+x] = Pixel{
+					R: clampUint8(r/total, 255),
+					G: clampUint8(g/total, 255),
+					B: clampUint8(b/total, 255),
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// DepthBlur simulates a shallow depth of field: every pixel of the PPM image is averaged
+// over a disc whose radius grows with the distance between its depth, read from the depth
+// map of the same size, and the focus plane. Depths and focusDistance are normalized to
+// [0, 1] by the maximum value of the map; the blur radius is aperture times the distance to
+// the focus plane, so aperture is the radius, in pixels, at a normalized distance of 1.
+// Rows are processed in parallel.
+func (ppm *PPM) DepthBlur(depth *PGM, focusDistance, aperture float64) error {
+	if depth.width != ppm.width || depth.height != ppm.height {
+		return fmt.Errorf("depth map size %dx%d does not match image size %dx%d", depth.width, depth.height, ppm.width, ppm.height)
+	}
+	if depth.max == 0 {
+		return fmt.Errorf("invalid depth map maximum value: 0")
+	}
+	if focusDistance < 0 || focusDistance > 1 {
+		return fmt.Errorf("invalid focus distance: %v", focusDistance)
+	}
+	if aperture < 0 {
+		return fmt.Errorf("invalid aperture: %v", aperture)
+	}
+	ppm.record("DepthBlur", "focusDistance", focusDistance, "aperture", aperture)
+
+	ppm.mapNeighborhood(func(x, y int, at func(dx, dy int) Pixel) Pixel {
+		d := clamp01(float64(depth.data[y*depth.width+x]) / float64(depth.max))
+		radius := aperture * math.Abs(d-focusDistance)
+		if radius < 0.5 {
+			return at(0, 0)
+		}
+		r := int(radius)
+		var sr, sg, sb, n float64
+		for dy := -r; dy <= r; dy++ {
+			for dx := -r; dx <= r; dx++ {
+				if float64(dx*dx+dy*dy) > radius*radius {
+					continue
+				}
+				p := at(dx, dy)
+				sr += float64(p.R)
+				sg += float64(p.G)
+				sb += float64(p.B)
+				n++
+			}
+		}
+		return Pixel{clampUint8(sr/n, ppm.max), clampUint8(sg/n, ppm.max), clampUint8(sb/n, ppm.max)}
+	})
+	return nil
+}
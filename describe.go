@@ -0,0 +1,105 @@
+package Netpbm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// histogramSketchBins is the number of bins of the histogram included in a Description.
+const histogramSketchBins = 16
+
+// Description is a JSON-serializable summary of an image, convenient for cataloging datasets.
+type Description struct {
+	Format    string    `json:"format"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	MaxValue  uint      `json:"maxValue"`
+	Histogram []int     `json:"histogram"` // Pixel counts in 16 equal bins of intensity
+	Mean      []float64 `json:"mean"`      // Mean sample value per channel
+	Hash      string    `json:"hash"`      // SHA-256 of the dimensions and samples
+}
+
+// sketchBin returns the histogram bin of a sample value.
+func sketchBin(v, max uint) int {
+	if max == 0 {
+		return 0
+	}
+	bin := int(v * histogramSketchBins / (max + 1))
+	if bin >= histogramSketchBins {
+		bin = histogramSketchBins - 1
+	}
+	return bin
+}
+
+// contentHash returns the hex SHA-256 of the image dimensions followed by its samples.
+func contentHash(width, height int, max uint, samples func(write func(b ...byte))) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d %d %d\n", width, height, max)
+	samples(func(b ...byte) { h.Write(b) })
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Describe returns a summary of the PBM image. Black pixels count as intensity 1.
+func (pbm *PBM) Describe() Description {
+	d := Description{Format: pbm.magicNumber, Width: pbm.width, Height: pbm.height, MaxValue: 1}
+	d.Histogram = make([]int, histogramSketchBins)
+	var black int
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			if pbm.data[y*pbm.width+x] {
+				black++
+			}
+		}
+	}
+	d.Histogram[0] = pbm.width*pbm.height - black
+	d.Histogram[histogramSketchBins-1] = black
+	d.Mean = []float64{mean(float64(black), pbm.width*pbm.height)}
+	d.Hash = pbm.Fingerprint()
+	return d
+}
+
+// Describe returns a summary of the PGM image.
+func (pgm *PGM) Describe() Description {
+	d := Description{Format: pgm.magicNumber, Width: pgm.width, Height: pgm.height, MaxValue: pgm.max}
+	d.Histogram = make([]int, histogramSketchBins)
+	var sum float64
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			v := pgm.data[y*pgm.width+x]
+			d.Histogram[sketchBin(uint(v), pgm.max)]++
+			sum += float64(v)
+		}
+	}
+	d.Mean = []float64{mean(sum, pgm.width*pgm.height)}
+	d.Hash = pgm.Fingerprint()
+	return d
+}
+
+// Describe returns a summary of the PPM image. The histogram is computed on the average of the channels.
+func (ppm *PPM) Describe() Description {
+	d := Description{Format: ppm.magicNumber, Width: ppm.width, Height: ppm.height, MaxValue: uint(ppm.max)}
+	d.Histogram = make([]int, histogramSketchBins)
+	var r, g, b float64
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			p := ppm.data[y*ppm.width+x]
+			d.Histogram[sketchBin((uint(p.R)+uint(p.G)+uint(p.B))/3, uint(ppm.max))]++
+			r += float64(p.R)
+			g += float64(p.G)
+			b += float64(p.B)
+		}
+	}
+	n := ppm.width * ppm.height
+	d.Mean = []float64{mean(r, n), mean(g, n), mean(b, n)}
+	d.Hash = ppm.Fingerprint()
+	return d
+}
+
+// mean returns sum divided by n, or 0 for an empty image.
+func mean(sum float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
@@ -0,0 +1,98 @@
+package Netpbm
+
+import "math"
+
+// maxSkew is the largest skew, in degrees, searched by DetectSkew.
+const maxSkew = 15
+
+// projectionScore returns the sum of the squares of the projection profile of the ink
+// points along lines sloping by angle degrees: it peaks when the lines follow the rows of
+// text, concentrating the ink in few bins.
+func projectionScore(points []Point, height int, angle float64) float64 {
+	slope := math.Tan(angle * math.Pi / 180)
+	offset := 0
+	for _, p := range points {
+		offset = max(offset, int(math.Ceil(math.Abs(float64(p.X)*slope))))
+	}
+	bins := make([]int, height+2*offset+1)
+	for _, p := range points {
+		bins[int(math.Round(float64(p.Y)-float64(p.X)*slope))+offset]++
+	}
+	var score float64
+	for _, n := range bins {
+		score += float64(n) * float64(n)
+	}
+	return score
+}
+
+// detectSkew returns the angle, in degrees clockwise, between the horizontal and the rows
+// of the ink points, searching in steps of half a degree then refining to a twentieth.
+func detectSkew(points []Point, height int) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+	best, bestScore := 0.0, projectionScore(points, height, 0)
+	search := func(from, to, step float64) {
+		for i := 0; from+float64(i)*step <= to+step/2; i++ {
+			angle := math.Round((from+float64(i)*step)*100) / 100
+			if score := projectionScore(points, height, angle); score > bestScore {
+				best, bestScore = angle, score
+			}
+		}
+	}
+	search(-maxSkew, maxSkew, 0.5)
+	search(best-0.5, best+0.5, 0.05)
+	return best
+}
+
+// inkPoints returns the pixels for which ink is true.
+func inkPoints(width, height int, ink func(x, y int) bool) []Point {
+	var points []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if ink(x, y) {
+				points = append(points, Point{x, y})
+			}
+		}
+	}
+	return points
+}
+
+// DetectSkew returns the angle, in degrees clockwise, by which the text lines of a scanned
+// PGM page are rotated, between -15 and 15, from the projection profile of its dark
+// pixels. It returns 0 for pages without text.
+func (pgm *PGM) DetectSkew() float64 {
+	threshold := uint8(pgm.max / 2)
+	return detectSkew(inkPoints(pgm.width, pgm.height, func(x, y int) bool {
+		return pgm.data[y*pgm.width+x] < threshold
+	}), pgm.height)
+}
+
+// Deskew levels the text lines of a scanned PGM page by rotating it by the opposite of
+// DetectSkew, filling the uncovered corners with white. It returns the detected skew.
+func (pgm *PGM) Deskew() float64 {
+	angle := pgm.DetectSkew()
+	if angle != 0 {
+		pgm.Rotate(-angle, uint8(pgm.max))
+	}
+	return angle
+}
+
+// DetectSkew returns the angle, in degrees clockwise, by which the text lines of a scanned
+// PBM page are rotated, between -15 and 15, from the projection profile of its set pixels.
+// It returns 0 for empty pages.
+func (pbm *PBM) DetectSkew() float64 {
+	return detectSkew(inkPoints(pbm.width, pbm.height, func(x, y int) bool {
+		return pbm.data[y*pbm.width+x]
+	}), pbm.height)
+}
+
+// Deskew levels the text lines of a scanned PBM page by rotating it by the opposite of
+// DetectSkew, clearing the uncovered corners. It returns the detected skew.
+func (pbm *PBM) Deskew() float64 {
+	angle := pbm.DetectSkew()
+	if angle != 0 {
+		pbm.Rotate(-angle, false)
+	}
+	return angle
+}
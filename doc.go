@@ -0,0 +1,8 @@
+// Package Netpbm reads, writes and processes images in the Netpbm formats: PBM bitmaps,
+// PGM gray maps and PPM pixel maps, in their plain (P1, P2, P3) and raw (P4, P5, P6)
+// encodings.
+//
+// Methods named after a verb, such as Invert or Resize, modify the image in place, while
+// their past-participle counterparts, such as Inverted or Resized, return a new image and
+// leave the receiver unchanged. Clone returns a deep copy of any image.
+package Netpbm
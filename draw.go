@@ -0,0 +1,321 @@
+package Netpbm
+
+import (
+	"math"
+	"sort"
+)
+
+// The drawing primitives below are shared by the PBM, PGM and PPM images: they compute the
+// pixels of a shape and pass them to plot, which sets them to the drawing value of the image
+// and clips them.
+
+// thickLine plots a line of the given thickness with rounded ends.
+func thickLine(p1, p2 Point, thickness int, plot func(x, y int)) {
+	if thickness <= 1 {
+		bresenham(p1, p2, plot)
+		return
+	}
+	brush := discOffsets(float64(thickness) / 2)
+	bresenham(p1, p2, func(x, y int) {
+		for _, o := range brush {
+			plot(x+o.X, y+o.Y)
+		}
+	})
+}
+
+// rectangle plots the outline of the rectangle from p1 to p1 + (width, height), both
+// included.
+func rectangle(p1 Point, width, height int, plot func(x, y int)) {
+	polygon([]Point{p1, {p1.X + width, p1.Y}, {p1.X + width, p1.Y + height}, {p1.X, p1.Y + height}}, plot)
+}
+
+// filledRectangle plots the width x height pixels from p1.
+func filledRectangle(p1 Point, width, height int, plot func(x, y int)) {
+	for i := 0; i < height; i++ {
+		for j := 0; j < width; j++ {
+			plot(p1.X+j, p1.Y+i)
+		}
+	}
+}
+
+// circle plots the circumference of a circle.
+func circle(center Point, radius int, plot func(x, y int)) {
+	midpointCircle(radius, func(dx, dy int) {
+		plot(center.X+dx, center.Y+dy)
+	})
+}
+
+// thickCircle plots the pixels at less than width/2 from the circumference of a circle.
+func thickCircle(center Point, radius, width int, plot func(x, y int)) {
+	if width <= 1 {
+		circle(center, radius, plot)
+		return
+	}
+	inner := math.Max(0, float64(radius)-float64(width)/2)
+	outer := float64(radius) + float64(width)/2
+	r := int(math.Ceil(outer))
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			d := float64(x*x + y*y)
+			if d >= inner*inner && d < outer*outer {
+				plot(center.X+x, center.Y+y)
+			}
+		}
+	}
+}
+
+// filledCircle plots the pixels of a disc.
+func filledCircle(center Point, radius int, plot func(x, y int)) {
+	for x := -radius; x <= radius; x++ {
+		for y := -radius; y <= radius; y++ {
+			if x*x+y*y <= radius*radius {
+				plot(center.X+x, center.Y+y)
+			}
+		}
+	}
+}
+
+// polygon plots the closed outline through points.
+func polygon(points []Point, plot func(x, y int)) {
+	for i := range points {
+		bresenham(points[i], points[(i+1)%len(points)], plot)
+	}
+}
+
+// filledPolygon plots the interior of a polygon with the even–odd rule, and its outline.
+func filledPolygon(points []Point, plot func(x, y int)) {
+	if len(points) == 0 {
+		return
+	}
+	minY, maxY := points[0].Y, points[0].Y
+	for _, p := range points {
+		minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+	}
+
+	var xs []float64
+	for y := minY; y <= maxY; y++ {
+		// Intersections of the row with the edges; an edge covers its rows half-open, so
+		// vertices shared by two edges are counted once.
+		xs = xs[:0]
+		for i := range points {
+			p1, p2 := points[i], points[(i+1)%len(points)]
+			if (p1.Y <= y) == (p2.Y <= y) {
+				continue
+			}
+			t := float64(y-p1.Y) / float64(p2.Y-p1.Y)
+			xs = append(xs, float64(p1.X)+t*float64(p2.X-p1.X))
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			for x := int(math.Ceil(xs[i])); x <= int(math.Floor(xs[i+1])); x++ {
+				plot(x, y)
+			}
+		}
+	}
+	polygon(points, plot)
+}
+
+// ellipse plots the outline of an ellipse.
+func ellipse(center Point, rx, ry int, plot func(x, y int)) {
+	midpointEllipse(rx, ry, func(dx, dy int) {
+		plot(center.X+dx, center.Y+dy)
+	})
+}
+
+// filledEllipse plots the pixels inside the outline of an ellipse, outline included.
+func filledEllipse(center Point, rx, ry int, plot func(x, y int)) {
+	if ry < 0 {
+		return
+	}
+	spans := make([]int, ry+1)
+	for i := range spans {
+		spans[i] = -1
+	}
+	midpointEllipse(rx, ry, func(dx, dy int) {
+		if dy >= 0 && dy <= ry && dx > spans[dy] {
+			spans[dy] = dx
+		}
+	})
+	for dy, span := range spans {
+		for dx := -span; dx <= span; dx++ {
+			plot(center.X+dx, center.Y+dy)
+			if dy != 0 {
+				plot(center.X+dx, center.Y-dy)
+			}
+		}
+	}
+}
+
+// plot sets a pixel drawn by the drawing functions, handling pixels outside the PGM image
+// as told by its bounds policy.
+func (pgm *PGM) plot(x, y int, value uint8) {
+	if x, y, ok := pgm.bounds.resolve(x, y, pgm.width, pgm.height); ok {
+		pgm.data[y*pgm.width+x] = value
+	}
+}
+
+// plotter returns the function plotting pixels of the given gray value for the drawing core.
+func (pgm *PGM) plotter(value uint8) func(x, y int) {
+	return func(x, y int) { pgm.plot(x, y, value) }
+}
+
+// DrawLine draws a line on the PGM image between two points with the specified gray value.
+func (pgm *PGM) DrawLine(p1, p2 Point, value uint8) {
+	bresenham(p1, p2, pgm.plotter(value))
+}
+
+// DrawThickLine draws a line of the given thickness, in pixels, on the PGM image between two
+// points with the specified gray value. Ends are rounded.
+func (pgm *PGM) DrawThickLine(p1, p2 Point, thickness int, value uint8) {
+	thickLine(p1, p2, thickness, pgm.plotter(value))
+}
+
+// DrawRectangle draws a rectangle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawRectangle(p1 Point, width, height int, value uint8) {
+	rectangle(p1, width, height, pgm.plotter(value))
+}
+
+// DrawFilledRectangle draws a filled rectangle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawFilledRectangle(p1 Point, width, height int, value uint8) {
+	filledRectangle(p1, width, height, pgm.plotter(value))
+}
+
+// DrawCircle draws the outline of a circle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawCircle(center Point, radius int, value uint8) {
+	circle(center, radius, pgm.plotter(value))
+}
+
+// DrawThickCircle draws the outline of a circle of the given line width on the PGM image
+// with the specified gray value.
+func (pgm *PGM) DrawThickCircle(center Point, radius, width int, value uint8) {
+	thickCircle(center, radius, width, pgm.plotter(value))
+}
+
+// DrawFilledCircle draws a filled circle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawFilledCircle(center Point, radius int, value uint8) {
+	filledCircle(center, radius, pgm.plotter(value))
+}
+
+// DrawEllipse draws the outline of an ellipse on the PGM image with the specified gray value.
+func (pgm *PGM) DrawEllipse(center Point, rx, ry int, value uint8) {
+	ellipse(center, rx, ry, pgm.plotter(value))
+}
+
+// DrawFilledEllipse draws a filled ellipse on the PGM image with the specified gray value.
+func (pgm *PGM) DrawFilledEllipse(center Point, rx, ry int, value uint8) {
+	filledEllipse(center, rx, ry, pgm.plotter(value))
+}
+
+// DrawTriangle draws a triangle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawTriangle(p1, p2, p3 Point, value uint8) {
+	polygon([]Point{p1, p2, p3}, pgm.plotter(value))
+}
+
+// DrawFilledTriangle draws a filled triangle on the PGM image with the specified gray value.
+func (pgm *PGM) DrawFilledTriangle(p1, p2, p3 Point, value uint8) {
+	filledPolygon([]Point{p1, p2, p3}, pgm.plotter(value))
+}
+
+// DrawPolygon draws a polygon on the PGM image with the specified gray value.
+func (pgm *PGM) DrawPolygon(points []Point, value uint8) {
+	polygon(points, pgm.plotter(value))
+}
+
+// DrawFilledPolygon draws a filled polygon on the PGM image with the specified gray value,
+// following the even–odd rule.
+func (pgm *PGM) DrawFilledPolygon(points []Point, value uint8) {
+	filledPolygon(points, pgm.plotter(value))
+}
+
+// DrawText draws text on the PGM image with the built-in 5x7 font and the specified gray
+// value, every font pixel becoming a scale x scale square.
+func (pgm *PGM) DrawText(p Point, text string, value uint8, scale int) {
+	drawText(p, text, scale, pgm.plotter(value))
+}
+
+// plot sets a pixel drawn by the drawing functions, handling pixels outside the PBM image
+// as told by its bounds policy.
+func (pbm *PBM) plot(x, y int, value bool) {
+	if x, y, ok := pbm.bounds.resolve(x, y, pbm.width, pbm.height); ok {
+		pbm.data[y*pbm.width+x] = value
+	}
+}
+
+// plotter returns the function plotting pixels of the given value for the drawing core.
+func (pbm *PBM) plotter(value bool) func(x, y int) {
+	return func(x, y int) { pbm.plot(x, y, value) }
+}
+
+// DrawLine draws a line on the PBM image between two points with the specified value.
+func (pbm *PBM) DrawLine(p1, p2 Point, value bool) {
+	bresenham(p1, p2, pbm.plotter(value))
+}
+
+// DrawThickLine draws a line of the given thickness, in pixels, on the PBM image between two
+// points with the specified value. Ends are rounded.
+func (pbm *PBM) DrawThickLine(p1, p2 Point, thickness int, value bool) {
+	thickLine(p1, p2, thickness, pbm.plotter(value))
+}
+
+// DrawRectangle draws a rectangle on the PBM image with the specified value.
+func (pbm *PBM) DrawRectangle(p1 Point, width, height int, value bool) {
+	rectangle(p1, width, height, pbm.plotter(value))
+}
+
+// DrawFilledRectangle draws a filled rectangle on the PBM image with the specified value.
+func (pbm *PBM) DrawFilledRectangle(p1 Point, width, height int, value bool) {
+	filledRectangle(p1, width, height, pbm.plotter(value))
+}
+
+// DrawCircle draws the outline of a circle on the PBM image with the specified value.
+func (pbm *PBM) DrawCircle(center Point, radius int, value bool) {
+	circle(center, radius, pbm.plotter(value))
+}
+
+// DrawThickCircle draws the outline of a circle of the given line width on the PBM image
+// with the specified value.
+func (pbm *PBM) DrawThickCircle(center Point, radius, width int, value bool) {
+	thickCircle(center, radius, width, pbm.plotter(value))
+}
+
+// DrawFilledCircle draws a filled circle on the PBM image with the specified value.
+func (pbm *PBM) DrawFilledCircle(center Point, radius int, value bool) {
+	filledCircle(center, radius, pbm.plotter(value))
+}
+
+// DrawEllipse draws the outline of an ellipse on the PBM image with the specified value.
+func (pbm *PBM) DrawEllipse(center Point, rx, ry int, value bool) {
+	ellipse(center, rx, ry, pbm.plotter(value))
+}
+
+// DrawFilledEllipse draws a filled ellipse on the PBM image with the specified value.
+func (pbm *PBM) DrawFilledEllipse(center Point, rx, ry int, value bool) {
+	filledEllipse(center, rx, ry, pbm.plotter(value))
+}
+
+// DrawTriangle draws a triangle on the PBM image with the specified value.
+func (pbm *PBM) DrawTriangle(p1, p2, p3 Point, value bool) {
+	polygon([]Point{p1, p2, p3}, pbm.plotter(value))
+}
+
+// DrawFilledTriangle draws a filled triangle on the PBM image with the specified value.
+func (pbm *PBM) DrawFilledTriangle(p1, p2, p3 Point, value bool) {
+	filledPolygon([]Point{p1, p2, p3}, pbm.plotter(value))
+}
+
+// DrawPolygon draws a polygon on the PBM image with the specified value.
+func (pbm *PBM) DrawPolygon(points []Point, value bool) {
+	polygon(points, pbm.plotter(value))
+}
+
+// DrawFilledPolygon draws a filled polygon on the PBM image with the specified value,
+// following the even–odd rule.
+func (pbm *PBM) DrawFilledPolygon(points []Point, value bool) {
+	filledPolygon(points, pbm.plotter(value))
+}
+
+// DrawText draws text on the PBM image with the built-in 5x7 font and the specified value,
+// every font pixel becoming a scale x scale square.
+func (pbm *PBM) DrawText(p Point, text string, value bool, scale int) {
+	drawText(p, text, scale, pbm.plotter(value))
+}
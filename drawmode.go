@@ -0,0 +1,41 @@
+package Netpbm
+
+// DrawMode selects how the drawing methods of a PPM image combine the drawn color with the
+// pixels of the image.
+type DrawMode int
+
+const (
+	// DrawCopy replaces the pixels by the drawn color.
+	DrawCopy DrawMode = iota
+	// DrawXOR combines the pixels with the drawn color by a bitwise exclusive or, so drawing
+	// the same shape a second time restores the image, as for cursors and rubber-band
+	// selections. The result may exceed the maximum value of images whose maximum value is
+	// not a power of two minus one.
+	DrawXOR
+	// DrawInvert replaces the pixels by their negative, whatever the drawn color; drawing
+	// the same shape a second time restores the image.
+	DrawInvert
+)
+
+// apply returns the pixel old drawn over with color.
+func (m DrawMode) apply(old, color Pixel, max uint8) Pixel {
+	switch m {
+	case DrawXOR:
+		return Pixel{old.R ^ color.R, old.G ^ color.G, old.B ^ color.B}
+	case DrawInvert:
+		return Pixel{max - min(old.R, max), max - min(old.G, max), max - min(old.B, max)}
+	}
+	return color
+}
+
+// SetDrawMode sets how the drawing methods of the PPM image combine the drawn colors with
+// its pixels. Anti-aliased drawing in the XOR and invert modes plots the pixels covered by
+// at least half instead of blending them.
+func (ppm *PPM) SetDrawMode(mode DrawMode) {
+	ppm.mode = mode
+}
+
+// DrawMode returns the current drawing mode of the PPM image.
+func (ppm *PPM) DrawMode() DrawMode {
+	return ppm.mode
+}
@@ -0,0 +1,203 @@
+package Netpbm
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// regionOf clips rect to an image of the given size. An empty rectangle selects the whole image.
+func regionOf(rect image.Rectangle, width, height int) image.Rectangle {
+	bounds := image.Rect(0, 0, width, height)
+	if rect.Empty() {
+		return bounds
+	}
+	return rect.Intersect(bounds)
+}
+
+// Pixelate replaces every blockSize x blockSize block of rect with its average gray value,
+// as used to redact parts of scanned documents. Blocks are aligned on the top-left corner
+// of rect; an empty rectangle pixelates the whole image.
+func (pgm *PGM) Pixelate(rect image.Rectangle, blockSize int) error {
+	if blockSize < 1 {
+		return fmt.Errorf("invalid block size: %d", blockSize)
+	}
+	pgm.record("Pixelate", "rect", rect, "blockSize", blockSize)
+
+	region := regionOf(rect, pgm.width, pgm.height)
+	for by := region.Min.Y; by < region.Max.Y; by += blockSize {
+		for bx := region.Min.X; bx < region.Max.X; bx += blockSize {
+			block := image.Rect(bx, by, bx+blockSize, by+blockSize).Intersect(region)
+			var sum int
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					sum += int(pgm.data[y*pgm.width+x])
+				}
+			}
+			n := block.Dx() * block.Dy()
+			avg := uint8((sum + n/2) / n)
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					pgm.data[y*pgm.width+x] = avg
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Pixelate replaces every blockSize x blockSize block of rect with its average color,
+// as used to redact parts of scanned documents. Blocks are aligned on the top-left corner
+// of rect; an empty rectangle pixelates the whole image.
+func (ppm *PPM) Pixelate(rect image.Rectangle, blockSize int) error {
+	if blockSize < 1 {
+		return fmt.Errorf("invalid block size: %d", blockSize)
+	}
+	ppm.record("Pixelate", "rect", rect, "blockSize", blockSize)
+	ppm.own()
+
+	region := regionOf(rect, ppm.width, ppm.height)
+	for by := region.Min.Y; by < region.Max.Y; by += blockSize {
+		for bx := region.Min.X; bx < region.Max.X; bx += blockSize {
+			block := image.Rect(bx, by, bx+blockSize, by+blockSize).Intersect(region)
+			var r, g, b int
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					p := ppm.data[y*ppm.width+x]
+					r += int(p.R)
+					g += int(p.G)
+					b += int(p.B)
+				}
+			}
+			n := block.Dx() * block.Dy()
+			avg := Pixel{uint8((r + n/2) / n), uint8((g + n/2) / n), uint8((b + n/2) / n)}
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				for x := block.Min.X; x < block.Max.X; x++ {
+					ppm.data[y*ppm.width+x] = avg
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// OilPaint gives the PPM image the look of an oil painting: each pixel takes the average
+// color of the most common intensity level among its neighbors within radius. Fewer
+// intensity levels produce broader strokes.
+func (ppm *PPM) OilPaint(radius, intensityLevels int) error {
+	if radius < 1 {
+		return fmt.Errorf("invalid radius: %d", radius)
+	}
+	if intensityLevels < 1 || intensityLevels > 256 {
+		return fmt.Errorf("invalid number of intensity levels: %d", intensityLevels)
+	}
+	ppm.record("OilPaint", "radius", radius, "intensityLevels", intensityLevels)
+
+	ppm.mapNeighborhood(func(x, y int, at func(dx, dy int) Pixel) Pixel {
+		counts := make([]int, intensityLevels)
+		sums := make([][3]int, intensityLevels)
+		best := 0
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				p := at(dx, dy)
+				level := (int(p.R) + int(p.G) + int(p.B)) / 3 * intensityLevels / 256
+				counts[level]++
+				sums[level][0] += int(p.R)
+				sums[level][1] += int(p.G)
+				sums[level][2] += int(p.B)
+				if counts[level] > counts[best] {
+					best = level
+				}
+			}
+		}
+		n := counts[best]
+		return Pixel{uint8(sums[best][0] / n), uint8(sums[best][1] / n), uint8(sums[best][2] / n)}
+	})
+	return nil
+}
+
+// Kuwahara applies the Kuwahara filter to the PPM image: each pixel takes the mean color
+// of the least varied of the four (radius+1) x (radius+1) quadrants around it, flattening
+// areas while keeping edges sharp.
+func (ppm *PPM) Kuwahara(radius int) error {
+	if radius < 1 {
+		return fmt.Errorf("invalid radius: %d", radius)
+	}
+	ppm.record("Kuwahara", "radius", radius)
+
+	quadrants := [4][2]int{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+	ppm.mapNeighborhood(func(x, y int, at func(dx, dy int) Pixel) Pixel {
+		var best Pixel
+		bestVariance := math.Inf(1)
+		for _, q := range quadrants {
+			var r, g, b, l, l2 float64
+			for i := 0; i <= radius; i++ {
+				for j := 0; j <= radius; j++ {
+					p := at(q[0]*j, q[1]*i)
+					r += float64(p.R)
+					g += float64(p.G)
+					b += float64(p.B)
+					lum := 0.299*float64(p.R) + 0.587*float64(p.G) + 0.114*float64(p.B)
+					l += lum
+					l2 += lum * lum
+				}
+			}
+			n := float64((radius + 1) * (radius + 1))
+			variance := l2/n - (l/n)*(l/n)
+			if variance < bestVariance {
+				bestVariance = variance
+				best = Pixel{clampUint8(r/n, 255), clampUint8(g/n, 255), clampUint8(b/n, 255)}
+			}
+		}
+		return best
+	})
+	return nil
+}
+
+// Solarize inverts the samples of the PGM image that are above threshold, replicating
+// the darkroom effect of overexposing a print.
+func (pgm *PGM) Solarize(threshold uint8) {
+	pgm.record("Solarize", "threshold", threshold)
+	for i := 0; i < pgm.height; i++ {
+		for j := 0; j < pgm.width; j++ {
+			if v := pgm.data[i*pgm.width+j]; v > threshold && uint(v) <= pgm.max {
+				pgm.data[i*pgm.width+j] = uint8(pgm.max - uint(v))
+			}
+		}
+	}
+}
+
+// Solarize inverts the samples of the PPM image that are above threshold, replicating
+// the darkroom effect of overexposing a print. Each channel is tested separately unless
+// onLuminance is set, in which case whole pixels whose luminance exceeds the threshold
+// are inverted.
+func (ppm *PPM) Solarize(threshold uint8, onLuminance bool) {
+	ppm.record("Solarize", "threshold", threshold, "onLuminance", onLuminance)
+	ppm.own()
+	invert := func(v uint8) uint8 {
+		if v > ppm.max {
+			return v
+		}
+		return ppm.max - v
+	}
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			p := &ppm.data[i*ppm.width+j]
+			if onLuminance {
+				if 0.299*float64(p.R)+0.587*float64(p.G)+0.114*float64(p.B) > float64(threshold) {
+					*p = Pixel{invert(p.R), invert(p.G), invert(p.B)}
+				}
+				continue
+			}
+			if p.R > threshold {
+				p.R = invert(p.R)
+			}
+			if p.G > threshold {
+				p.G = invert(p.G)
+			}
+			if p.B > threshold {
+				p.B = invert(p.B)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package Netpbm
+
+// Equal reports whether the PBM image has the same dimensions and pixels as other,
+// whatever their magic numbers.
+func (pbm *PBM) Equal(other *PBM) bool {
+	if pbm.width != other.width || pbm.height != other.height {
+		return false
+	}
+	for y := 0; y < pbm.height; y++ {
+		row := pbm.row(y)
+		for x, v := range row {
+			if other.data[y*other.width+x] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Fingerprint returns the hex SHA-256 of the dimensions and pixels of the PBM image. It
+// does not depend on the magic number, so plain and raw files with the same content share
+// their fingerprint.
+func (pbm *PBM) Fingerprint() string {
+	return contentHash(pbm.width, pbm.height, 1, func(write func(b ...byte)) {
+		for y := 0; y < pbm.height; y++ {
+			for x := 0; x < pbm.width; x++ {
+				if pbm.data[y*pbm.width+x] {
+					write(1)
+				} else {
+					write(0)
+				}
+			}
+		}
+	})
+}
+
+// Equal reports whether the PGM image has the same dimensions, maximum value and samples as
+// other, whatever their magic numbers.
+func (pgm *PGM) Equal(other *PGM) bool {
+	if pgm.width != other.width || pgm.height != other.height || pgm.max != other.max {
+		return false
+	}
+	for y := 0; y < pgm.height; y++ {
+		row := pgm.row(y)
+		for x, v := range row {
+			if other.data[y*other.width+x] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Fingerprint returns the hex SHA-256 of the dimensions, maximum value and samples of the
+// PGM image, independent of its magic number.
+func (pgm *PGM) Fingerprint() string {
+	return contentHash(pgm.width, pgm.height, pgm.max, func(write func(b ...byte)) {
+		for y := 0; y < pgm.height; y++ {
+			write(pgm.row(y)...)
+		}
+	})
+}
+
+// Equal reports whether the PPM image has the same dimensions, maximum value and pixels as
+// other, whatever their magic numbers.
+func (ppm *PPM) Equal(other *PPM) bool {
+	if ppm.width != other.width || ppm.height != other.height || ppm.max != other.max {
+		return false
+	}
+	for y := 0; y < ppm.height; y++ {
+		row := ppm.row(y)
+		for x, p := range row {
+			if other.data[y*other.width+x] != p {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Fingerprint returns the hex SHA-256 of the dimensions, maximum value and pixels of the
+// PPM image, independent of its magic number.
+func (ppm *PPM) Fingerprint() string {
+	return contentHash(ppm.width, ppm.height, uint(ppm.max), func(write func(b ...byte)) {
+		for y := 0; y < ppm.height; y++ {
+			for x := 0; x < ppm.width; x++ {
+				p := ppm.data[y*ppm.width+x]
+				write(p.R, p.G, p.B)
+			}
+		}
+	})
+}
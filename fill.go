@@ -0,0 +1,115 @@
+package Netpbm
+
+// scanlineFill calls set for every pixel of the region containing start where inside is
+// true, growing it with the given connectivity. Whole horizontal spans are filled at once
+// and only span seeds are pushed on the stack, which keeps it small.
+func scanlineFill(width, height int, start Point, connectivity Connectivity, inside func(x, y int) bool, set func(x, y int)) {
+	if start.X < 0 || start.Y < 0 || start.X >= width || start.Y >= height || !inside(start.X, start.Y) {
+		return
+	}
+	visited := make([][]bool, height)
+	for y := range visited {
+		visited[y] = make([]bool, width)
+	}
+	fillable := func(x, y int) bool {
+		return !visited[y][x] && inside(x, y)
+	}
+
+	spread := 0
+	if connectivity == EightConnected {
+		spread = 1
+	}
+	stack := []Point{start}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fillable(p.X, p.Y) {
+			continue
+		}
+		// Extend the span to the left and right.
+		x1, x2 := p.X, p.X
+		for x1 > 0 && fillable(x1-1, p.Y) {
+			x1--
+		}
+		for x2 < width-1 && fillable(x2+1, p.Y) {
+			x2++
+		}
+		for x := x1; x <= x2; x++ {
+			visited[p.Y][x] = true
+			set(x, p.Y)
+		}
+		// Push one seed per run of fillable pixels in the rows above and below.
+		for _, y := range []int{p.Y - 1, p.Y + 1} {
+			if y < 0 || y >= height {
+				continue
+			}
+			inRun := false
+			for x := clampInt(x1-spread, 0, width-1); x <= clampInt(x2+spread, 0, width-1); x++ {
+				if fillable(x, y) {
+					if !inRun {
+						stack = append(stack, Point{x, y})
+					}
+					inRun = true
+				} else {
+					inRun = false
+				}
+			}
+		}
+	}
+}
+
+// FloodFill fills with newColor the 4-connected region of the PPM image around start
+// whose pixels differ from the color at start by at most tolerance on every channel, like
+// the paint bucket of image editors.
+func (ppm *PPM) FloodFill(start Point, newColor Pixel, tolerance uint8) {
+	ppm.FloodFillConnected(start, newColor, tolerance, FourConnected)
+}
+
+// FloodFillConnected is like FloodFill with the given connectivity; 8-connected fills
+// also leak through diagonal gaps.
+func (ppm *PPM) FloodFillConnected(start Point, newColor Pixel, tolerance uint8, connectivity Connectivity) error {
+	if err := checkConnectivity(connectivity); err != nil {
+		return err
+	}
+	ppm.record("FloodFill", "start", start, "color", newColor, "tolerance", tolerance, "connectivity", connectivity)
+	ppm.own()
+	if start.X < 0 || start.Y < 0 || start.X >= ppm.width || start.Y >= ppm.height {
+		return nil
+	}
+	target := ppm.data[start.Y*ppm.width+start.X]
+	near := func(a, b uint8) bool {
+		if a > b {
+			return a-b <= tolerance
+		}
+		return b-a <= tolerance
+	}
+	scanlineFill(ppm.width, ppm.height, start, connectivity,
+		func(x, y int) bool {
+			p := ppm.data[y*ppm.width+x]
+			return near(p.R, target.R) && near(p.G, target.G) && near(p.B, target.B)
+		},
+		func(x, y int) { ppm.data[y*ppm.width+x] = newColor })
+	return nil
+}
+
+// FloodFill sets to value the 4-connected region of the PBM image around start having the
+// same value as start.
+func (pbm *PBM) FloodFill(start Point, value bool) {
+	pbm.FloodFillConnected(start, value, FourConnected)
+}
+
+// FloodFillConnected is like FloodFill with the given connectivity.
+func (pbm *PBM) FloodFillConnected(start Point, value bool, connectivity Connectivity) error {
+	if err := checkConnectivity(connectivity); err != nil {
+		return err
+	}
+	pbm.record("FloodFill", "start", start, "value", value, "connectivity", connectivity)
+	if start.X < 0 || start.Y < 0 || start.X >= pbm.width || start.Y >= pbm.height {
+		return nil
+	}
+	target := pbm.data[start.Y*pbm.width+start.X]
+	scanlineFill(pbm.width, pbm.height, start, connectivity,
+		func(x, y int) bool { return pbm.data[y*pbm.width+x] == target },
+		func(x, y int) { pbm.data[y*pbm.width+x] = value })
+	return nil
+}
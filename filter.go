@@ -0,0 +1,278 @@
+package Netpbm
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// clampInt restricts v to the range [lo, hi].
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// checkKernel validates a convolution kernel: it must be rectangular with odd dimensions.
+func checkKernel(kernel [][]float64) error {
+	if len(kernel) == 0 || len(kernel)%2 == 0 {
+		return fmt.Errorf("invalid kernel height: %d", len(kernel))
+	}
+	width := len(kernel[0])
+	if width%2 == 0 {
+		return fmt.Errorf("invalid kernel width: %d", width)
+	}
+	for i, row := range kernel {
+		if len(row) != width {
+			return fmt.Errorf("kernel row %d has %d values, want %d", i, len(row), width)
+		}
+	}
+	return nil
+}
+
+// Convolve applies a convolution kernel, such as those of the kernels subpackage, to the
+// PGM image. The kernel is centered on each pixel, edges are extended and results are
+// clamped to the maximum value.
+func (pgm *PGM) Convolve(kernel [][]float64) error {
+	return pgm.ConvolveCtx(context.Background(), kernel)
+}
+
+// ConvolveCtx is Convolve returning the error of ctx, leaving the image unchanged, if ctx
+// is canceled before the convolution completes.
+func (pgm *PGM) ConvolveCtx(ctx context.Context, kernel [][]float64) error {
+	if err := checkKernel(kernel); err != nil {
+		return err
+	}
+
+	max := uint8(255)
+	if pgm.max < 255 {
+		max = uint8(pgm.max)
+	}
+	ry, rx := len(kernel)/2, len(kernel[0])/2
+	filtered := make([]uint8, pgm.width*pgm.height)
+	err := parallelRowsCtx(ctx, pgm.height, func(y int) {
+		for x := 0; x < pgm.width; x++ {
+			var sum float64
+			for ky, row := range kernel {
+				src := pgm.row(clampInt(y+ky-ry, 0, pgm.height-1))
+				for kx, k := range row {
+					sum += k * float64(src[clampInt(x+kx-rx, 0, pgm.width-1)])
+				}
+			}
+			filtered[y*pgm.width+x] = clampUint8(sum, max)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	pgm.record("Convolve", "kernel", kernel)
+	pgm.data = filtered
+	return nil
+}
+
+// Convolve applies a convolution kernel, such as those of the kernels subpackage, to each
+// channel of the PPM image. The kernel is centered on each pixel, edges are extended and
+// results are clamped to the maximum value.
+func (ppm *PPM) Convolve(kernel [][]float64) error {
+	return ppm.ConvolveCtx(context.Background(), kernel)
+}
+
+// ConvolveCtx is Convolve returning the error of ctx, leaving the image unchanged, if ctx
+// is canceled before the convolution completes.
+func (ppm *PPM) ConvolveCtx(ctx context.Context, kernel [][]float64) error {
+	if err := checkKernel(kernel); err != nil {
+		return err
+	}
+
+	ry, rx := len(kernel)/2, len(kernel[0])/2
+	err := ppm.mapNeighborhoodCtx(ctx, func(x, y int, at func(dx, dy int) Pixel) Pixel {
+		var r, g, b float64
+		for ky, row := range kernel {
+			for kx, k := range row {
+				p := at(kx-rx, ky-ry)
+				r += k * float64(p.R)
+				g += k * float64(p.G)
+				b += k * float64(p.B)
+			}
+		}
+		return Pixel{clampUint8(r, ppm.max), clampUint8(g, ppm.max), clampUint8(b, ppm.max)}
+	})
+	if err != nil {
+		return err
+	}
+	ppm.record("Convolve", "kernel", kernel)
+	return nil
+}
+
+// mapNeighborhood replaces every pixel of the PPM image with the result of fn, which reads
+// the original neighbors of (x, y) through at; coordinates outside the image are clamped
+// to the nearest edge. Rows are processed in parallel.
+func (ppm *PPM) mapNeighborhood(fn func(x, y int, at func(dx, dy int) Pixel) Pixel) {
+	ppm.mapNeighborhoodCtx(context.Background(), fn)
+}
+
+// mapNeighborhoodCtx is mapNeighborhood returning the error of ctx, leaving the image
+// unchanged, if ctx is canceled before all the pixels are computed.
+func (ppm *PPM) mapNeighborhoodCtx(ctx context.Context, fn func(x, y int, at func(dx, dy int) Pixel) Pixel) error {
+	filtered := make([]Pixel, ppm.width*ppm.height)
+	err := parallelRowsCtx(ctx, ppm.height, func(y int) {
+		for x := 0; x < ppm.width; x++ {
+			filtered[y*ppm.width+x] = fn(x, y, func(dx, dy int) Pixel {
+				return ppm.data[clampInt(y+dy, 0, ppm.height-1)*ppm.width+clampInt(x+dx, 0, ppm.width-1)]
+			})
+		}
+	})
+	if err != nil {
+		return err
+	}
+	ppm.data = filtered
+	return nil
+}
+
+// bilateralWeights precomputes the spatial weights of a bilateral filter and returns its radius.
+func bilateralWeights(sigmaSpace float64) (int, [][]float64) {
+	radius := int(math.Ceil(2 * sigmaSpace))
+	weights := make([][]float64, 2*radius+1)
+	for dy := -radius; dy <= radius; dy++ {
+		weights[dy+radius] = make([]float64, 2*radius+1)
+		for dx := -radius; dx <= radius; dx++ {
+			weights[dy+radius][dx+radius] = math.Exp(-float64(dx*dx+dy*dy) / (2 * sigmaSpace * sigmaSpace))
+		}
+	}
+	return radius, weights
+}
+
+// checkBilateral validates the parameters of a bilateral filter.
+func checkBilateral(sigmaSpace, sigmaColor float64) error {
+	if sigmaSpace <= 0 || sigmaColor <= 0 {
+		return fmt.Errorf("invalid bilateral filter sigmas: %v, %v", sigmaSpace, sigmaColor)
+	}
+	return nil
+}
+
+// BilateralFilter smooths the PGM image while preserving edges: each pixel becomes an
+// average of its neighbors weighted both by distance (sigmaSpace, in pixels) and by
+// similarity of value (sigmaColor, in sample units).
+func (pgm *PGM) BilateralFilter(sigmaSpace, sigmaColor float64) error {
+	if err := checkBilateral(sigmaSpace, sigmaColor); err != nil {
+		return err
+	}
+	pgm.record("BilateralFilter", "sigmaSpace", sigmaSpace, "sigmaColor", sigmaColor)
+
+	radius, spatial := bilateralWeights(sigmaSpace)
+	var rangeWeights [256]float64
+	for d := range rangeWeights {
+		rangeWeights[d] = math.Exp(-float64(d*d) / (2 * sigmaColor * sigmaColor))
+	}
+
+	filtered := make([]uint8, pgm.width*pgm.height)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			center := int(pgm.data[y*pgm.width+x])
+			var sum, total float64
+			for dy := -radius; dy <= radius; dy++ {
+				row := pgm.row(clampInt(y+dy, 0, pgm.height-1))
+				for dx := -radius; dx <= radius; dx++ {
+					v := int(row[clampInt(x+dx, 0, pgm.width-1)])
+					d := v - center
+					if d < 0 {
+						d = -d
+					}
+					w := spatial[dy+radius][dx+radius] * rangeWeights[d]
+					sum += w * float64(v)
+					total += w
+				}
+			}
+			filtered[y*pgm.width+x] = clampUint8(sum/total, 255)
+		}
+	}
+	pgm.data = filtered
+	return nil
+}
+
+// NLMeansDenoise removes noise from the PGM image with the non-local means algorithm:
+// each pixel becomes an average of the pixels of a searchWindow x searchWindow area
+// weighted by the similarity of the patchSize x patchSize patches around them. h controls
+// the strength of the filtering, in sample units. Rows are processed in parallel.
+func (pgm *PGM) NLMeansDenoise(h float64, patchSize, searchWindow int) error {
+	if h <= 0 {
+		return fmt.Errorf("invalid filtering strength: %v", h)
+	}
+	if patchSize < 1 || patchSize%2 == 0 {
+		return fmt.Errorf("invalid patch size: %d", patchSize)
+	}
+	if searchWindow < 1 || searchWindow%2 == 0 {
+		return fmt.Errorf("invalid search window: %d", searchWindow)
+	}
+	pgm.record("NLMeansDenoise", "h", h, "patchSize", patchSize, "searchWindow", searchWindow)
+
+	patchRadius, searchRadius := patchSize/2, searchWindow/2
+	patchArea := float64(patchSize * patchSize)
+	at := func(x, y int) float64 {
+		return float64(pgm.data[clampInt(y, 0, pgm.height-1)*pgm.width+clampInt(x, 0, pgm.width-1)])
+	}
+
+	filtered := make([]uint8, pgm.width*pgm.height)
+	parallelRows(pgm.height, func(y int) {
+		for x := 0; x < pgm.width; x++ {
+			var sum, total float64
+			for sy := y - searchRadius; sy <= y+searchRadius; sy++ {
+				for sx := x - searchRadius; sx <= x+searchRadius; sx++ {
+					var dist float64
+					for py := -patchRadius; py <= patchRadius; py++ {
+						for px := -patchRadius; px <= patchRadius; px++ {
+							d := at(x+px, y+py) - at(sx+px, sy+py)
+							dist += d * d
+						}
+					}
+					w := math.Exp(-dist / patchArea / (h * h))
+					sum += w * at(sx, sy)
+					total += w
+				}
+			}
+			filtered[y*pgm.width+x] = clampUint8(sum/total, 255)
+		}
+	})
+	pgm.data = filtered
+	return nil
+}
+
+// BilateralFilter smooths the PPM image while preserving edges: each pixel becomes an
+// average of its neighbors weighted both by distance (sigmaSpace, in pixels) and by
+// color similarity (sigmaColor, Euclidean RGB distance in sample units).
+func (ppm *PPM) BilateralFilter(sigmaSpace, sigmaColor float64) error {
+	if err := checkBilateral(sigmaSpace, sigmaColor); err != nil {
+		return err
+	}
+	ppm.record("BilateralFilter", "sigmaSpace", sigmaSpace, "sigmaColor", sigmaColor)
+
+	radius, spatial := bilateralWeights(sigmaSpace)
+	filtered := make([]Pixel, ppm.width*ppm.height)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			c := ppm.data[y*ppm.width+x]
+			var r, g, b, total float64
+			for dy := -radius; dy <= radius; dy++ {
+				row := ppm.row(clampInt(y+dy, 0, ppm.height-1))
+				for dx := -radius; dx <= radius; dx++ {
+					p := row[clampInt(x+dx, 0, ppm.width-1)]
+					dr := float64(p.R) - float64(c.R)
+					dg := float64(p.G) - float64(c.G)
+					db := float64(p.B) - float64(c.B)
+					w := spatial[dy+radius][dx+radius] * math.Exp(-(dr*dr+dg*dg+db*db)/(2*sigmaColor*sigmaColor))
+					r += w * float64(p.R)
+					g += w * float64(p.G)
+					b += w * float64(p.B)
+					total += w
+				}
+			}
+			filtered[y*ppm.width+x] = Pixel{clampUint8(r/total, 255), clampUint8(g/total, 255), clampUint8(b/total, 255)}
+		}
+	}
+	ppm.data = filtered
+	return nil
+}
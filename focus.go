@@ -0,0 +1,125 @@
+package Netpbm
+
+import "fmt"
+
+// sobelEnergy returns the squared Sobel gradient magnitude of the PGM image at (x, y),
+// normalized by the squared maximum value. Edges are extended.
+func (pgm *PGM) sobelEnergy(x, y int) float64 {
+	at := func(dx, dy int) float64 {
+		return float64(pgm.data[clampInt(y+dy, 0, pgm.height-1)*pgm.width+clampInt(x+dx, 0, pgm.width-1)])
+	}
+	gx := at(1, -1) + 2*at(1, 0) + at(1, 1) - at(-1, -1) - 2*at(-1, 0) - at(-1, 1)
+	gy := at(-1, 1) + 2*at(0, 1) + at(1, 1) - at(-1, -1) - 2*at(0, -1) - at(1, -1)
+	max := float64(pgm.max)
+	if max == 0 {
+		return 0
+	}
+	return (gx*gx + gy*gy) / (max * max)
+}
+
+// focusEnergy returns the mean Sobel energy of the pixels of the PGM image in [x0, x1) x [y0, y1).
+func (pgm *PGM) focusEnergy(x0, y0, x1, y1 int) float64 {
+	var sum float64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			sum += pgm.sobelEnergy(x, y)
+		}
+	}
+	n := (x1 - x0) * (y1 - y0)
+	if n <= 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// FocusScore measures how sharp the PGM image is, as the mean squared Sobel gradient
+// (Tenengrad). Scores only compare images of the same scene: among several shots, the
+// best-focused one has the highest score.
+func (pgm *PGM) FocusScore() float64 {
+	return pgm.focusEnergy(0, 0, pgm.width, pgm.height)
+}
+
+// FocusScoreTiles computes the FocusScore of every tileSize x tileSize tile of the PGM
+// image, indexed by tile row then column; tiles on the right and bottom edges may be
+// smaller. It shows which parts of the image are in focus.
+func (pgm *PGM) FocusScoreTiles(tileSize int) ([][]float64, error) {
+	if tileSize < 1 {
+		return nil, fmt.Errorf("invalid tile size: %d", tileSize)
+	}
+	rows := (pgm.height + tileSize - 1) / tileSize
+	cols := (pgm.width + tileSize - 1) / tileSize
+	scores := make([][]float64, rows)
+	for ty := range scores {
+		scores[ty] = make([]float64, cols)
+		for tx := range scores[ty] {
+			x0, y0 := tx*tileSize, ty*tileSize
+			scores[ty][tx] = pgm.focusEnergy(x0, y0, min(x0+tileSize, pgm.width), min(y0+tileSize, pgm.height))
+		}
+	}
+	return scores, nil
+}
+
+// SharpestFrame returns the index of the frame with the highest FocusScore, or -1 when
+// frames is empty.
+func SharpestFrame(frames []*PGM) int {
+	best, bestScore := -1, 0.0
+	for i, frame := range frames {
+		if score := frame.FocusScore(); best < 0 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// laplacianVariance returns the variance of the 4-neighbor Laplacian of a plane of samples
+// normalized to [0, 1], over the pixels whose neighbors are all inside the image.
+func laplacianVariance(p plane) float64 {
+	var sum, sumSq float64
+	var n int
+	for y := 1; y < len(p)-1; y++ {
+		for x := 1; x < len(p[y])-1; x++ {
+			l := p[y-1][x] + p[y+1][x] + p[y][x-1] + p[y][x+1] - 4*p[y][x]
+			sum += l
+			sumSq += l * l
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	m := sum / float64(n)
+	return sumSq/float64(n) - m*m
+}
+
+// Sharpness returns the variance of the Laplacian of the PGM image, its samples being
+// normalized to [0, 1]. Unlike FocusScore it is meant as an absolute measure: blurry or
+// blank scans score close to 0, and a threshold tuned once on a corpus can flag them.
+func (pgm *PGM) Sharpness() float64 {
+	if pgm.max == 0 {
+		return 0
+	}
+	p := pgm.planes()[0]
+	for _, row := range p {
+		for x := range row {
+			row[x] /= float64(pgm.max)
+		}
+	}
+	return laplacianVariance(p)
+}
+
+// Sharpness returns the variance of the Laplacian of the luminance of the PPM image,
+// normalized to [0, 1] (see PGM.Sharpness).
+func (ppm *PPM) Sharpness() float64 {
+	if ppm.max == 0 {
+		return 0
+	}
+	p := make(plane, ppm.height)
+	for y := 0; y < ppm.height; y++ {
+		row := ppm.row(y)
+		p[y] = make([]float64, len(row))
+		for x, v := range row {
+			p[y][x] = Rec601Luma(v) / float64(ppm.max)
+		}
+	}
+	return laplacianVariance(p)
+}
@@ -0,0 +1,136 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Format describes an image format identified by the magic number at the start of its files.
+type Format struct {
+	// Name is a short human readable name, such as "PGM".
+	Name string
+	// Magic is the magic number of the format, such as "P8". It must be at least 2 bytes long.
+	Magic string
+	// Decode reads an image from r, positioned at the start of the magic number.
+	Decode func(r io.Reader) (Image, error)
+	// Encode writes img to w. It may be nil for formats that can only be read.
+	Encode func(w io.Writer, img Image) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+func init() {
+	decodePBM := func(r io.Reader) (Image, error) { return DecodePBM(r) }
+	decodePGM := func(r io.Reader) (Image, error) { return DecodePGM(r) }
+	decodePPM := func(r io.Reader) (Image, error) { return DecodePPM(r) }
+	for _, f := range []Format{
+		{"PBM", "P1", decodePBM, encodeAs("P1")},
+		{"PBM", "P4", decodePBM, encodeAs("P4")},
+		{"PGM", "P2", decodePGM, encodeAs("P2")},
+		{"PGM", "P5", decodePGM, encodeAs("P5")},
+		{"PPM", "P3", decodePPM, encodeAs("P3")},
+		{"PPM", "P6", decodePPM, encodeAs("P6")},
+	} {
+		formats[f.Magic] = f
+	}
+}
+
+// encodeAs returns the encoder of a built-in format, writing a copy of the image header
+// with the given magic number.
+func encodeAs(magic string) func(w io.Writer, img Image) error {
+	return func(w io.Writer, img Image) error {
+		switch v := img.(type) {
+		case *PBM:
+			if magic == "P1" || magic == "P4" {
+				c := *v
+				c.magicNumber = magic
+				return c.Encode(w)
+			}
+		case *PGM:
+			if magic == "P2" || magic == "P5" {
+				c := *v
+				c.magicNumber = magic
+				return c.Encode(w)
+			}
+		case *PPM:
+			if magic == "P3" || magic == "P6" {
+				c := *v
+				c.magicNumber = magic
+				return c.Encode(w)
+			}
+		}
+		return fmt.Errorf("cannot encode %T as %s", img, magic)
+	}
+}
+
+// RegisterFormat adds a format so that ReadPNM and DecodeAny decode files starting with its
+// magic number, letting other packages support experimental formats such as "P8" without
+// forking the parsers. Magic numbers already registered, including the built-in P1 to P6,
+// are rejected; magic numbers must not be prefixes of each other.
+func RegisterFormat(f Format) error {
+	if len(f.Magic) < 2 {
+		return fmt.Errorf("invalid magic number: %q", f.Magic)
+	}
+	if f.Decode == nil {
+		return fmt.Errorf("format %s has no decoder", f.Magic)
+	}
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	for magic := range formats {
+		if strings.HasPrefix(f.Magic, magic) || strings.HasPrefix(magic, f.Magic) {
+			return fmt.Errorf("magic number %q conflicts with registered format %q", f.Magic, magic)
+		}
+	}
+	formats[f.Magic] = f
+	return nil
+}
+
+// Formats returns the registered formats sorted by magic number.
+func Formats() []Format {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	list := make([]Format, 0, len(formats))
+	for _, f := range formats {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Magic < list[j].Magic })
+	return list
+}
+
+// lookupFormat returns the registered format whose magic number starts the data of reader.
+func lookupFormat(reader *bufio.Reader) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	for magic, f := range formats {
+		peeked, err := reader.Peek(len(magic))
+		if err == nil && string(peeked) == magic {
+			return f, nil
+		}
+	}
+	peeked, err := reader.Peek(2)
+	if err != nil {
+		return Format{}, fmt.Errorf("error reading magic number: %v", err)
+	}
+	return Format{}, fmt.Errorf("invalid magic number: %s", peeked)
+}
+
+// EncodeFormat writes img to w in the registered format of the given magic number.
+func EncodeFormat(w io.Writer, img Image, magic string) error {
+	formatsMu.RLock()
+	f, ok := formats[magic]
+	formatsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown format: %s", magic)
+	}
+	if f.Encode == nil {
+		return fmt.Errorf("format %s cannot be encoded", magic)
+	}
+	return f.Encode(w, img)
+}
@@ -0,0 +1,127 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// gammaTable returns the lookup table raising normalized samples to the power 1/gamma.
+func gammaTable(gamma float64, max uint8) [256]uint8 {
+	var table [256]uint8
+	for v := range table {
+		if max == 0 {
+			continue
+		}
+		table[v] = clampUint8(math.Pow(float64(v)/float64(max), 1/gamma)*float64(max), max)
+	}
+	return table
+}
+
+// checkGamma validates a gamma value.
+func checkGamma(gamma float64) error {
+	if gamma <= 0 || math.IsInf(gamma, 0) || math.IsNaN(gamma) {
+		return fmt.Errorf("invalid gamma: %v", gamma)
+	}
+	return nil
+}
+
+// medianGamma returns the gamma moving the median of a histogram of samples to mid-gray,
+// or 1 when the median is black or white.
+func medianGamma(histogram []int, total int, max uint8) float64 {
+	if total == 0 || max == 0 {
+		return 1
+	}
+	var count int
+	for v, n := range histogram {
+		count += n
+		if 2*count >= total {
+			m := float64(v) / float64(max)
+			if m <= 0 || m >= 1 {
+				return 1
+			}
+			return math.Log(m) / math.Log(0.5)
+		}
+	}
+	return 1
+}
+
+// maxUint8 returns the maximum value of the PGM image as a sample.
+func (pgm *PGM) maxUint8() uint8 {
+	if pgm.max > 255 {
+		return 255
+	}
+	return uint8(pgm.max)
+}
+
+// Gamma applies a gamma correction to the PGM image: normalized samples are raised to the
+// power 1/gamma, so values above 1 brighten the midtones and values below 1 darken them.
+func (pgm *PGM) Gamma(gamma float64) error {
+	if err := checkGamma(gamma); err != nil {
+		return err
+	}
+	pgm.record("Gamma", "gamma", gamma)
+	table := gammaTable(gamma, pgm.maxUint8())
+	parallelRows(pgm.height, func(i int) {
+		for j := 0; j < pgm.width; j++ {
+			pgm.data[i*pgm.width+j] = table[pgm.data[i*pgm.width+j]]
+		}
+	})
+	return nil
+}
+
+// EstimateGamma returns the gamma that Gamma needs to bring the median of the PGM image
+// to mid-gray, or 1 for images whose median is black or white.
+func (pgm *PGM) EstimateGamma() float64 {
+	histogram := make([]int, 256)
+	for i := 0; i < pgm.height; i++ {
+		for j := 0; j < pgm.width; j++ {
+			histogram[pgm.data[i*pgm.width+j]]++
+		}
+	}
+	return medianGamma(histogram, pgm.width*pgm.height, pgm.maxUint8())
+}
+
+// AutoGamma corrects the PGM image with the gamma returned by EstimateGamma, and returns it.
+func (pgm *PGM) AutoGamma() float64 {
+	gamma := pgm.EstimateGamma()
+	pgm.Gamma(gamma)
+	return gamma
+}
+
+// Gamma applies a gamma correction to every channel of the PPM image: normalized samples
+// are raised to the power 1/gamma, so values above 1 brighten the midtones and values
+// below 1 darken them.
+func (ppm *PPM) Gamma(gamma float64) error {
+	if err := checkGamma(gamma); err != nil {
+		return err
+	}
+	ppm.record("Gamma", "gamma", gamma)
+	ppm.own()
+	table := gammaTable(gamma, ppm.max)
+	parallelRows(ppm.height, func(i int) {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			ppm.data[i*ppm.width+j] = Pixel{table[p.R], table[p.G], table[p.B]}
+		}
+	})
+	return nil
+}
+
+// EstimateGamma returns the gamma that Gamma needs to bring the median luminance of the
+// PPM image to mid-gray, or 1 for images whose median is black or white.
+func (ppm *PPM) EstimateGamma() float64 {
+	histogram := make([]int, 256)
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			histogram[clampUint8(Rec601Luma(ppm.data[i*ppm.width+j]), ppm.max)]++
+		}
+	}
+	return medianGamma(histogram, ppm.width*ppm.height, ppm.max)
+}
+
+// AutoGamma corrects the PPM image with the gamma returned by EstimateGamma, and returns it.
+func (ppm *PPM) AutoGamma() float64 {
+	gamma := ppm.EstimateGamma()
+	ppm.Gamma(gamma)
+	return gamma
+}
@@ -1,3 +1,8 @@
 module nom_du_module
 
 go 1.21.0
+
+require (
+	golang.org/x/image v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
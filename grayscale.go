@@ -0,0 +1,48 @@
+package Netpbm
+
+import "math"
+
+// GrayscaleMethod computes the gray value of a pixel, in sample units.
+type GrayscaleMethod func(p Pixel) float64
+
+// WeightedGray returns a method computing a weighted sum of the channels. Weights
+// normally add up to 1.
+func WeightedGray(r, g, b float64) GrayscaleMethod {
+	return func(p Pixel) float64 {
+		return r*float64(p.R) + g*float64(p.G) + b*float64(p.B)
+	}
+}
+
+// ChannelGray returns a method using a single channel as the gray value.
+func ChannelGray(c Channel) GrayscaleMethod {
+	return func(p Pixel) float64 {
+		return float64(c.get(p))
+	}
+}
+
+var (
+	// Rec601Luma weights the channels as in ITU-R BT.601 (standard definition video).
+	Rec601Luma = WeightedGray(0.299, 0.587, 0.114)
+	// Rec709Luma weights the channels as in ITU-R BT.709 (HDTV and sRGB).
+	Rec709Luma = WeightedGray(0.2126, 0.7152, 0.0722)
+	// AverageGray uses the mean of the three channels.
+	AverageGray = WeightedGray(1.0/3, 1.0/3, 1.0/3)
+)
+
+// LightnessGray uses the midpoint between the brightest and the darkest channel.
+func LightnessGray(p Pixel) float64 {
+	maxC := math.Max(float64(p.R), math.Max(float64(p.G), float64(p.B)))
+	minC := math.Min(float64(p.R), math.Min(float64(p.G), float64(p.B)))
+	return (maxC + minC) / 2
+}
+
+// ToPGMWith converts the PPM image to a PGM image using the given grayscale method.
+func (ppm *PPM) ToPGMWith(method GrayscaleMethod) *PGM {
+	pgm := newPGM(ppm.width, ppm.height, "P2", uint(ppm.max))
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			pgm.data[i*pgm.width+j] = clampUint8(method(ppm.data[i*ppm.width+j]), ppm.max)
+		}
+	}
+	return pgm
+}
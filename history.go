@@ -0,0 +1,78 @@
+package Netpbm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Param is a named parameter of a recorded operation.
+type Param struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// Operation describes one filter applied to an image.
+type Operation struct {
+	Name   string  `json:"name"`
+	Params []Param `json:"params,omitempty"`
+}
+
+// String formats the operation as its name followed by name=value parameters.
+func (op Operation) String() string {
+	var b strings.Builder
+	b.WriteString(op.Name)
+	for _, p := range op.Params {
+		fmt.Fprintf(&b, " %s=%v", p.Name, p.Value)
+	}
+	return b.String()
+}
+
+// history records the operations applied to an image once enabled.
+// It is embedded in every image type.
+type history struct {
+	enabled bool
+	ops     []Operation
+}
+
+// EnableHistory starts recording every filter applied to the image, so the processing of
+// an output can be reproduced.
+func (h *history) EnableHistory() {
+	h.enabled = true
+}
+
+// History returns the operations recorded since EnableHistory was called.
+func (h *history) History() []Operation {
+	return append([]Operation(nil), h.ops...)
+}
+
+// HistoryComments returns the recorded operations formatted as Netpbm comment lines.
+func (h *history) HistoryComments() []string {
+	comments := make([]string, len(h.ops))
+	for i, op := range h.ops {
+		comments[i] = "# " + op.String()
+	}
+	return comments
+}
+
+// HistoryJSON returns the recorded operations encoded as a JSON array.
+func (h *history) HistoryJSON() ([]byte, error) {
+	ops := h.ops
+	if ops == nil {
+		ops = []Operation{}
+	}
+	return json.Marshal(ops)
+}
+
+// record appends an operation to the history when recording is enabled.
+// Parameters are given as alternating names and values.
+func (h *history) record(name string, keysAndValues ...interface{}) {
+	if !h.enabled {
+		return
+	}
+	op := Operation{Name: name}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		op.Params = append(op.Params, Param{Name: fmt.Sprint(keysAndValues[i]), Value: keysAndValues[i+1]})
+	}
+	h.ops = append(h.ops, op)
+}
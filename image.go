@@ -0,0 +1,194 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Image is implemented by the PBM, PGM and PPM types.
+type Image interface {
+	Size() (int, int)
+	Encode(w io.Writer) error
+	Save(filename string) error
+}
+
+// ReadPNM reads an image from a file, selecting the decoder from its magic number as DecodeAny does.
+func ReadPNM(filename string) (Image, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return DecodeAny(file)
+}
+
+// DecodeAny reads an image from r, selecting the decoder from its magic number among the
+// built-in PBM, PGM and PPM formats and those added with RegisterFormat.
+func DecodeAny(r io.Reader) (Image, error) {
+	reader := bufio.NewReader(r)
+	f, err := lookupFormat(reader)
+	if err != nil {
+		return nil, err
+	}
+	return f.Decode(reader)
+}
+
+// newPBM allocates a blank PBM image.
+func newPBM(width, height int, magicNumber string) *PBM {
+	return &PBM{data: make([]bool, width*height), width: width, height: height, magicNumber: magicNumber}
+}
+
+// newPGM allocates a black PGM image.
+func newPGM(width, height int, magicNumber string, max uint) *PGM {
+	return &PGM{data: make([]uint8, width*height), width: width, height: height, magicNumber: magicNumber, max: max}
+}
+
+// newPPM allocates a black PPM image.
+func newPPM(width, height int, magicNumber string, max uint8) *PPM {
+	return &PPM{data: make([]Pixel, width*height), width: width, height: height, magicNumber: magicNumber, max: max}
+}
+
+// checkNewImage validates the size, maximum value and fill values given to the New
+// constructors; a single fill value at most may be given.
+func checkNewImage(width, height int, max uint, fills int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size: %dx%d", width, height)
+	}
+	if max == 0 || max > 255 {
+		return fmt.Errorf("invalid max value: %d", max)
+	}
+	if fills > 1 {
+		return fmt.Errorf("too many fill values: %d", fills)
+	}
+	return nil
+}
+
+// checkData ensures a pixel buffer passed to the FromData constructors holds a whole image.
+func checkData(length, width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size: %dx%d", width, height)
+	}
+	if length != width*height {
+		return fmt.Errorf("data has %d pixels, want %d for size %dx%d", length, width*height, width, height)
+	}
+	return nil
+}
+
+// NewPBM returns a PBM image of the given size in the P1 format, cleared or filled with
+// the optional fill value.
+func NewPBM(width, height int, fill ...bool) (*PBM, error) {
+	if err := checkNewImage(width, height, 1, len(fill)); err != nil {
+		return nil, err
+	}
+	pbm := newPBM(width, height, "P1")
+	if len(fill) == 1 && fill[0] {
+		for i := range pbm.data {
+			pbm.data[i] = true
+		}
+	}
+	return pbm, nil
+}
+
+// NewPGM returns a PGM image of the given size and maximum value, at most 255, in the P2
+// format, black or filled with the optional fill value.
+func NewPGM(width, height int, max uint, fill ...uint8) (*PGM, error) {
+	if err := checkNewImage(width, height, max, len(fill)); err != nil {
+		return nil, err
+	}
+	pgm := newPGM(width, height, "P2", max)
+	if len(fill) == 1 {
+		if uint(fill[0]) > max {
+			return nil, fmt.Errorf("fill value %d exceeds max value %d", fill[0], max)
+		}
+		for i := range pgm.data {
+			pgm.data[i] = fill[0]
+		}
+	}
+	return pgm, nil
+}
+
+// NewPPM returns a PPM image of the given size and maximum value in the P3 format, black
+// or filled with the optional fill color.
+func NewPPM(width, height int, max uint8, fill ...Pixel) (*PPM, error) {
+	if err := checkNewImage(width, height, uint(max), len(fill)); err != nil {
+		return nil, err
+	}
+	ppm := newPPM(width, height, "P3", max)
+	if len(fill) == 1 {
+		c := fill[0]
+		if c.R > max || c.G > max || c.B > max {
+			return nil, fmt.Errorf("fill color %v exceeds max value %d", c, max)
+		}
+		for i := range ppm.data {
+			ppm.data[i] = c
+		}
+	}
+	return ppm, nil
+}
+
+// NewPBMFromData returns a PBM image in the P1 format holding a copy of data, the pixels
+// of the image row after row.
+func NewPBMFromData(width, height int, data []bool) (*PBM, error) {
+	if err := checkData(len(data), width, height); err != nil {
+		return nil, err
+	}
+	pbm := newPBM(width, height, "P1")
+	copy(pbm.data, data)
+	return pbm, nil
+}
+
+// NewPGMFromData returns a PGM image in the P2 format holding a copy of data, the values
+// of the image row after row, none of which may exceed max.
+func NewPGMFromData(width, height int, max uint, data []uint8) (*PGM, error) {
+	if err := checkData(len(data), width, height); err != nil {
+		return nil, err
+	}
+	if err := checkNewImage(width, height, max, 0); err != nil {
+		return nil, err
+	}
+	for i, v := range data {
+		if uint(v) > max {
+			return nil, fmt.Errorf("value %d at (%d, %d) exceeds max value %d", v, i%width, i/width, max)
+		}
+	}
+	pgm := newPGM(width, height, "P2", max)
+	copy(pgm.data, data)
+	return pgm, nil
+}
+
+// NewPPMFromData returns a PPM image in the P3 format holding a copy of data, the pixels
+// of the image row after row, none of whose channels may exceed max.
+func NewPPMFromData(width, height int, max uint8, data []Pixel) (*PPM, error) {
+	if err := checkData(len(data), width, height); err != nil {
+		return nil, err
+	}
+	if err := checkNewImage(width, height, uint(max), 0); err != nil {
+		return nil, err
+	}
+	for i, c := range data {
+		if c.R > max || c.G > max || c.B > max {
+			return nil, fmt.Errorf("color %v at (%d, %d) exceeds max value %d", c, i%width, i/width, max)
+		}
+	}
+	ppm := newPPM(width, height, "P3", max)
+	copy(ppm.data, data)
+	return ppm, nil
+}
+
+// row returns the pixels of row y of the PBM image.
+func (pbm *PBM) row(y int) []bool {
+	return pbm.data[y*pbm.width : (y+1)*pbm.width]
+}
+
+// row returns the pixels of row y of the PGM image.
+func (pgm *PGM) row(y int) []uint8 {
+	return pgm.data[y*pgm.width : (y+1)*pgm.width]
+}
+
+// row returns the pixels of row y of the PPM image.
+func (ppm *PPM) row(y int) []Pixel {
+	return ppm.data[y*ppm.width : (y+1)*ppm.width]
+}
@@ -0,0 +1,106 @@
+package Netpbm
+
+// ForEach calls fn with the coordinates and color of every pixel of the PPM image, row
+// after row.
+func (ppm *PPM) ForEach(fn func(x, y int, p Pixel)) {
+	for y := 0; y < ppm.height; y++ {
+		for x, p := range ppm.row(y) {
+			fn(x, y, p)
+		}
+	}
+}
+
+// MapPixels replaces every pixel of the PPM image with the result of fn, each channel
+// clamped to the maximum value.
+func (ppm *PPM) MapPixels(fn func(p Pixel) Pixel) {
+	ppm.record("MapPixels")
+	ppm.own()
+	for y := 0; y < ppm.height; y++ {
+		ppm.mapRow(y, fn)
+	}
+}
+
+// MapPixelsParallel is MapPixels spreading the rows across goroutines, see SetParallelism.
+// fn must be safe for concurrent use.
+func (ppm *PPM) MapPixelsParallel(fn func(p Pixel) Pixel) {
+	ppm.record("MapPixelsParallel")
+	ppm.own()
+	parallelRows(ppm.height, func(y int) { ppm.mapRow(y, fn) })
+}
+
+// mapRow replaces the pixels of row y with the result of fn.
+func (ppm *PPM) mapRow(y int, fn func(p Pixel) Pixel) {
+	row := ppm.row(y)
+	for x, p := range row {
+		p = fn(p)
+		row[x] = Pixel{min(p.R, ppm.max), min(p.G, ppm.max), min(p.B, ppm.max)}
+	}
+}
+
+// ForEach calls fn with the coordinates and value of every pixel of the PGM image, row
+// after row.
+func (pgm *PGM) ForEach(fn func(x, y int, v uint8)) {
+	for y := 0; y < pgm.height; y++ {
+		for x, v := range pgm.row(y) {
+			fn(x, y, v)
+		}
+	}
+}
+
+// MapPixels replaces every pixel of the PGM image with the result of fn, clamped to the
+// maximum value.
+func (pgm *PGM) MapPixels(fn func(v uint8) uint8) {
+	pgm.record("MapPixels")
+	for y := 0; y < pgm.height; y++ {
+		pgm.mapRow(y, fn)
+	}
+}
+
+// MapPixelsParallel is MapPixels spreading the rows across goroutines, see SetParallelism.
+// fn must be safe for concurrent use.
+func (pgm *PGM) MapPixelsParallel(fn func(v uint8) uint8) {
+	pgm.record("MapPixelsParallel")
+	parallelRows(pgm.height, func(y int) { pgm.mapRow(y, fn) })
+}
+
+// mapRow replaces the pixels of row y with the result of fn.
+func (pgm *PGM) mapRow(y int, fn func(v uint8) uint8) {
+	max := uint8(min(pgm.max, 255))
+	row := pgm.row(y)
+	for x, v := range row {
+		row[x] = min(fn(v), max)
+	}
+}
+
+// ForEach calls fn with the coordinates and value of every pixel of the PBM image, row
+// after row.
+func (pbm *PBM) ForEach(fn func(x, y int, v bool)) {
+	for y := 0; y < pbm.height; y++ {
+		for x, v := range pbm.row(y) {
+			fn(x, y, v)
+		}
+	}
+}
+
+// MapPixels replaces every pixel of the PBM image with the result of fn.
+func (pbm *PBM) MapPixels(fn func(v bool) bool) {
+	pbm.record("MapPixels")
+	for y := 0; y < pbm.height; y++ {
+		pbm.mapRow(y, fn)
+	}
+}
+
+// MapPixelsParallel is MapPixels spreading the rows across goroutines, see SetParallelism.
+// fn must be safe for concurrent use.
+func (pbm *PBM) MapPixelsParallel(fn func(v bool) bool) {
+	pbm.record("MapPixelsParallel")
+	parallelRows(pbm.height, func(y int) { pbm.mapRow(y, fn) })
+}
+
+// mapRow replaces the pixels of row y with the result of fn.
+func (pbm *PBM) mapRow(y int, fn func(v bool) bool) {
+	row := pbm.row(y)
+	for x, v := range row {
+		row[x] = fn(v)
+	}
+}
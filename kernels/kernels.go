@@ -0,0 +1,135 @@
+// Package kernels provides named convolution kernels for the Convolve methods of the
+// Netpbm images. Every function returns a new matrix that callers may modify.
+package kernels
+
+import "math"
+
+// Gaussian returns a normalized Gaussian blur kernel of standard deviation sigma, in
+// pixels. The kernel is (2*ceil(3*sigma)+1) pixels wide, covering 99.7% of the weight.
+func Gaussian(sigma float64) [][]float64 {
+	if sigma <= 0 {
+		return Identity()
+	}
+	radius := int(math.Ceil(3 * sigma))
+	size := 2*radius + 1
+	kernel := make([][]float64, size)
+	var total float64
+	for y := range kernel {
+		kernel[y] = make([]float64, size)
+		for x := range kernel[y] {
+			dx, dy := float64(x-radius), float64(y-radius)
+			kernel[y][x] = math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+			total += kernel[y][x]
+		}
+	}
+	for y := range kernel {
+		for x := range kernel[y] {
+			kernel[y][x] /= total
+		}
+	}
+	return kernel
+}
+
+// Box returns a normalized size x size averaging kernel. Even sizes are rounded up.
+func Box(size int) [][]float64 {
+	if size < 1 {
+		size = 1
+	}
+	if size%2 == 0 {
+		size++
+	}
+	kernel := make([][]float64, size)
+	for y := range kernel {
+		kernel[y] = make([]float64, size)
+		for x := range kernel[y] {
+			kernel[y][x] = 1 / float64(size*size)
+		}
+	}
+	return kernel
+}
+
+// Identity returns the 1x1 kernel leaving images unchanged.
+func Identity() [][]float64 {
+	return [][]float64{{1}}
+}
+
+// SobelX returns the Sobel operator responding to horizontal intensity changes (vertical edges).
+func SobelX() [][]float64 {
+	return [][]float64{
+		{-1, 0, 1},
+		{-2, 0, 2},
+		{-1, 0, 1},
+	}
+}
+
+// SobelY returns the Sobel operator responding to vertical intensity changes (horizontal edges).
+func SobelY() [][]float64 {
+	return [][]float64{
+		{-1, -2, -1},
+		{0, 0, 0},
+		{1, 2, 1},
+	}
+}
+
+// PrewittX returns the Prewitt operator responding to horizontal intensity changes.
+func PrewittX() [][]float64 {
+	return [][]float64{
+		{-1, 0, 1},
+		{-1, 0, 1},
+		{-1, 0, 1},
+	}
+}
+
+// PrewittY returns the Prewitt operator responding to vertical intensity changes.
+func PrewittY() [][]float64 {
+	return [][]float64{
+		{-1, -1, -1},
+		{0, 0, 0},
+		{1, 1, 1},
+	}
+}
+
+// Laplacian4 returns the Laplacian using the four direct neighbors.
+func Laplacian4() [][]float64 {
+	return [][]float64{
+		{0, 1, 0},
+		{1, -4, 1},
+		{0, 1, 0},
+	}
+}
+
+// Laplacian8 returns the Laplacian using all eight neighbors, including diagonals.
+func Laplacian8() [][]float64 {
+	return [][]float64{
+		{1, 1, 1},
+		{1, -8, 1},
+		{1, 1, 1},
+	}
+}
+
+// Sharpen returns a kernel enhancing edges while keeping the overall brightness.
+func Sharpen() [][]float64 {
+	return [][]float64{
+		{0, -1, 0},
+		{-1, 5, -1},
+		{0, -1, 0},
+	}
+}
+
+// Ridge returns a ridge detection kernel highlighting thin lines on uniform areas.
+func Ridge() [][]float64 {
+	return [][]float64{
+		{-1, -1, -1},
+		{-1, 8, -1},
+		{-1, -1, -1},
+	}
+}
+
+// Emboss returns a kernel giving images a relief look lit from the top left.
+func Emboss() [][]float64 {
+	return [][]float64{
+		{-2, -1, 0},
+		{-1, 1, 1},
+		{0, 1, 2},
+	}
+}
@@ -0,0 +1,82 @@
+package Netpbm
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// MappedImage is a raw PPM or PGM file opened with OpenMapped, whose pixels are read from
+// the memory of the file instead of being decoded.
+type MappedImage struct {
+	// Image is the *PPM or *PGM view of the file, valid until Close.
+	Image
+	unmap func() error
+}
+
+// OpenMapped opens a raw P5 or P6 file as a view mapping the file into memory, so even
+// multi-gigabyte images load immediately, their pages being read only when accessed. On
+// systems without memory mapping the file is read instead. The file itself is never
+// modified: the mapping is private, the pages of the image written to being copied by the
+// system on first write. Close releases the mapping.
+func OpenMapped(filename string) (*MappedImage, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	h, offset, err := readRawHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	var sampleSize int
+	switch h.MagicNumber {
+	case "P5":
+		sampleSize = 1
+	case "P6":
+		sampleSize = 3
+	default:
+		return nil, fmt.Errorf("memory mapping requires a raw PGM or PPM file, got %s", h.MagicNumber)
+	}
+	if h.Max > 255 {
+		return nil, fmt.Errorf("unsupported max value: %d", h.Max)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := int64(h.Width) * int64(h.Height) * int64(sampleSize)
+	if info.Size()-offset < size {
+		return nil, fmt.Errorf("unexpected end of file: expected %d bytes of pixel data, got %d", size, info.Size()-offset)
+	}
+	mapping, unmap, err := mapFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("error mapping file: %v", err)
+	}
+	samples := mapping[offset : offset+size]
+
+	m := &MappedImage{unmap: unmap}
+	if sampleSize == 1 {
+		m.Image = &PGM{data: samples, width: h.Width, height: h.Height, magicNumber: h.MagicNumber, max: h.Max}
+		return m, nil
+	}
+	var pixels []Pixel
+	if size > 0 {
+		// Pixel is three bytes without padding, laid out as the samples of P6 files.
+		pixels = unsafe.Slice((*Pixel)(unsafe.Pointer(&samples[0])), h.Width*h.Height)
+	}
+	m.Image = &PPM{data: pixels, width: h.Width, height: h.Height, magicNumber: h.MagicNumber, max: uint8(h.Max)}
+	return m, nil
+}
+
+// Close releases the memory of the mapped file. The image must not be used afterwards.
+func (m *MappedImage) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	unmap := m.unmap
+	m.unmap, m.Image = nil, nil
+	return unmap()
+}
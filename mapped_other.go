@@ -0,0 +1,18 @@
+//go:build !unix
+
+package Netpbm
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the first size bytes of file, memory mapping not being available, and
+// returns them along with a function doing nothing in place of releasing a mapping.
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package Netpbm
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the first size bytes of file into memory and returns them along with the
+// function releasing the mapping. The mapping is private: writes to it are copied on write
+// and never reach the file.
+func mapFile(file *os.File, size int64) ([]byte, func() error, error) {
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,76 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// MatchTemplateMap computes the zero-mean normalized cross-correlation between template
+// and every position of the PGM image. The score at [y][x] compares template with the
+// area of the image whose top-left corner is (x, y), from -1 (inverted) to 1 (identical up
+// to brightness and contrast); flat areas, whose correlation is undefined, score 0. The
+// map has (width-tw+1) x (height-th+1) entries. Rows are processed in parallel.
+func (pgm *PGM) MatchTemplateMap(template *PGM) ([][]float64, error) {
+	tw, th := template.width, template.height
+	if tw == 0 || th == 0 {
+		return nil, fmt.Errorf("empty template")
+	}
+	if tw > pgm.width || th > pgm.height {
+		return nil, fmt.Errorf("template size %dx%d exceeds image size %dx%d", tw, th, pgm.width, pgm.height)
+	}
+
+	n := float64(tw * th)
+	var tMean float64
+	for _, v := range template.data {
+		tMean += float64(v)
+	}
+	tMean /= n
+	var tNorm float64
+	for _, v := range template.data {
+		d := float64(v) - tMean
+		tNorm += d * d
+	}
+
+	scores := make([][]float64, pgm.height-th+1)
+	parallelRows(len(scores), func(y int) {
+		scores[y] = make([]float64, pgm.width-tw+1)
+		for x := range scores[y] {
+			var sum, sumSq, cross float64
+			for ty := 0; ty < th; ty++ {
+				row := pgm.row(y + ty)[x : x+tw]
+				for tx, v := range row {
+					f := float64(v)
+					sum += f
+					sumSq += f * f
+					cross += f * (float64(template.data[ty*template.width+tx]) - tMean)
+				}
+			}
+			// Sum of the squared deviations of the image area from its mean.
+			iNorm := sumSq - sum*sum/n
+			if iNorm <= 0 || tNorm == 0 {
+				continue
+			}
+			scores[y][x] = cross / math.Sqrt(iNorm*tNorm)
+		}
+	})
+	return scores, nil
+}
+
+// MatchTemplate locates template, such as a logo or a fiducial marker, in the PGM image and
+// returns the top-left corner of the best match with its score (see MatchTemplateMap).
+// The score is -1 when the template is empty or larger than the image.
+func (pgm *PGM) MatchTemplate(template *PGM) (Point, float64) {
+	scores, err := pgm.MatchTemplateMap(template)
+	if err != nil {
+		return Point{}, -1
+	}
+	best, bestScore := Point{}, math.Inf(-1)
+	for y, row := range scores {
+		for x, s := range row {
+			if s > bestScore {
+				best, bestScore = Point{x, y}, s
+			}
+		}
+	}
+	return best, bestScore
+}
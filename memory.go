@@ -0,0 +1,151 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"unsafe"
+)
+
+// Header holds the information found at the start of a Netpbm file.
+type Header struct {
+	MagicNumber   string
+	Width, Height int
+	Max           uint // Maximum sample value, 1 for PBM images
+}
+
+// ReadHeader reads only the header of a Netpbm file, so the size of the image can be
+// checked before decoding it.
+func ReadHeader(filename string) (Header, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Header{}, err
+	}
+	defer file.Close()
+
+	return DecodeHeader(file)
+}
+
+// DecodeHeader reads only the header of a Netpbm image from r.
+func DecodeHeader(r io.Reader) (Header, error) {
+	return readHeader(bufio.NewReader(r))
+}
+
+// readHeader parses a Netpbm header, skipping whitespace and comments.
+func readHeader(reader *bufio.Reader) (Header, error) {
+	var h Header
+	magicNumber, err := readToken(reader)
+	if err != nil {
+		return h, fmt.Errorf("error reading magic number: %v", err)
+	}
+	h.MagicNumber = magicNumber
+	switch magicNumber {
+	case "P1", "P2", "P3", "P4", "P5", "P6":
+	default:
+		return h, fmt.Errorf("invalid magic number: %s", magicNumber)
+	}
+
+	fields := []*int{&h.Width, &h.Height}
+	for _, field := range fields {
+		token, err := readToken(reader)
+		if err != nil {
+			return h, fmt.Errorf("error reading dimensions: %v", err)
+		}
+		*field, err = strconv.Atoi(token)
+		if err != nil || *field < 0 {
+			return h, fmt.Errorf("invalid dimensions: %s", token)
+		}
+	}
+
+	h.Max = 1
+	if magicNumber != "P1" && magicNumber != "P4" {
+		token, err := readToken(reader)
+		if err != nil {
+			return h, fmt.Errorf("error reading max value: %v", err)
+		}
+		maxValue, err := strconv.ParseUint(token, 10, 16)
+		if err != nil || maxValue == 0 {
+			return h, fmt.Errorf("invalid max value: %s", token)
+		}
+		h.Max = uint(maxValue)
+	}
+	return h, nil
+}
+
+// readToken reads the next whitespace separated token, skipping '#' comments.
+// The single whitespace byte following the token is consumed.
+func readToken(reader *bufio.Reader) (string, error) {
+	var token []byte
+	for {
+		c, err := reader.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(token) > 0 {
+				return string(token), nil
+			}
+			if err == io.EOF {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		switch {
+		case c == '#' && len(token) == 0:
+			if _, err := reader.ReadString('\n'); err != nil {
+				return "", err
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f':
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, c)
+		}
+	}
+}
+
+// pixelSize is the size of a pixel of a PPM image in memory.
+const pixelSize = int64(unsafe.Sizeof(Pixel{}))
+
+// matrixMemory returns the bytes used by the contiguous buffer of a width x height image
+// with elements of the given size.
+func matrixMemory(width, height int, elemSize int64) int64 {
+	return int64(width) * int64(height) * elemSize
+}
+
+// ApproxMemory returns an estimate, in bytes, of the memory held by the PBM image.
+func (pbm *PBM) ApproxMemory() int64 {
+	return int64(unsafe.Sizeof(*pbm)) + matrixMemory(pbm.width, pbm.height, 1)
+}
+
+// ApproxMemory returns an estimate, in bytes, of the memory held by the PGM image.
+func (pgm *PGM) ApproxMemory() int64 {
+	return int64(unsafe.Sizeof(*pgm)) + matrixMemory(pgm.width, pgm.height, 1)
+}
+
+// ApproxMemory returns an estimate, in bytes, of the memory held by the PPM image.
+func (ppm *PPM) ApproxMemory() int64 {
+	return int64(unsafe.Sizeof(*ppm)) + matrixMemory(ppm.width, ppm.height, pixelSize)
+}
+
+// ApproxMemory returns an estimate, in bytes, of the memory held by the packed image.
+func (p *PackedPBM) ApproxMemory() int64 {
+	return int64(unsafe.Sizeof(*p)) + int64(len(p.data))
+}
+
+// EstimateDecodedSize returns the approximate number of bytes an image described by the
+// header will occupy once decoded, matching ApproxMemory of the resulting image.
+func EstimateDecodedSize(h Header) (int64, error) {
+	if h.Width < 0 || h.Height < 0 {
+		return 0, fmt.Errorf("invalid dimensions: %dx%d", h.Width, h.Height)
+	}
+	switch h.MagicNumber {
+	case "P1", "P4":
+		return int64(unsafe.Sizeof(PBM{})) + matrixMemory(h.Width, h.Height, 1), nil
+	case "P2", "P5":
+		return int64(unsafe.Sizeof(PGM{})) + matrixMemory(h.Width, h.Height, 1), nil
+	case "P3", "P6":
+		return int64(unsafe.Sizeof(PPM{})) + matrixMemory(h.Width, h.Height, pixelSize), nil
+	}
+	return 0, fmt.Errorf("invalid magic number: %s", h.MagicNumber)
+}
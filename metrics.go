@@ -0,0 +1,201 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Metric selects the measure of similarity computed by Compare.
+type Metric int
+
+const (
+	// MSE is the mean squared error between the samples, 0 for identical images.
+	MSE Metric = iota
+	// PSNR is the peak signal-to-noise ratio in decibels, +Inf for identical images.
+	PSNR
+	// SSIM is the structural similarity index of Wang et al., computed on 11x11 Gaussian
+	// windows, 1 for identical images.
+	SSIM
+)
+
+// String returns the name of the metric.
+func (m Metric) String() string {
+	switch m {
+	case MSE:
+		return "MSE"
+	case PSNR:
+		return "PSNR"
+	case SSIM:
+		return "SSIM"
+	}
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// SSIM parameters: Gaussian window and stabilizing constants, relative to the maximum value.
+const (
+	ssimSigma  = 1.5
+	ssimRadius = 5
+	ssimK1     = 0.01
+	ssimK2     = 0.03
+)
+
+// plane is one channel of an image as floating-point samples.
+type plane [][]float64
+
+// compare computes a metric between the channels of two images of maximum value max.
+func compare(a, b []plane, max float64, metric Metric) (float64, error) {
+	switch metric {
+	case MSE, PSNR:
+		var sum float64
+		var n int
+		for c := range a {
+			for y, row := range a[c] {
+				for x, v := range row {
+					d := v - b[c][y][x]
+					sum += d * d
+				}
+				n += len(row)
+			}
+		}
+		mse := 0.0
+		if n > 0 {
+			mse = sum / float64(n)
+		}
+		if metric == MSE {
+			return mse, nil
+		}
+		if mse == 0 {
+			return math.Inf(1), nil
+		}
+		return 10 * math.Log10(max*max/mse), nil
+	case SSIM:
+		var total float64
+		for c := range a {
+			total += ssim(a[c], b[c], max)
+		}
+		return total / float64(len(a)), nil
+	}
+	return 0, fmt.Errorf("invalid metric: %d", metric)
+}
+
+// ssim returns the mean structural similarity between two planes.
+func ssim(a, b plane, max float64) float64 {
+	height := len(a)
+	if height == 0 || len(a[0]) == 0 {
+		return 1
+	}
+	width := len(a[0])
+	product := func(p, q plane) plane {
+		out := make(plane, height)
+		for y := range out {
+			out[y] = make([]float64, width)
+			for x := range out[y] {
+				out[y][x] = p[y][x] * q[y][x]
+			}
+		}
+		return out
+	}
+	muA, muB := gaussianPlane(a), gaussianPlane(b)
+	aa, bb, ab := gaussianPlane(product(a, a)), gaussianPlane(product(b, b)), gaussianPlane(product(a, b))
+	c1, c2 := (ssimK1*max)*(ssimK1*max), (ssimK2*max)*(ssimK2*max)
+
+	var total float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ma, mb := muA[y][x], muB[y][x]
+			varA, varB, cov := aa[y][x]-ma*ma, bb[y][x]-mb*mb, ab[y][x]-ma*mb
+			total += (2*ma*mb + c1) * (2*cov + c2) / ((ma*ma + mb*mb + c1) * (varA + varB + c2))
+		}
+	}
+	return total / float64(width*height)
+}
+
+// gaussianPlane blurs a plane with the separable SSIM window, renormalizing the weights at
+// the borders.
+func gaussianPlane(p plane) plane {
+	var weights [2*ssimRadius + 1]float64
+	for i := range weights {
+		d := float64(i - ssimRadius)
+		weights[i] = math.Exp(-d * d / (2 * ssimSigma * ssimSigma))
+	}
+	pass := func(src plane, horizontal bool) plane {
+		height, width := len(src), len(src[0])
+		out := make(plane, height)
+		parallelRows(height, func(y int) {
+			out[y] = make([]float64, width)
+			for x := range out[y] {
+				var sum, weight float64
+				for i, w := range weights {
+					sx, sy := x, y
+					if horizontal {
+						sx += i - ssimRadius
+					} else {
+						sy += i - ssimRadius
+					}
+					if sx < 0 || sy < 0 || sx >= width || sy >= height {
+						continue
+					}
+					sum += w * src[sy][sx]
+					weight += w
+				}
+				out[y][x] = sum / weight
+			}
+		})
+		return out
+	}
+	return pass(pass(p, true), false)
+}
+
+// planes returns the samples of the PGM image.
+func (pgm *PGM) planes() []plane {
+	p := make(plane, pgm.height)
+	for y := 0; y < pgm.height; y++ {
+		row := pgm.row(y)
+		p[y] = make([]float64, len(row))
+		for x, v := range row {
+			p[y][x] = float64(v)
+		}
+	}
+	return []plane{p}
+}
+
+// Compare measures the similarity of the PGM image with other, of the same size and
+// maximum value, with the given metric.
+func (pgm *PGM) Compare(other *PGM, metric Metric) (float64, error) {
+	if pgm.width != other.width || pgm.height != other.height {
+		return 0, fmt.Errorf("image sizes differ: %dx%d and %dx%d", pgm.width, pgm.height, other.width, other.height)
+	}
+	if pgm.max != other.max {
+		return 0, fmt.Errorf("maximum values differ: %d and %d", pgm.max, other.max)
+	}
+	return compare(pgm.planes(), other.planes(), float64(pgm.max), metric)
+}
+
+// planes returns the red, green and blue samples of the PPM image.
+func (ppm *PPM) planes() []plane {
+	planes := make([]plane, 3)
+	for c := range planes {
+		planes[c] = make(plane, ppm.height)
+		for y := 0; y < ppm.height; y++ {
+			row := ppm.row(y)
+			planes[c][y] = make([]float64, len(row))
+			for x, p := range row {
+				planes[c][y][x] = float64(Channel(c).get(p))
+			}
+		}
+	}
+	return planes
+}
+
+// Compare measures the similarity of the PPM image with other, of the same size and
+// maximum value, with the given metric. MSE and PSNR pool the errors of all channels; SSIM
+// is the mean of the indexes of the channels.
+func (ppm *PPM) Compare(other *PPM, metric Metric) (float64, error) {
+	if err := ppm.checkSameSize(other); err != nil {
+		return 0, err
+	}
+	if ppm.max != other.max {
+		return 0, fmt.Errorf("maximum values differ: %d and %d", ppm.max, other.max)
+	}
+	return compare(ppm.planes(), other.planes(), float64(ppm.max), metric)
+}
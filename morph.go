@@ -0,0 +1,384 @@
+package Netpbm
+
+import "fmt"
+
+// StructuringElement is the neighborhood used by the morphological operations, as a set of
+// offsets from the pixel being processed.
+type StructuringElement struct {
+	offsets []Point
+}
+
+// NewStructuringElement builds a structuring element from a mask with odd dimensions,
+// centered on the middle of the mask: true values are part of the neighborhood.
+func NewStructuringElement(mask [][]bool) (StructuringElement, error) {
+	if len(mask) == 0 || len(mask)%2 == 0 {
+		return StructuringElement{}, fmt.Errorf("invalid structuring element height: %d", len(mask))
+	}
+	width := len(mask[0])
+	if width%2 == 0 {
+		return StructuringElement{}, fmt.Errorf("invalid structuring element width: %d", width)
+	}
+	var se StructuringElement
+	for y, row := range mask {
+		if len(row) != width {
+			return StructuringElement{}, fmt.Errorf("structuring element row %d has %d values, want %d", y, len(row), width)
+		}
+		for x, in := range row {
+			if in {
+				se.offsets = append(se.offsets, Point{x - width/2, y - len(mask)/2})
+			}
+		}
+	}
+	return se, nil
+}
+
+// RectElement returns a width x height rectangular structuring element. Even sizes are
+// rounded up so that the element has a center.
+func RectElement(width, height int) StructuringElement {
+	rx, ry := elementRadius(width), elementRadius(height)
+	var se StructuringElement
+	for dy := -ry; dy <= ry; dy++ {
+		for dx := -rx; dx <= rx; dx++ {
+			se.offsets = append(se.offsets, Point{dx, dy})
+		}
+	}
+	return se
+}
+
+// CrossElement returns a plus-shaped structuring element with arms of the given radius.
+func CrossElement(radius int) StructuringElement {
+	se := StructuringElement{offsets: []Point{{0, 0}}}
+	for d := 1; d <= radius; d++ {
+		se.offsets = append(se.offsets, Point{-d, 0}, Point{d, 0}, Point{0, -d}, Point{0, d})
+	}
+	return se
+}
+
+// DiskElement returns a disk-shaped structuring element of the given radius.
+func DiskElement(radius int) StructuringElement {
+	var se StructuringElement
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				se.offsets = append(se.offsets, Point{dx, dy})
+			}
+		}
+	}
+	return se
+}
+
+// elementRadius returns the radius of a structuring element of the given size.
+func elementRadius(size int) int {
+	if size < 1 {
+		return 0
+	}
+	return size / 2
+}
+
+// checkElement ensures a structuring element is not empty.
+func checkElement(se StructuringElement) error {
+	if len(se.offsets) == 0 {
+		return fmt.Errorf("empty structuring element")
+	}
+	return nil
+}
+
+// Erode shrinks the black shapes of the PBM image: a pixel stays black only if every
+// pixel of the structuring element around it is black. Pixels outside the image are
+// ignored.
+func (pbm *PBM) Erode(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("Erode", "elementSize", len(se.offsets))
+	pbm.morph(se, true)
+	return nil
+}
+
+// Dilate grows the black shapes of the PBM image: a pixel becomes black if any pixel of
+// the structuring element around it is black.
+func (pbm *PBM) Dilate(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("Dilate", "elementSize", len(se.offsets))
+	pbm.morph(se, false)
+	return nil
+}
+
+// morph erodes or dilates the PBM image.
+func (pbm *PBM) morph(se StructuringElement, erode bool) {
+	result := make([]bool, pbm.width*pbm.height)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			v := erode
+			for _, o := range se.offsets {
+				nx, ny := x+o.X, y+o.Y
+				if nx < 0 || ny < 0 || nx >= pbm.width || ny >= pbm.height {
+					continue
+				}
+				if pbm.data[ny*pbm.width+nx] != erode {
+					v = !erode
+					break
+				}
+			}
+			result[y*pbm.width+x] = v
+		}
+	}
+	pbm.data = result
+}
+
+// Erode replaces every pixel of the PGM image by the minimum of the pixels of the
+// structuring element around it, darkening the image and removing small bright details.
+// Pixels outside the image are ignored.
+func (pgm *PGM) Erode(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("Erode", "elementSize", len(se.offsets))
+	pgm.morph(se, true)
+	return nil
+}
+
+// Dilate replaces every pixel of the PGM image by the maximum of the pixels of the
+// structuring element around it, brightening the image and removing small dark details.
+func (pgm *PGM) Dilate(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("Dilate", "elementSize", len(se.offsets))
+	pgm.morph(se, false)
+	return nil
+}
+
+// morph erodes (minimum) or dilates (maximum) the PGM image. Rows are processed in parallel.
+func (pgm *PGM) morph(se StructuringElement, erode bool) {
+	result := make([]uint8, pgm.width*pgm.height)
+	parallelRows(pgm.height, func(y int) {
+		for x := 0; x < pgm.width; x++ {
+			v, found := pgm.data[y*pgm.width+x], false
+			for _, o := range se.offsets {
+				nx, ny := x+o.X, y+o.Y
+				if nx < 0 || ny < 0 || nx >= pgm.width || ny >= pgm.height {
+					continue
+				}
+				n := pgm.data[ny*pgm.width+nx]
+				if !found || (erode && n < v) || (!erode && n > v) {
+					v, found = n, true
+				}
+			}
+			result[y*pgm.width+x] = v
+		}
+	})
+	pgm.data = result
+}
+
+// Open erodes then dilates the PBM image, removing black specks smaller than the
+// structuring element while keeping the size of larger shapes.
+func (pbm *PBM) Open(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("Open", "elementSize", len(se.offsets))
+	pbm.morph(se, true)
+	pbm.morph(se, false)
+	return nil
+}
+
+// Close dilates then erodes the PBM image, filling white holes and gaps smaller than the
+// structuring element while keeping the size of larger shapes.
+func (pbm *PBM) Close(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("Close", "elementSize", len(se.offsets))
+	pbm.morph(se, false)
+	pbm.morph(se, true)
+	return nil
+}
+
+// TopHat keeps only the black details of the PBM image removed by Open, that is those
+// smaller than the structuring element.
+func (pbm *PBM) TopHat(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("TopHat", "elementSize", len(se.offsets))
+	original := pbm.data
+	pbm.morph(se, true)
+	pbm.morph(se, false)
+	pbm.combine(original, func(orig, opened bool) bool { return orig && !opened })
+	return nil
+}
+
+// BlackHat keeps only the white holes of the PBM image filled by Close, that is those
+// smaller than the structuring element, as black pixels.
+func (pbm *PBM) BlackHat(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("BlackHat", "elementSize", len(se.offsets))
+	original := pbm.data
+	pbm.morph(se, false)
+	pbm.morph(se, true)
+	pbm.combine(original, func(orig, closed bool) bool { return closed && !orig })
+	return nil
+}
+
+// MorphGradient keeps the pixels of the PBM image that differ between its dilation and its
+// erosion, giving the outlines of the shapes.
+func (pbm *PBM) MorphGradient(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pbm.record("MorphGradient", "elementSize", len(se.offsets))
+	original := pbm.data
+	pbm.morph(se, true)
+	eroded := pbm.data
+	pbm.data = original
+	pbm.morph(se, false)
+	pbm.combine(eroded, func(eroded, dilated bool) bool { return dilated && !eroded })
+	return nil
+}
+
+// combine replaces every pixel of the PBM image by fn applied to the pixel of other at
+// the same position and to its current value.
+func (pbm *PBM) combine(other []bool, fn func(a, b bool) bool) {
+	for i, v := range pbm.data {
+		pbm.data[i] = fn(other[i], v)
+	}
+}
+
+// Open erodes then dilates the PGM image, removing bright details smaller than the
+// structuring element.
+func (pgm *PGM) Open(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("Open", "elementSize", len(se.offsets))
+	pgm.morph(se, true)
+	pgm.morph(se, false)
+	return nil
+}
+
+// Close dilates then erodes the PGM image, removing dark details smaller than the
+// structuring element.
+func (pgm *PGM) Close(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("Close", "elementSize", len(se.offsets))
+	pgm.morph(se, false)
+	pgm.morph(se, true)
+	return nil
+}
+
+// TopHat subtracts the opening from the PGM image, extracting the bright details smaller
+// than the structuring element, for example to correct uneven lighting.
+func (pgm *PGM) TopHat(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("TopHat", "elementSize", len(se.offsets))
+	original := pgm.data
+	pgm.morph(se, true)
+	pgm.morph(se, false)
+	pgm.combine(original, func(orig, opened uint8) uint8 { return subSaturated(orig, opened) })
+	return nil
+}
+
+// BlackHat subtracts the PGM image from its closing, extracting the dark details smaller
+// than the structuring element, such as text on a light background.
+func (pgm *PGM) BlackHat(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("BlackHat", "elementSize", len(se.offsets))
+	original := pgm.data
+	pgm.morph(se, false)
+	pgm.morph(se, true)
+	pgm.combine(original, func(orig, closed uint8) uint8 { return subSaturated(closed, orig) })
+	return nil
+}
+
+// MorphGradient subtracts the erosion of the PGM image from its dilation, highlighting
+// the edges.
+func (pgm *PGM) MorphGradient(se StructuringElement) error {
+	if err := checkElement(se); err != nil {
+		return err
+	}
+	pgm.record("MorphGradient", "elementSize", len(se.offsets))
+	original := pgm.data
+	pgm.morph(se, true)
+	eroded := pgm.data
+	pgm.data = original
+	pgm.morph(se, false)
+	pgm.combine(eroded, func(eroded, dilated uint8) uint8 { return subSaturated(dilated, eroded) })
+	return nil
+}
+
+// combine replaces every pixel of the PGM image by fn applied to the pixel of other at
+// the same position and to its current value.
+func (pgm *PGM) combine(other []uint8, fn func(a, b uint8) uint8) {
+	for i, v := range pgm.data {
+		pgm.data[i] = fn(other[i], v)
+	}
+}
+
+// subSaturated returns a-b, or 0 if b is greater than a.
+func subSaturated(a, b uint8) uint8 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+// Skeletonize thins the black shapes of the PBM image down to one-pixel-wide skeletons
+// with the Zhang–Suen algorithm, preserving their connectivity.
+func (pbm *PBM) Skeletonize() {
+	pbm.record("Skeletonize")
+	at := func(x, y int) int {
+		if x < 0 || y < 0 || x >= pbm.width || y >= pbm.height || !pbm.data[y*pbm.width+x] {
+			return 0
+		}
+		return 1
+	}
+	for changed := true; changed; {
+		changed = false
+		for pass := 0; pass < 2; pass++ {
+			var removed []Point
+			for y := 0; y < pbm.height; y++ {
+				for x := 0; x < pbm.width; x++ {
+					if !pbm.data[y*pbm.width+x] {
+						continue
+					}
+					// Neighbors clockwise from the top: P2 to P9.
+					n := [8]int{at(x, y-1), at(x+1, y-1), at(x+1, y), at(x+1, y+1), at(x, y+1), at(x-1, y+1), at(x-1, y), at(x-1, y-1)}
+					var count, transitions int
+					for i, v := range n {
+						count += v
+						if v == 0 && n[(i+1)%8] == 1 {
+							transitions++
+						}
+					}
+					if count < 2 || count > 6 || transitions != 1 {
+						continue
+					}
+					if pass == 0 && (n[0]*n[2]*n[4] != 0 || n[2]*n[4]*n[6] != 0) {
+						continue
+					}
+					if pass == 1 && (n[0]*n[2]*n[6] != 0 || n[0]*n[4]*n[6] != 0) {
+						continue
+					}
+					removed = append(removed, Point{x, y})
+				}
+			}
+			for _, p := range removed {
+				pbm.data[p.Y*pbm.width+p.X] = false
+			}
+			if len(removed) > 0 {
+				changed = true
+			}
+		}
+	}
+}
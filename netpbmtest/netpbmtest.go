@@ -0,0 +1,202 @@
+// Package netpbmtest provides golden-image helpers for testing code that produces
+// Netpbm images.
+//
+// A golden file holds the expected output of a test. AssertEqual compares an image
+// against it and, on mismatch, writes the actual image and a diff image next to the
+// golden file so the failure can be inspected. Golden files are (re)generated by
+// running the tests with -netpbmtest.update or with NETPBM_UPDATE_GOLDEN=1.
+package netpbmtest
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	Netpbm "nom_du_module"
+)
+
+var update = flag.Bool("netpbmtest.update", false, "rewrite golden Netpbm files instead of comparing")
+
+// updating reports whether golden files should be rewritten.
+func updating() bool {
+	return *update || os.Getenv("NETPBM_UPDATE_GOLDEN") == "1"
+}
+
+// Image is the part of the image API shared by PBM, PGM and PPM.
+type Image interface {
+	Size() (int, int)
+	Save(filename string) error
+}
+
+// samples returns the size of img and a function reading its samples as RGB triples.
+func samples(img Image) (int, int, func(x, y int) [3]int, error) {
+	w, h := img.Size()
+	switch img := img.(type) {
+	case *Netpbm.PBM:
+		return w, h, func(x, y int) [3]int {
+			if img.At(x, y) {
+				return [3]int{1, 1, 1}
+			}
+			return [3]int{}
+		}, nil
+	case *Netpbm.PGM:
+		return w, h, func(x, y int) [3]int {
+			v := int(img.At(x, y))
+			return [3]int{v, v, v}
+		}, nil
+	case *Netpbm.PPM:
+		return w, h, func(x, y int) [3]int {
+			p := img.At(x, y)
+			return [3]int{int(p.R), int(p.G), int(p.B)}
+		}, nil
+	}
+	return 0, 0, nil, fmt.Errorf("unsupported image type %T", img)
+}
+
+// readGolden reads a golden file of the same type as got.
+func readGolden(got Image, filename string) (Image, error) {
+	switch got.(type) {
+	case *Netpbm.PBM:
+		return Netpbm.ReadPBM(filename)
+	case *Netpbm.PGM:
+		return Netpbm.ReadPGM(filename)
+	case *Netpbm.PPM:
+		return Netpbm.ReadPPM(filename)
+	}
+	return nil, fmt.Errorf("unsupported image type %T", got)
+}
+
+// AssertEqual compares got, a *Netpbm.PBM, *Netpbm.PGM or *Netpbm.PPM, with the golden
+// image stored in wantFile. Samples may differ by at most tolerance. On mismatch the test
+// fails and the actual image and a diff image highlighting differing pixels in red are
+// written next to the golden file.
+func AssertEqual(t TB, got Image, wantFile string, tolerance int) {
+	t.Helper()
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(wantFile), 0o755); err != nil {
+			t.Fatalf("netpbmtest: %v", err)
+		}
+		if err := got.Save(wantFile); err != nil {
+			t.Fatalf("netpbmtest: error updating golden file %s: %v", wantFile, err)
+		}
+		return
+	}
+
+	want, err := readGolden(got, wantFile)
+	if err != nil {
+		t.Fatalf("netpbmtest: error reading golden file %s: %v (run with -netpbmtest.update to create it)", wantFile, err)
+	}
+
+	gw, gh, gotAt, err := samples(got)
+	if err != nil {
+		t.Fatalf("netpbmtest: %v", err)
+	}
+	ww, wh, wantAt, _ := samples(want)
+	if gw != ww || gh != wh {
+		t.Errorf("netpbmtest: size %dx%d, want %dx%d (%s)", gw, gh, ww, wh, wantFile)
+		writeArtifacts(t, got, wantFile, nil)
+		return
+	}
+
+	diff := make([][]bool, gh)
+	mismatches, worst := 0, 0
+	firstX, firstY := -1, -1
+	for y := 0; y < gh; y++ {
+		diff[y] = make([]bool, gw)
+		for x := 0; x < gw; x++ {
+			g, w := gotAt(x, y), wantAt(x, y)
+			for c := 0; c < 3; c++ {
+				d := g[c] - w[c]
+				if d < 0 {
+					d = -d
+				}
+				if d > worst {
+					worst = d
+				}
+				if d > tolerance {
+					diff[y][x] = true
+				}
+			}
+			if diff[y][x] {
+				if mismatches == 0 {
+					firstX, firstY = x, y
+				}
+				mismatches++
+			}
+		}
+	}
+	if mismatches == 0 {
+		return
+	}
+
+	t.Errorf("netpbmtest: %d pixels differ from %s by more than %d (max difference %d, first at %d,%d)",
+		mismatches, wantFile, tolerance, worst, firstX, firstY)
+	writeArtifacts(t, got, wantFile, diff)
+}
+
+// TB is the subset of testing.TB used by AssertEqual.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// artifactPath derives an artifact file name from the golden file name.
+func artifactPath(wantFile, suffix string) string {
+	ext := filepath.Ext(wantFile)
+	return strings.TrimSuffix(wantFile, ext) + suffix + ext
+}
+
+// writeArtifacts saves the actual image and, when available, the diff image next to the golden file.
+func writeArtifacts(t TB, got Image, wantFile string, diff [][]bool) {
+	t.Helper()
+
+	gotFile := artifactPath(wantFile, ".got")
+	if err := got.Save(gotFile); err != nil {
+		t.Logf("netpbmtest: error writing %s: %v", gotFile, err)
+	} else {
+		t.Logf("netpbmtest: actual image written to %s", gotFile)
+	}
+	if diff == nil {
+		return
+	}
+
+	diffFile := strings.TrimSuffix(wantFile, filepath.Ext(wantFile)) + ".diff.ppm"
+	if err := writeDiff(diffFile, diff); err != nil {
+		t.Logf("netpbmtest: error writing %s: %v", diffFile, err)
+	} else {
+		t.Logf("netpbmtest: diff image written to %s", diffFile)
+	}
+}
+
+// writeDiff writes a P3 image showing differing pixels in red on a black background.
+func writeDiff(filename string, diff [][]bool) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	width := 0
+	if len(diff) > 0 {
+		width = len(diff[0])
+	}
+	fmt.Fprintf(writer, "P3\n%d %d\n255\n", width, len(diff))
+	for _, row := range diff {
+		for _, d := range row {
+			if d {
+				fmt.Fprint(writer, "255 0 0 ")
+			} else {
+				fmt.Fprint(writer, "0 0 0 ")
+			}
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,324 @@
+package Netpbm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NPYType selects the element type written by WriteNPY.
+//
+// NPY arrays hold no maximum value, so samples are always scaled to the full range of the
+// element type: WriteNPY maps the maximum value of the image to 255 or 65535, and
+// ReadPGMNPY and ReadPPMNPY map 255 or 65535 back to the maximum value 255 of the images
+// they return. Images of maximum value 255 thus come back unchanged from either type.
+type NPYType int
+
+const (
+	// NPYUint8 stores samples as unsigned 8-bit integers ('|u1'), scaled to 0-255.
+	NPYUint8 NPYType = iota
+	// NPYUint16 stores samples as little-endian unsigned 16-bit integers ('<u2'), scaled
+	// to 0-65535.
+	NPYUint16
+)
+
+// npyMagic starts every NumPy .npy file.
+const npyMagic = "\x93NUMPY"
+
+const (
+	// maxNPYHeader bounds the length of the header dictionary read by readNPY.
+	maxNPYHeader = 1 << 20
+	// maxNPYValues bounds the number of elements of the arrays read by readNPY, so that the
+	// byte size of 16-bit arrays fits in an int on 32-bit platforms.
+	maxNPYValues = 1<<30 - 1
+	// npyChunkSize is the number of bytes of data readNPY reads at once.
+	npyChunkSize = 64 << 10
+)
+
+// writeNPYHeader writes a version 1.0 .npy header for a C-ordered array.
+func writeNPYHeader(w io.Writer, dtype NPYType, shape []int) error {
+	descr := "|u1"
+	switch dtype {
+	case NPYUint8:
+	case NPYUint16:
+		descr = "<u2"
+	default:
+		return fmt.Errorf("invalid NPY type: %d", dtype)
+	}
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = strconv.Itoa(d)
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, strings.Join(dims, ", "))
+
+	// Pad with spaces so the data starts on a 64-byte boundary.
+	total := len(npyMagic) + 4 + len(header) + 1
+	header += strings.Repeat(" ", (64-total%64)%64) + "\n"
+
+	prefix := []byte(npyMagic + "\x01\x00")
+	prefix = binary.LittleEndian.AppendUint16(prefix, uint16(len(header)))
+	if _, err := w.Write(prefix); err != nil {
+		return fmt.Errorf("error writing NPY header: %v", err)
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("error writing NPY header: %v", err)
+	}
+	return nil
+}
+
+// writeNPYSamples writes samples of maximum value max with the given element type, scaled
+// to its full range, in a single write.
+func writeNPYSamples(w *bufio.Writer, dtype NPYType, max uint, samples ...uint8) error {
+	full := uint(255)
+	if dtype == NPYUint16 {
+		full = 65535
+	}
+	scale := func(s uint8) uint {
+		if max == 0 {
+			return 0
+		}
+		return (min(uint(s), max)*full + max/2) / max
+	}
+	data := make([]byte, 0, 2*len(samples))
+	for _, s := range samples {
+		if dtype == NPYUint16 {
+			v := scale(s)
+			data = append(data, byte(v), byte(v>>8))
+		} else {
+			data = append(data, byte(scale(s)))
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("error writing NPY data: %v", err)
+	}
+	return nil
+}
+
+// WriteNPY writes the PGM image as a NumPy array of shape (height, width), with samples
+// scaled as described for NPYType.
+func (pgm *PGM) WriteNPY(w io.Writer, dtype NPYType) error {
+	writer := bufio.NewWriter(w)
+	if err := writeNPYHeader(writer, dtype, []int{pgm.height, pgm.width}); err != nil {
+		return err
+	}
+	for y := 0; y < pgm.height; y++ {
+		if err := writeNPYSamples(writer, dtype, pgm.max, pgm.row(y)...); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// WriteNPY writes the PPM image as a NumPy array of shape (height, width, 3), with samples
+// scaled as described for NPYType.
+func (ppm *PPM) WriteNPY(w io.Writer, dtype NPYType) error {
+	writer := bufio.NewWriter(w)
+	if err := writeNPYHeader(writer, dtype, []int{ppm.height, ppm.width, 3}); err != nil {
+		return err
+	}
+	samples := make([]uint8, 0, 3*ppm.width)
+	for y := 0; y < ppm.height; y++ {
+		samples = samples[:0]
+		for _, p := range ppm.row(y) {
+			samples = append(samples, p.R, p.G, p.B)
+		}
+		if err := writeNPYSamples(writer, dtype, uint(ppm.max), samples...); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// npyArray is a decoded .npy array of unsigned integers.
+type npyArray struct {
+	shape  []int
+	values []uint16
+	wide   bool
+}
+
+// readNPY decodes a C-ordered .npy array of unsigned 8 or 16-bit integers.
+func readNPY(r io.Reader) (*npyArray, error) {
+	reader := bufio.NewReader(r)
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return nil, fmt.Errorf("error reading NPY magic: %v", err)
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("invalid NPY magic")
+	}
+
+	var headerLen int
+	switch prefix[len(npyMagic)] {
+	case 1:
+		var n uint16
+		if err := binary.Read(reader, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("error reading NPY header: %v", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(reader, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("error reading NPY header: %v", err)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported NPY version: %d", prefix[len(npyMagic)])
+	}
+	if headerLen > maxNPYHeader {
+		return nil, fmt.Errorf("NPY header too large: %d bytes", headerLen)
+	}
+	headerBytes := make([]byte, headerLen)
+	if _, err := io.ReadFull(reader, headerBytes); err != nil {
+		return nil, fmt.Errorf("error reading NPY header: %v", err)
+	}
+	header := string(headerBytes)
+
+	descr, err := npyHeaderValue(header, "descr")
+	if err != nil {
+		return nil, err
+	}
+	array := &npyArray{}
+	var order binary.ByteOrder = binary.LittleEndian
+	switch strings.Trim(descr, "'\"") {
+	case "|u1", "u1", "<u1", ">u1":
+	case "<u2":
+		array.wide = true
+	case ">u2":
+		array.wide = true
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("unsupported NPY element type: %s", descr)
+	}
+
+	fortran, err := npyHeaderValue(header, "fortran_order")
+	if err != nil {
+		return nil, err
+	}
+	if fortran != "False" {
+		return nil, fmt.Errorf("unsupported NPY Fortran order")
+	}
+
+	shape, err := npyHeaderValue(header, "shape")
+	if err != nil {
+		return nil, err
+	}
+	count := 1
+	for _, dim := range strings.Split(strings.Trim(shape, "()"), ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid NPY shape: %s", shape)
+		}
+		array.shape = append(array.shape, n)
+		if n > 0 && count > maxNPYValues/n {
+			return nil, fmt.Errorf("NPY shape too large: %s", shape)
+		}
+		count *= n
+	}
+
+	size := 1
+	if array.wide {
+		size = 2
+	}
+	// The data is read by chunks so that a truncated file claiming a huge shape fails
+	// before its whole size is allocated.
+	raw := make([]byte, min(count*size, npyChunkSize))
+	array.values = make([]uint16, 0, min(count, npyChunkSize))
+	for remaining := count * size; remaining > 0; remaining -= len(raw) {
+		raw = raw[:min(remaining, len(raw))]
+		if _, err := io.ReadFull(reader, raw); err != nil {
+			return nil, fmt.Errorf("error reading NPY data: %v", err)
+		}
+		if array.wide {
+			for i := 0; i < len(raw); i += 2 {
+				array.values = append(array.values, order.Uint16(raw[i:]))
+			}
+		} else {
+			for _, b := range raw {
+				array.values = append(array.values, uint16(b))
+			}
+		}
+	}
+	return array, nil
+}
+
+// npyHeaderValue extracts the textual value of a key from a .npy header dictionary.
+func npyHeaderValue(header, key string) (string, error) {
+	i := strings.Index(header, "'"+key+"'")
+	if i < 0 {
+		return "", fmt.Errorf("missing NPY header key: %s", key)
+	}
+	rest := strings.TrimSpace(header[i+len(key)+2:])
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, ":"))
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", fmt.Errorf("invalid NPY header value: %s", key)
+		}
+		return rest[:end+1], nil
+	}
+	end := strings.IndexAny(rest, ",}")
+	if end < 0 {
+		return "", fmt.Errorf("invalid NPY header value: %s", key)
+	}
+	return strings.TrimSpace(rest[:end]), nil
+}
+
+// samples8 returns the array values as 8-bit samples, scaling 16-bit values from the full
+// 16-bit range.
+func (a *npyArray) samples8() []uint8 {
+	samples := make([]uint8, len(a.values))
+	for i, v := range a.values {
+		if a.wide {
+			samples[i] = uint8((uint32(v)*255 + 32767) / 65535)
+		} else {
+			samples[i] = uint8(v)
+		}
+	}
+	return samples
+}
+
+// ReadPGMNPY reads a PGM image of maximum value 255 from a NumPy array of shape (height,
+// width) holding unsigned 8 or 16-bit integers, scaled as described for NPYType.
+func ReadPGMNPY(r io.Reader) (*PGM, error) {
+	array, err := readNPY(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(array.shape) != 2 {
+		return nil, fmt.Errorf("invalid NPY shape for PGM: %v", array.shape)
+	}
+	samples := array.samples8()
+	pgm := newPGM(array.shape[1], array.shape[0], "P2", 255)
+	for y := 0; y < pgm.height; y++ {
+		copy(pgm.row(y), samples[y*pgm.width:(y+1)*pgm.width])
+	}
+	return pgm, nil
+}
+
+// ReadPPMNPY reads a PPM image of maximum value 255 from a NumPy array of shape (height,
+// width, 3) holding unsigned 8 or 16-bit integers, scaled as described for NPYType.
+func ReadPPMNPY(r io.Reader) (*PPM, error) {
+	array, err := readNPY(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(array.shape) != 3 || array.shape[2] != 3 {
+		return nil, fmt.Errorf("invalid NPY shape for PPM: %v", array.shape)
+	}
+	samples := array.samples8()
+	ppm := newPPM(array.shape[1], array.shape[0], "P3", 255)
+	for y := 0; y < ppm.height; y++ {
+		for x := 0; x < ppm.width; x++ {
+			i := 3 * (y*ppm.width + x)
+			ppm.data[y*ppm.width+x] = Pixel{samples[i], samples[i+1], samples[i+2]}
+		}
+	}
+	return ppm, nil
+}
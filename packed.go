@@ -0,0 +1,274 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PackedPBM represents a PBM image kept in the packed P4 layout: eight pixels per byte,
+// most significant bit first, each row padded to a whole byte. It allows lossless
+// flips and rotations of very large bitmaps without expanding them to one bool per pixel.
+type PackedPBM struct {
+	data          []byte
+	width, height int
+	stride        int
+}
+
+// reversedBits maps every byte to the byte with its bits in reverse order.
+var reversedBits = func() (table [256]byte) {
+	for i := range table {
+		var r byte
+		for b := 0; b < 8; b++ {
+			if i&(1<<b) != 0 {
+				r |= 0x80 >> b
+			}
+		}
+		table[i] = r
+	}
+	return table
+}()
+
+// newPackedPBM allocates a blank packed bitmap.
+func newPackedPBM(width, height int) *PackedPBM {
+	stride := (width + 7) / 8
+	return &PackedPBM{
+		data:   make([]byte, stride*height),
+		width:  width,
+		height: height,
+		stride: stride,
+	}
+}
+
+// ReadPackedPBM reads a binary (P4) PBM image from a file without unpacking its pixels.
+func ReadPackedPBM(filename string) (*PackedPBM, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return DecodePackedPBM(file)
+}
+
+// DecodePackedPBM reads a binary (P4) PBM image from r without unpacking its pixels.
+func DecodePackedPBM(r io.Reader) (*PackedPBM, error) {
+	reader := bufio.NewReader(r)
+
+	// Read the magic number
+	magicNumber, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading magic number: %v", err)
+	}
+	magicNumber = strings.TrimSpace(magicNumber)
+	if magicNumber != "P4" {
+		return nil, fmt.Errorf("invalid magic number for packed PBM: %s", magicNumber)
+	}
+
+	// Read dimensions
+	dimensions, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading dimensions: %v", err)
+	}
+	var width, height int
+	_, err = fmt.Sscanf(strings.TrimSpace(dimensions), "%d %d", &width, &height)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dimensions: %v", err)
+	}
+
+	packed := newPackedPBM(width, height)
+	if _, err := io.ReadFull(reader, packed.data); err != nil {
+		return nil, fmt.Errorf("error reading pixel data: %v", err)
+	}
+	packed.clearPadding()
+	return packed, nil
+}
+
+// Save saves the packed image to a file in the P4 format.
+func (p *PackedPBM) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return p.Encode(file)
+}
+
+// Encode writes the packed image to w in the P4 format.
+func (p *PackedPBM) Encode(w io.Writer) error {
+	writer := getWriter(w)
+	defer putWriter(writer)
+	if _, err := fmt.Fprintf(writer, "P4\n%d %d\n", p.width, p.height); err != nil {
+		return fmt.Errorf("error writing header: %v", err)
+	}
+	if _, err := writer.Write(p.data); err != nil {
+		return fmt.Errorf("error writing binary data: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error flushing write buffer: %v", err)
+	}
+	return nil
+}
+
+// Size returns the width and height of the packed image.
+func (p *PackedPBM) Size() (int, int) {
+	return p.width, p.height
+}
+
+// At returns the pixel value at position (x, y).
+func (p *PackedPBM) At(x, y int) bool {
+	return p.data[y*p.stride+x/8]&(0x80>>(x%8)) != 0
+}
+
+// Set sets the pixel value at position (x, y).
+func (p *PackedPBM) Set(x, y int, value bool) {
+	if value {
+		p.data[y*p.stride+x/8] |= 0x80 >> (x % 8)
+	} else {
+		p.data[y*p.stride+x/8] &^= 0x80 >> (x % 8)
+	}
+}
+
+// row returns the packed bytes of row y.
+func (p *PackedPBM) row(y int) []byte {
+	return p.data[y*p.stride : (y+1)*p.stride]
+}
+
+// packBits packs a row of pixels into bytes, most significant bit first, as in P4 files.
+// The padding bits of the last byte are cleared.
+func packBits(dst []byte, row []bool) {
+	for i := range dst {
+		dst[i] = 0
+	}
+	for x, v := range row {
+		if v {
+			dst[x/8] |= 0x80 >> (x % 8)
+		}
+	}
+}
+
+// unpackBits unpacks the bits of a P4 row, most significant bit first, into pixels.
+func unpackBits(row []bool, src []byte) {
+	for x := range row {
+		row[x] = src[x/8]&(0x80>>(x%8)) != 0
+	}
+}
+
+// clearPadding zeroes the unused bits at the end of every row.
+func (p *PackedPBM) clearPadding() {
+	if p.width%8 == 0 || p.stride == 0 {
+		return
+	}
+	mask := byte(0xFF << (8 - p.width%8))
+	for y := 0; y < p.height; y++ {
+		p.data[y*p.stride+p.stride-1] &= mask
+	}
+}
+
+// Invert inverts the values of all pixels.
+func (p *PackedPBM) Invert() {
+	for i := range p.data {
+		p.data[i] = ^p.data[i]
+	}
+	p.clearPadding()
+}
+
+// Flip flips the packed image horizontally.
+func (p *PackedPBM) Flip() {
+	for y := 0; y < p.height; y++ {
+		p.reverseRow(p.row(y))
+	}
+}
+
+// reverseRow mirrors one packed row in place using the bit reversal table.
+func (p *PackedPBM) reverseRow(row []byte) {
+	for i, j := 0, len(row)-1; i < j; i, j = i+1, j-1 {
+		row[i], row[j] = reversedBits[row[j]], reversedBits[row[i]]
+	}
+	if len(row)%2 == 1 {
+		row[len(row)/2] = reversedBits[row[len(row)/2]]
+	}
+
+	// The padding bits now lead the row; shift them back to the end.
+	shift := uint(p.stride*8 - p.width)
+	if shift == 0 {
+		return
+	}
+	for i := 0; i < len(row); i++ {
+		next := byte(0)
+		if i+1 < len(row) {
+			next = row[i+1]
+		}
+		row[i] = row[i]<<shift | next>>(8-shift)
+	}
+}
+
+// Flop flips the packed image vertically.
+func (p *PackedPBM) Flop() {
+	tmp := make([]byte, p.stride)
+	for y := 0; y < p.height/2; y++ {
+		top, bottom := p.row(y), p.row(p.height-1-y)
+		copy(tmp, top)
+		copy(top, bottom)
+		copy(bottom, tmp)
+	}
+}
+
+// Rotate180 rotates the packed image by 180 degrees.
+func (p *PackedPBM) Rotate180() {
+	p.Flop()
+	p.Flip()
+}
+
+// Rotate90CW rotates the packed image 90 degrees clockwise.
+func (p *PackedPBM) Rotate90CW() {
+	p.transpose(func(x, y int) (int, int) { return p.height - 1 - y, x })
+}
+
+// Rotate90CCW rotates the packed image 90 degrees counterclockwise.
+func (p *PackedPBM) Rotate90CCW() {
+	p.transpose(func(x, y int) (int, int) { return y, p.width - 1 - x })
+}
+
+// transpose builds the rotated bitmap by moving every set bit to the destination given by dest.
+// Zero bytes are skipped, which makes the mostly white pages of fax images fast to rotate.
+func (p *PackedPBM) transpose(dest func(x, y int) (int, int)) {
+	rotated := newPackedPBM(p.height, p.width)
+	for y := 0; y < p.height; y++ {
+		row := p.row(y)
+		for i, b := range row {
+			if b == 0 {
+				continue
+			}
+			for bit := 0; bit < 8; bit++ {
+				if b&(0x80>>bit) == 0 {
+					continue
+				}
+				nx, ny := dest(i*8+bit, y)
+				rotated.data[ny*rotated.stride+nx/8] |= 0x80 >> (nx % 8)
+			}
+		}
+	}
+	*p = *rotated
+}
+
+// ToPBM unpacks the image into a PBM image using the P4 format.
+func (p *PackedPBM) ToPBM() *PBM {
+	pbm := newPBM(p.width, p.height, "P4")
+	for y := 0; y < p.height; y++ {
+		unpackBits(pbm.row(y), p.row(y))
+	}
+	return pbm
+}
+
+// Pack converts the PBM image to the packed P4 layout.
+func (pbm *PBM) Pack() *PackedPBM {
+	packed := newPackedPBM(pbm.width, pbm.height)
+	for y := 0; y < pbm.height; y++ {
+		packBits(packed.row(y), pbm.row(y))
+	}
+	return packed
+}
@@ -0,0 +1,204 @@
+package Netpbm
+
+import (
+	"image"
+	"math"
+)
+
+// Paint gives the color of every pixel of the shapes drawn by the Fill methods: a Solid
+// color, a gradient, a Tile of an image or a Hatch pattern.
+type Paint interface {
+	At(x, y int) Pixel
+}
+
+// LinearGradient is a Paint going from one color to another along a direction.
+type LinearGradient struct {
+	from, to Pixel
+	// dx, dy is the direction of the gradient, lo and hi the positions of its ends along it.
+	dx, dy, lo, hi float64
+}
+
+// NewLinearGradient returns the gradient going from the color from to the color to across
+// the rectangle r, in the direction given by angle, in degrees clockwise from the x axis: 0
+// runs from the left edge to the right edge, 90 from the top edge to the bottom edge.
+func NewLinearGradient(r image.Rectangle, from, to Pixel, angle float64) LinearGradient {
+	rad := angle * math.Pi / 180
+	g := LinearGradient{from: from, to: to, dx: math.Cos(rad), dy: math.Sin(rad)}
+	r = r.Canon()
+	g.lo, g.hi = math.Inf(1), math.Inf(-1)
+	for _, c := range [4]image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, r.Max.Sub(image.Pt(1, 1))} {
+		d := float64(c.X)*g.dx + float64(c.Y)*g.dy
+		g.lo, g.hi = math.Min(g.lo, d), math.Max(g.hi, d)
+	}
+	return g
+}
+
+// At returns the color of the gradient at (x, y); pixels beyond its ends get the end colors.
+func (g LinearGradient) At(x, y int) Pixel {
+	if g.hi <= g.lo {
+		return g.from
+	}
+	d := float64(x)*g.dx + float64(y)*g.dy
+	return mixPixel(g.from, g.to, clamp01((d-g.lo)/(g.hi-g.lo)))
+}
+
+// RadialGradient is a Paint going from the Inner color at Center to the Outer color at
+// Radius pixels from it, and beyond.
+type RadialGradient struct {
+	Center       Point
+	Radius       int
+	Inner, Outer Pixel
+}
+
+// At returns the color of the gradient at (x, y).
+func (g RadialGradient) At(x, y int) Pixel {
+	if g.Radius <= 0 {
+		return g.Outer
+	}
+	d := math.Hypot(float64(x-g.Center.X), float64(y-g.Center.Y))
+	return mixPixel(g.Inner, g.Outer, clamp01(d/float64(g.Radius)))
+}
+
+// Solid is a Paint of a single color.
+type Solid Pixel
+
+// At returns the color of the paint.
+func (s Solid) At(x, y int) Pixel {
+	return Pixel(s)
+}
+
+// Tile is a Paint repeating Image in both directions, with its top-left corner at Offset.
+type Tile struct {
+	Image  *PPM
+	Offset Point
+}
+
+// At returns the pixel of the tiled image covering (x, y).
+func (t Tile) At(x, y int) Pixel {
+	if t.Image == nil || t.Image.width == 0 || t.Image.height == 0 {
+		return Pixel{}
+	}
+	tx := ((x-t.Offset.X)%t.Image.width + t.Image.width) % t.Image.width
+	ty := ((y-t.Offset.Y)%t.Image.height + t.Image.height) % t.Image.height
+	return t.Image.data[ty*t.Image.width+tx]
+}
+
+// HatchStyle selects the lines drawn by a Hatch.
+type HatchStyle int
+
+const (
+	// HorizontalHatch draws horizontal lines.
+	HorizontalHatch HatchStyle = iota
+	// VerticalHatch draws vertical lines.
+	VerticalHatch
+	// DiagonalHatch draws lines rising to the right.
+	DiagonalHatch
+	// BackDiagonalHatch draws lines falling to the right.
+	BackDiagonalHatch
+	// CrossHatch draws horizontal and vertical lines.
+	CrossHatch
+	// DiagonalCrossHatch draws lines in both diagonal directions.
+	DiagonalCrossHatch
+	// DotHatch draws a grid of dots.
+	DotHatch
+)
+
+// Hatch is a Paint of one-pixel lines of the Foreground color every Spacing pixels over
+// the Background color, to tell apart the areas of charts and diagrams printed without
+// color.
+type Hatch struct {
+	Style                  HatchStyle
+	Spacing                int
+	Foreground, Background Pixel
+}
+
+// At returns the color of the hatch pattern at (x, y).
+func (h Hatch) At(x, y int) Pixel {
+	spacing := max(h.Spacing, 2)
+	on := func(v int) bool { return (v%spacing+spacing)%spacing == 0 }
+	var line bool
+	switch h.Style {
+	case HorizontalHatch:
+		line = on(y)
+	case VerticalHatch:
+		line = on(x)
+	case DiagonalHatch:
+		line = on(x + y)
+	case BackDiagonalHatch:
+		line = on(x - y)
+	case CrossHatch:
+		line = on(x) || on(y)
+	case DiagonalCrossHatch:
+		line = on(x+y) || on(x-y)
+	case DotHatch:
+		line = on(x) && on(y)
+	}
+	if line {
+		return h.Foreground
+	}
+	return h.Background
+}
+
+// fillWith runs draw with every plotted pixel taking its color from paint.
+func (ppm *PPM) fillWith(paint Paint, draw func()) {
+	ppm.paint = paint
+	defer func() { ppm.paint = nil }()
+	draw()
+}
+
+// FillRectangle fills the rectangle r of the PPM image with paint.
+func (ppm *PPM) FillRectangle(r image.Rectangle, paint Paint) {
+	r = r.Canon()
+	ppm.fillWith(paint, func() {
+		ppm.DrawFilledRectangle(Point{r.Min.X, r.Min.Y}, r.Dx(), r.Dy(), Pixel{})
+	})
+}
+
+// FillRoundedRectangle fills a w x h rectangle from p with rounded corners of the given
+// radius on the PPM image with paint.
+func (ppm *PPM) FillRoundedRectangle(p Point, w, h, cornerRadius int, paint Paint) {
+	ppm.fillWith(paint, func() {
+		ppm.DrawFilledRoundedRectangle(p, w, h, cornerRadius, Pixel{})
+	})
+}
+
+// FillCircle fills a disc of the given radius on the PPM image with paint.
+func (ppm *PPM) FillCircle(center Point, radius int, paint Paint) {
+	ppm.fillWith(paint, func() {
+		ppm.DrawFilledCircle(center, radius, Pixel{})
+	})
+}
+
+// FillEllipse fills an ellipse of radii rx and ry on the PPM image with paint.
+func (ppm *PPM) FillEllipse(center Point, rx, ry int, paint Paint) {
+	ppm.fillWith(paint, func() {
+		ppm.DrawFilledEllipse(center, rx, ry, Pixel{})
+	})
+}
+
+// FillPolygon fills a polygon on the PPM image with paint, following the even–odd rule.
+func (ppm *PPM) FillPolygon(points []Point, paint Paint) {
+	ppm.fillWith(paint, func() {
+		ppm.DrawFilledPolygon(points, Pixel{})
+	})
+}
+
+// FillPieSlice fills a slice of the disc of the given radius between startAngle and
+// endAngle, in degrees clockwise from the 3 o'clock direction, on the PPM image with paint.
+func (ppm *PPM) FillPieSlice(center Point, radius int, startAngle, endAngle float64, paint Paint) {
+	ppm.fillWith(paint, func() {
+		ppm.DrawPieSlice(center, radius, startAngle, endAngle, Pixel{})
+	})
+}
+
+// FillLinearGradient fills the rectangle r of the PPM image with a gradient from the color
+// from to the color to, in the direction given by angle (see NewLinearGradient).
+func (ppm *PPM) FillLinearGradient(r image.Rectangle, from, to Pixel, angle float64) {
+	ppm.FillRectangle(r, NewLinearGradient(r, from, to, angle))
+}
+
+// FillRadialGradient fills the disc of the given radius of the PPM image with a gradient
+// from the inner color at its center to the outer color on its circumference.
+func (ppm *PPM) FillRadialGradient(center Point, radius int, inner, outer Pixel) {
+	ppm.FillCircle(center, radius, RadialGradient{Center: center, Radius: radius, Inner: inner, Outer: outer})
+}
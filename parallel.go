@@ -0,0 +1,108 @@
+package Netpbm
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minBandRows is the smallest number of rows worth handing to another goroutine.
+const minBandRows = 8
+
+// ctxBatchRows is the number of rows processed by parallelRowsCtx between checks for
+// cancellation.
+const ctxBatchRows = 64
+
+// parallelism is the maximum number of goroutines used by parallelRows, 0 meaning
+// GOMAXPROCS.
+var parallelism atomic.Int32
+
+// SetParallelism sets the maximum number of goroutines the image operations spread their
+// rows across and returns the previous setting. 1 disables parallel processing, which
+// helps when many images are already processed concurrently; 0 restores the default of
+// GOMAXPROCS.
+func SetParallelism(workers int) int {
+	if workers < 0 {
+		workers = 0
+	}
+	return int(parallelism.Swap(int32(workers)))
+}
+
+// rowBand is a range of rows [start, end) processed by a worker of the pool.
+type rowBand struct {
+	start, end int
+	fn         func(y int)
+	wg         *sync.WaitGroup
+}
+
+// run calls fn for every row of the band.
+func (b rowBand) run() {
+	defer b.wg.Done()
+	for y := b.start; y < b.end; y++ {
+		b.fn(y)
+	}
+}
+
+// workerPool holds the goroutines running the bands of parallelRows, started on first use.
+var workerPool struct {
+	once  sync.Once
+	bands chan rowBand
+}
+
+// startWorkers starts one worker per processor.
+func startWorkers() {
+	workerPool.bands = make(chan rowBand)
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		go func() {
+			for b := range workerPool.bands {
+				b.run()
+			}
+		}()
+	}
+}
+
+// parallelRows calls fn for every row index in [0, height), spreading contiguous bands of
+// rows across the workers of the pool. fn must only write to data owned by its row. Bands
+// no idle worker takes, such as those of nested calls, run on the calling goroutine.
+func parallelRows(height int, fn func(y int)) {
+	workers := int(parallelism.Load())
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, height/minBandRows)
+	if workers <= 1 {
+		for y := 0; y < height; y++ {
+			fn(y)
+		}
+		return
+	}
+
+	workerPool.once.Do(startWorkers)
+	var wg sync.WaitGroup
+	band := (height + workers - 1) / workers
+	wg.Add((height + band - 1) / band)
+	for start := band; start < height; start += band {
+		b := rowBand{start, min(start+band, height), fn, &wg}
+		select {
+		case workerPool.bands <- b:
+		default:
+			b.run()
+		}
+	}
+	rowBand{0, band, fn, &wg}.run()
+	wg.Wait()
+}
+
+// parallelRowsCtx is parallelRows processing the rows in batches, returning the error of
+// ctx without processing the remaining rows once it is canceled.
+func parallelRowsCtx(ctx context.Context, height int, fn func(y int)) error {
+	for start := 0; start < height; start += ctxBatchRows {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+ctxBatchRows, height)
+		parallelRows(end-start, func(y int) { fn(start + y) })
+	}
+	return nil
+}
@@ -10,9 +10,11 @@ import (
 
 // PBM represents a PBM image
 type PBM struct {
-	data          [][]bool
+	data          []bool // Pixel values of the image, row after row
 	width, height int
 	magicNumber   string
+	history
+	bounds BoundsPolicy // Handling of the drawn pixels outside the image
 }
 
 // ReadPBM reads a PBM image from a file and returns a structure representing the image.
@@ -23,7 +25,12 @@ func ReadPBM(filename string) (*PBM, error) {
 	}
 	defer file.Close()
 
-	reader := bufio.NewReader(file)
+	return DecodePBM(file)
+}
+
+// DecodePBM reads a PBM image from r and returns a structure representing the image.
+func DecodePBM(r io.Reader) (*PBM, error) {
+	reader := bufio.NewReader(r)
 
 	// Read the magic number
 	magicNumber, err := reader.ReadString('\n')
@@ -46,11 +53,7 @@ func ReadPBM(filename string) (*PBM, error) {
 		return nil, fmt.Errorf("invalid dimensions: %v", err)
 	}
 
-	data := make([][]bool, height)
-
-	for i := range data {
-		data[i] = make([]bool, width)
-	}
+	data := make([]bool, width*height)
 
 	if magicNumber == "P1" {
 		// Read format P1 (ASCII)
@@ -64,39 +67,29 @@ func ReadPBM(filename string) (*PBM, error) {
 				if x >= width {
 					return nil, fmt.Errorf("index out of range at line %d", y)
 				}
-				data[y][x] = field == "1"
+				data[y*width+x] = field == "1"
 			}
 		}
 	} else if magicNumber == "P4" {
 		// Read format P4 (binary)
 		expectedBytesPerRow := (width + 7) / 8
+		row := make([]byte, expectedBytesPerRow)
 		for y := 0; y < height; y++ {
-			row := make([]byte, expectedBytesPerRow)
-			n, err := reader.Read(row)
-			if err != nil {
-				if err == io.EOF {
-					return nil, fmt.Errorf("unexpected end of file at line %d", y)
-				}
-				return nil, fmt.Errorf("error reading pixel data at line %d: %v", y, err)
+			n, err := io.ReadFull(reader, row)
+			if err == io.EOF {
+				return nil, fmt.Errorf("unexpected end of file at line %d", y)
 			}
-			if n < expectedBytesPerRow {
+			if err == io.ErrUnexpectedEOF {
 				return nil, fmt.Errorf("unexpected end of file at line %d, expected %d bytes, got %d", y, expectedBytesPerRow, n)
 			}
-
-			for x := 0; x < width; x++ {
-				byteIndex := x / 8
-				bitIndex := 7 - (x % 8)
-
-				// Convert ASCII to decimal and extract the bit
-				decimalValue := int(row[byteIndex])
-				bitValue := (decimalValue >> bitIndex) & 1
-
-				data[y][x] = bitValue != 0
+			if err != nil {
+				return nil, fmt.Errorf("error reading pixel data at line %d: %v", y, err)
 			}
+			unpackBits(data[y*width:(y+1)*width], row)
 		}
 	}
 
-	return &PBM{data, width, height, magicNumber}, nil
+	return &PBM{data: data, width: width, height: height, magicNumber: magicNumber}, nil
 }
 
 // Save saves a PBM image to a file.
@@ -107,10 +100,16 @@ func (pbm *PBM) Save(filename string) error {
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
+	return pbm.Encode(file)
+}
+
+// Encode writes the PBM image to w.
+func (pbm *PBM) Encode(w io.Writer) error {
+	writer := getWriter(w)
+	defer putWriter(writer)
 
 	// Write the magic number
-	_, err = fmt.Fprintln(writer, pbm.magicNumber)
+	_, err := fmt.Fprintln(writer, pbm.magicNumber)
 	if err != nil {
 		return fmt.Errorf("error writing magic number: %v", err)
 	}
@@ -125,7 +124,7 @@ func (pbm *PBM) Save(filename string) error {
 		// Write format P1 (ASCII)
 		for y := 0; y < pbm.height; y++ {
 			for x := 0; x < pbm.width; x++ {
-				if pbm.data[y][x] {
+				if pbm.data[y*pbm.width+x] {
 					_, err := fmt.Fprint(writer, "1 ")
 					if err != nil {
 						return fmt.Errorf("error writing data at line %d, column %d: %v", y, x, err)
@@ -143,25 +142,12 @@ func (pbm *PBM) Save(filename string) error {
 			}
 		}
 	} else if pbm.magicNumber == "P4" {
-		// Write format P4 (binary)
+		// Write format P4 (binary), a whole row at a time
+		row := make([]byte, (pbm.width+7)/8)
 		for y := 0; y < pbm.height; y++ {
-			var currentByte byte
-			for x := 0; x < pbm.width; x++ {
-				bitIndex := 7 - (x % 8)
-				bitValue := 0
-				if pbm.data[y][x] {
-					bitValue = 1
-				}
-				// Update the appropriate bit in the byte
-				currentByte |= byte(bitValue << bitIndex)
-
-				if (x+1)%8 == 0 || x == pbm.width-1 {
-					_, err := writer.Write([]byte{currentByte})
-					if err != nil {
-						return fmt.Errorf("error writing binary data at line %d: %v", y, err)
-					}
-					currentByte = 0
-				}
+			packBits(row, pbm.row(y))
+			if _, err := writer.Write(row); err != nil {
+				return fmt.Errorf("error writing binary data at line %d: %v", y, err)
 			}
 		}
 	}
@@ -179,44 +165,70 @@ func (pbm *PBM) Size() (int, int) {
 	return pbm.width, pbm.height
 }
 
-// At returns the pixel value at position (x, y) in the PBM image.
+// At returns the pixel value at position (x, y) in the PBM image, panicking if it lies
+// outside the image; see AtOK.
 func (pbm *PBM) At(x, y int) bool {
-	return pbm.data[y][x]
+	checkPixel(x, y, pbm.width, pbm.height)
+	return pbm.data[y*pbm.width+x]
 }
 
-// Set sets the pixel value at position (x, y) in the PBM image.
+// Set sets the pixel value at position (x, y) in the PBM image, panicking if it lies
+// outside the image; see SetClamped.
 func (pbm *PBM) Set(x, y int, value bool) {
-	pbm.data[y][x] = value
+	checkPixel(x, y, pbm.width, pbm.height)
+	pbm.data[y*pbm.width+x] = value
 }
 
 // Invert inverts the values of all pixels in the PBM image.
 func (pbm *PBM) Invert() {
-	for y := 0; y < pbm.height; y++ {
-		for x := 0; x < pbm.width; x++ {
-			pbm.data[y][x] = !pbm.data[y][x]
+	pbm.record("Invert")
+	parallelRows(pbm.height, func(y int) {
+		row := pbm.row(y)
+		for x, v := range row {
+			row[x] = !v
 		}
-	}
+	})
 }
 
 // Flip flips the PBM image horizontally.
 func (pbm *PBM) Flip() {
-	for y := 0; y < pbm.height; y++ {
+	pbm.record("Flip")
+	parallelRows(pbm.height, func(y int) {
+		row := pbm.row(y)
 		for x := 0; x < pbm.width/2; x++ {
-			pbm.data[y][x], pbm.data[y][pbm.width-x-1] = pbm.data[y][pbm.width-x-1], pbm.data[y][x]
+			row[x], row[pbm.width-x-1] = row[pbm.width-x-1], row[x]
 		}
-	}
+	})
 }
 
 // Flop flips the PBM image vertically.
 func (pbm *PBM) Flop() {
-	for x := 0; x < pbm.width; x++ {
-		for y := 0; y < pbm.height/2; y++ {
-			pbm.data[y][x], pbm.data[pbm.height-y-1][x] = pbm.data[pbm.height-y-1][x], pbm.data[y][x]
+	pbm.record("Flop")
+	parallelRows(pbm.height/2, func(y int) {
+		top, bottom := pbm.row(y), pbm.row(pbm.height-y-1)
+		for x := range top {
+			top[x], bottom[x] = bottom[x], top[x]
 		}
-	}
+	})
+}
+
+// MagicNumber returns the magic number of the PBM image, "P1" or "P4".
+func (pbm *PBM) MagicNumber() string {
+	return pbm.magicNumber
 }
 
 // SetMagicNumber sets the magic number of the PBM image.
 func (pbm *PBM) SetMagicNumber(magicNumber string) {
 	pbm.magicNumber = magicNumber
 }
+
+// ToPPM converts the PBM image to a PPM image, drawing set pixels in black on white.
+func (pbm *PBM) ToPPM() *PPM {
+	ppmData := make([]Pixel, len(pbm.data))
+	for i, v := range pbm.data {
+		if !v {
+			ppmData[i] = Pixel{255, 255, 255}
+		}
+	}
+	return &PPM{data: ppmData, width: pbm.width, height: pbm.height, magicNumber: "P3", max: 255}
+}
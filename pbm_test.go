@@ -0,0 +1,45 @@
+package Netpbm
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// benchmarkPBM returns a 1024x1024 P4 image with a checkerboard pattern.
+func benchmarkPBM() *PBM {
+	pbm := newPBM(1024, 1024, "P4")
+	for i := range pbm.data {
+		pbm.data[i] = (i/pbm.width+i%pbm.width)%2 == 0
+	}
+	return pbm
+}
+
+// BenchmarkEncodeP4 measures the encoding of a raw PBM image.
+func BenchmarkEncodeP4(b *testing.B) {
+	pbm := benchmarkPBM()
+	b.SetBytes(int64(pbm.height * ((pbm.width + 7) / 8)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pbm.Encode(io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeP4 measures the decoding of a raw PBM image.
+func BenchmarkDecodeP4(b *testing.B) {
+	pbm := benchmarkPBM()
+	var buf bytes.Buffer
+	if err := pbm.Encode(&buf); err != nil {
+		b.Fatal(err)
+	}
+	encoded := buf.Bytes()
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodePBM(bytes.NewReader(encoded)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
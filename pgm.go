@@ -3,6 +3,7 @@ package Netpbm
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -10,11 +11,13 @@ import (
 
 // PGM represents a PGM image.
 type PGM struct {
-	data        [][]uint8 // Pixel values of the image
-	width       int       // Width of the image
-	height      int       // Height of the image
-	magicNumber string    // PGM file format identifier
-	max         uint      // Maximum pixel value (usually 255 for 8-bit PGM)
+	data        []uint8 // Pixel values of the image, row after row
+	width       int     // Width of the image
+	height      int     // Height of the image
+	magicNumber string  // PGM file format identifier
+	max         uint    // Maximum pixel value (usually 255 for 8-bit PGM)
+	history
+	bounds BoundsPolicy // Handling of the drawn pixels outside the image
 }
 
 // ReadPGM reads a PGM image from a file and returns a structure representing the image.
@@ -25,7 +28,17 @@ func ReadPGM(filename string) (*PGM, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	return DecodePGM(file)
+}
+
+// DecodePGM reads a PGM image from r and returns a structure representing the image.
+func DecodePGM(r io.Reader) (*PGM, error) {
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(2); err == nil && string(magic) == "P5" {
+		return decodeRawPGM(reader)
+	}
+	scanner, release := newScanner(reader)
+	defer release()
 
 	// Read the magic number
 	scanner.Scan()
@@ -49,9 +62,8 @@ func ReadPGM(filename string) (*PGM, error) {
 		return nil, err
 	}
 
-	data := make([][]uint8, height)
+	data := make([]uint8, width*height)
 	for i := 0; i < height; i++ {
-		data[i] = make([]uint8, width)
 		scanner.Scan()
 		row := strings.Fields(scanner.Text())
 		for j := 0; j < width; j++ {
@@ -59,7 +71,7 @@ func ReadPGM(filename string) (*PGM, error) {
 			if err != nil {
 				return nil, err
 			}
-			data[i][j] = uint8(value)
+			data[i*width+j] = uint8(value)
 		}
 	}
 
@@ -72,19 +84,39 @@ func ReadPGM(filename string) (*PGM, error) {
 	}, nil
 }
 
+// decodeRawPGM reads a P5 image, whose samples follow the header as one byte each.
+func decodeRawPGM(reader *bufio.Reader) (*PGM, error) {
+	h, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if h.Max > 255 {
+		return nil, fmt.Errorf("unsupported max value: %d", h.Max)
+	}
+	pgm := newPGM(h.Width, h.Height, h.MagicNumber, h.Max)
+	if _, err := io.ReadFull(reader, pgm.data); err != nil {
+		return nil, fmt.Errorf("error reading pixel data: %v", err)
+	}
+	return pgm, nil
+}
+
 // Size returns the width and height of the image.
 func (pgm *PGM) Size() (int, int) {
 	return pgm.width, pgm.height
 }
 
-// At returns the pixel value at position (x, y).
+// At returns the pixel value at position (x, y), panicking if it lies outside the image;
+// see AtOK.
 func (pgm *PGM) At(x, y int) uint8 {
-	return pgm.data[y][x]
+	checkPixel(x, y, pgm.width, pgm.height)
+	return pgm.data[y*pgm.width+x]
 }
 
-// Set sets the pixel value at position (x, y).
+// Set sets the pixel value at position (x, y), panicking if it lies outside the image;
+// see SetClamped.
 func (pgm *PGM) Set(x, y int, value uint8) {
-	pgm.data[y][x] = value
+	checkPixel(x, y, pgm.width, pgm.height)
+	pgm.data[y*pgm.width+x] = value
 }
 
 // Save saves the PGM image to a file and returns an error if any.
@@ -95,43 +127,78 @@ func (pgm *PGM) Save(filename string) error {
 	}
 	defer file.Close()
 
-	fmt.Fprintf(file, "%s\n%d %d\n%d\n", pgm.magicNumber, pgm.width, pgm.height, pgm.max)
+	return pgm.Encode(file)
+}
+
+// Encode writes the PGM image to w and returns an error if any.
+func (pgm *PGM) Encode(w io.Writer) error {
+	writer := getWriter(w)
+	defer putWriter(writer)
+
+	fmt.Fprintf(writer, "%s\n%d %d\n%d\n", pgm.magicNumber, pgm.width, pgm.height, pgm.max)
+
+	if pgm.magicNumber == "P5" {
+		// Write format P5 (binary), one byte per sample
+		if pgm.max > 255 {
+			return fmt.Errorf("unsupported max value: %d", pgm.max)
+		}
+		if _, err := writer.Write(pgm.data); err != nil {
+			return fmt.Errorf("error writing binary data: %v", err)
+		}
+		return writer.Flush()
+	}
 
 	for i := 0; i < pgm.height; i++ {
 		for j := 0; j < pgm.width; j++ {
-			fmt.Fprintf(file, "%d ", pgm.data[i][j])
+			fmt.Fprintf(writer, "%d ", pgm.data[i*pgm.width+j])
 		}
-		fmt.Fprintln(file)
+		fmt.Fprintln(writer)
 	}
 
-	return nil
+	return writer.Flush()
 }
 
 // Invert inverts the colors of the PGM image.
 func (pgm *PGM) Invert() {
-	for i := 0; i < pgm.height; i++ {
-		for j := 0; j < pgm.width; j++ {
-			pgm.data[i][j] = uint8(pgm.max) - pgm.data[i][j]
+	pgm.record("Invert")
+	parallelRows(pgm.height, func(i int) {
+		row := pgm.row(i)
+		for j, v := range row {
+			row[j] = uint8(pgm.max) - v
 		}
-	}
+	})
 }
 
 // Flip flips the PGM image horizontally.
 func (pgm *PGM) Flip() {
-	for i := 0; i < pgm.height; i++ {
+	pgm.record("Flip")
+	parallelRows(pgm.height, func(i int) {
+		row := pgm.row(i)
 		for j := 0; j < pgm.width/2; j++ {
-			pgm.data[i][j], pgm.data[i][pgm.width-j-1] = pgm.data[i][pgm.width-j-1], pgm.data[i][j]
+			row[j], row[pgm.width-j-1] = row[pgm.width-j-1], row[j]
 		}
-	}
+	})
 }
 
 // Flop flips the PGM image vertically.
 func (pgm *PGM) Flop() {
-	for i := 0; i < pgm.height/2; i++ {
-		for j := 0; j < pgm.width; j++ {
-			pgm.data[i][j], pgm.data[pgm.height-i-1][j] = pgm.data[pgm.height-i-1][j], pgm.data[i][j]
+	pgm.record("Flop")
+	parallelRows(pgm.height/2, func(i int) {
+		top, bottom := pgm.row(i), pgm.row(pgm.height-i-1)
+		for j := range top {
+			top[j], bottom[j] = bottom[j], top[j]
 		}
-	}
+	})
+}
+
+// MagicNumber returns the magic number of the PGM image, "P2" or "P5".
+func (pgm *PGM) MagicNumber() string {
+	return pgm.magicNumber
+}
+
+// MaxValue returns the maximum value of the PGM image.
+func (pgm *PGM) MaxValue() uint {
+	return pgm.max
 }
 
 // SetMagicNumber sets the magic number of the PGM image.
@@ -146,14 +213,11 @@ func (pgm *PGM) SetMaxValue(maxValue uint) {
 
 // Rotate90CW rotates the PGM image 90 degrees clockwise.
 func (pgm *PGM) Rotate90CW() {
-	rotatedData := make([][]uint8, pgm.width)
-	for i := 0; i < pgm.width; i++ {
-		rotatedData[i] = make([]uint8, pgm.height)
-	}
-
+	pgm.record("Rotate90CW")
+	rotatedData := make([]uint8, len(pgm.data))
 	for i := 0; i < pgm.width; i++ {
 		for j := 0; j < pgm.height; j++ {
-			rotatedData[i][j] = pgm.data[pgm.height-j-1][i]
+			rotatedData[i*pgm.height+j] = pgm.data[(pgm.height-j-1)*pgm.width+i]
 		}
 	}
 
@@ -163,12 +227,9 @@ func (pgm *PGM) Rotate90CW() {
 
 // ToPBM converts the PGM image to PBM.
 func (pgm *PGM) ToPBM() *PBM {
-	pbmData := make([][]bool, pgm.height)
-	for i := 0; i < pgm.height; i++ {
-		pbmData[i] = make([]bool, pgm.width)
-		for j := 0; j < pgm.width; j++ {
-			pbmData[i][j] = uint16(pgm.data[i][j]) > uint16(pgm.max)/2
-		}
+	pbmData := make([]bool, len(pgm.data))
+	for i, v := range pgm.data {
+		pbmData[i] = uint16(v) > uint16(pgm.max)/2
 	}
 
 	return &PBM{
@@ -178,3 +239,23 @@ func (pgm *PGM) ToPBM() *PBM {
 		magicNumber: "P4",
 	}
 }
+
+// ToPPM converts the PGM image to a PPM image with equal red, green and blue values.
+func (pgm *PGM) ToPPM() *PPM {
+	max := uint8(255)
+	if pgm.max < 255 {
+		max = uint8(pgm.max)
+	}
+	ppmData := make([]Pixel, len(pgm.data))
+	for i, v := range pgm.data {
+		ppmData[i] = Pixel{v, v, v}
+	}
+
+	return &PPM{
+		data:        ppmData,
+		width:       pgm.width,
+		height:      pgm.height,
+		magicNumber: "P3",
+		max:         max,
+	}
+}
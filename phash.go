@@ -0,0 +1,149 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// HashMethod selects the perceptual hash computed by Hash.
+type HashMethod int
+
+const (
+	// AverageHash sets the bits of the 8x8 thumbnail cells brighter than its mean. It is
+	// fast but sensitive to global brightness changes.
+	AverageHash HashMethod = iota
+	// DifferenceHash sets the bits of the cells of a 9x8 thumbnail brighter than their right
+	// neighbor, following the gradients of the image.
+	DifferenceHash
+	// PerceptualHash sets the bits of the 8x8 lowest frequencies of the discrete cosine
+	// transform of a 32x32 thumbnail above their median. It is the most robust to scaling,
+	// compression and small edits.
+	PerceptualHash
+)
+
+// pHashSize is the size of the thumbnail transformed by PerceptualHash.
+const pHashSize = 32
+
+// HammingDistance returns the number of bits differing between two hashes: near-duplicate
+// images have hashes a few bits apart, typically less than 10 out of 64.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// thumbnail shrinks a plane to w x h cells, each the mean of the samples it covers.
+func thumbnail(p plane, w, h int) plane {
+	height := len(p)
+	width := 0
+	if height > 0 {
+		width = len(p[0])
+	}
+	out := make(plane, h)
+	for cy := range out {
+		out[cy] = make([]float64, w)
+		y0, y1 := cy*height/h, max((cy+1)*height/h, cy*height/h+1)
+		for cx := range out[cy] {
+			x0, x1 := cx*width/w, max((cx+1)*width/w, cx*width/w+1)
+			var sum float64
+			var n int
+			for y := y0; y < min(y1, height); y++ {
+				for x := x0; x < min(x1, width); x++ {
+					sum += p[y][x]
+					n++
+				}
+			}
+			if n > 0 {
+				out[cy][cx] = sum / float64(n)
+			}
+		}
+	}
+	return out
+}
+
+// hashPlane computes a perceptual hash of a plane of gray levels.
+func hashPlane(p plane, method HashMethod) (uint64, error) {
+	var hash uint64
+	setBits := func(values []float64, threshold float64) {
+		for i, v := range values {
+			if v > threshold {
+				hash |= 1 << uint(i)
+			}
+		}
+	}
+	switch method {
+	case AverageHash:
+		t := thumbnail(p, 8, 8)
+		var values []float64
+		var sum float64
+		for _, row := range t {
+			values = append(values, row...)
+			for _, v := range row {
+				sum += v
+			}
+		}
+		setBits(values, sum/64)
+	case DifferenceHash:
+		t := thumbnail(p, 9, 8)
+		bit := 0
+		for _, row := range t {
+			for x := 0; x < 8; x++ {
+				if row[x] > row[x+1] {
+					hash |= 1 << uint(bit)
+				}
+				bit++
+			}
+		}
+	case PerceptualHash:
+		t := thumbnail(p, pHashSize, pHashSize)
+		coefficients := make([]float64, 0, 64)
+		for v := 0; v < 8; v++ {
+			for u := 0; u < 8; u++ {
+				coefficients = append(coefficients, dct2(t, u, v))
+			}
+		}
+		// The median leaves out the DC coefficient, which only reflects the mean brightness.
+		sorted := append([]float64(nil), coefficients[1:]...)
+		sort.Float64s(sorted)
+		median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+		setBits(coefficients, median)
+	default:
+		return 0, fmt.Errorf("invalid hash method: %d", method)
+	}
+	return hash, nil
+}
+
+// dct2 returns the (u, v) coefficient of the two-dimensional DCT-II of a square plane.
+func dct2(p plane, u, v int) float64 {
+	n := float64(len(p))
+	var sum float64
+	for y, row := range p {
+		cy := math.Cos((2*float64(y) + 1) * float64(v) * math.Pi / (2 * n))
+		for x, s := range row {
+			sum += s * cy * math.Cos((2*float64(x)+1)*float64(u)*math.Pi/(2*n))
+		}
+	}
+	return sum
+}
+
+// Hash returns a 64-bit perceptual hash of the PGM image: similar images have hashes at a
+// small HammingDistance. It returns 0 for an invalid method.
+func (pgm *PGM) Hash(method HashMethod) uint64 {
+	hash, _ := hashPlane(pgm.planes()[0], method)
+	return hash
+}
+
+// Hash returns a 64-bit perceptual hash of the luminance of the PPM image: similar images
+// have hashes at a small HammingDistance. It returns 0 for an invalid method.
+func (ppm *PPM) Hash(method HashMethod) uint64 {
+	p := make(plane, ppm.height)
+	for y := 0; y < ppm.height; y++ {
+		row := ppm.row(y)
+		p[y] = make([]float64, len(row))
+		for x, v := range row {
+			p[y][x] = Rec601Luma(v)
+		}
+	}
+	hash, _ := hashPlane(p, method)
+	return hash
+}
@@ -0,0 +1,523 @@
+package Netpbm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"nom_du_module/kernels"
+)
+
+// pipelineStep is one named operation of a pipeline.
+type pipelineStep struct {
+	name  string
+	apply func(ppm *PPM) error
+	// pixel is set instead of apply for the operations transforming every pixel on its
+	// own, returning the transform for images of the given maximum value, so that
+	// consecutive such steps run in a single pass. params are then recorded in the history.
+	pixel  func(max uint8) func(p Pixel) Pixel
+	params []interface{}
+}
+
+// Pipeline is an ordered list of operations applied to PPM images. Besides Add and
+// LoadPipeline, it can be built by chaining the methods named after the operations:
+//
+//	out, err := NewPipeline(img).Bin(2, BinAverage).Blur(1.5).Invert().Gamma(2.2).Run()
+//
+// Consecutive operations transforming every pixel independently, such as Invert, Gamma or
+// AdjustHue, are fused into a single pass over the image.
+type Pipeline struct {
+	steps  []pipelineStep
+	source *PPM
+	// err is the first invalid parameter given to the chained methods.
+	err error
+}
+
+// NewPipeline returns an empty pipeline whose Run processes src.
+func NewPipeline(src *PPM) *Pipeline {
+	return &Pipeline{source: src}
+}
+
+// Add appends a named operation to the pipeline and returns the pipeline.
+func (p *Pipeline) Add(name string, apply func(ppm *PPM) error) *Pipeline {
+	p.steps = append(p.steps, pipelineStep{name: name, apply: apply})
+	return p
+}
+
+// Len returns the number of operations in the pipeline.
+func (p *Pipeline) Len() int {
+	return len(p.steps)
+}
+
+// Apply runs every operation of the pipeline on the PPM image, in order, stopping at the first error.
+func (p *Pipeline) Apply(ppm *PPM) error {
+	if p.err != nil {
+		return p.err
+	}
+	for i := 0; i < len(p.steps); {
+		step := p.steps[i]
+		if step.pixel == nil {
+			if err := step.apply(ppm); err != nil {
+				return fmt.Errorf("step %d (%s): %v", i, step.name, err)
+			}
+			i++
+			continue
+		}
+		end := i + 1
+		for end < len(p.steps) && p.steps[end].pixel != nil {
+			end++
+		}
+		ppm.applyPixelSteps(p.steps[i:end])
+		i = end
+	}
+	return nil
+}
+
+// Run applies the pipeline to a copy of the image given to NewPipeline and returns it,
+// leaving the source unchanged. The source pixels are copied once, when first modified.
+func (p *Pipeline) Run() (*PPM, error) {
+	if p.source == nil {
+		return nil, fmt.Errorf("pipeline has no source image")
+	}
+	out := p.source.Snapshot()
+	if err := p.Apply(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// applyPixelSteps runs consecutive per-pixel steps in a single pass over the image.
+func (ppm *PPM) applyPixelSteps(steps []pipelineStep) {
+	fns := make([]func(p Pixel) Pixel, len(steps))
+	for i, step := range steps {
+		ppm.record(step.name, step.params...)
+		fns[i] = step.pixel(ppm.max)
+	}
+	ppm.own()
+	parallelRows(ppm.height, func(y int) {
+		row := ppm.row(y)
+		for x, v := range row {
+			for _, fn := range fns {
+				v = fn(v)
+			}
+			row[x] = v
+		}
+	})
+}
+
+// addPixel appends a per-pixel operation to the pipeline and returns the pipeline.
+func (p *Pipeline) addPixel(name string, pixel func(max uint8) func(p Pixel) Pixel, params ...interface{}) *Pipeline {
+	p.steps = append(p.steps, pipelineStep{name: name, pixel: pixel, params: params})
+	return p
+}
+
+// fail keeps the first error of the chained methods, reported by Apply and Run, and
+// returns the pipeline.
+func (p *Pipeline) fail(name string, err error) *Pipeline {
+	if p.err == nil {
+		p.err = fmt.Errorf("step %d (%s): %v", len(p.steps), name, err)
+	}
+	return p
+}
+
+// Invert appends the inversion of the colors, as done by PPM.Invert, to the pipeline.
+func (p *Pipeline) Invert() *Pipeline {
+	return p.addPixel("Invert", func(max uint8) func(v Pixel) Pixel {
+		return func(v Pixel) Pixel { return Pixel{max - v.R, max - v.G, max - v.B} }
+	})
+}
+
+// Gamma appends a gamma correction, as done by PPM.Gamma, to the pipeline.
+func (p *Pipeline) Gamma(gamma float64) *Pipeline {
+	if err := checkGamma(gamma); err != nil {
+		return p.fail("Gamma", err)
+	}
+	return p.addPixel("Gamma", func(max uint8) func(v Pixel) Pixel {
+		table := gammaTable(gamma, max)
+		return func(v Pixel) Pixel { return Pixel{table[v.R], table[v.G], table[v.B]} }
+	}, "gamma", gamma)
+}
+
+// SwapChannels appends the reordering of the color channels, as done by
+// PPM.SwapChannels, to the pipeline.
+func (p *Pipeline) SwapChannels(order string) *Pipeline {
+	sources, err := parseChannelOrder(order)
+	if err != nil {
+		return p.fail("SwapChannels", err)
+	}
+	return p.addPixel("SwapChannels", func(uint8) func(v Pixel) Pixel {
+		return func(v Pixel) Pixel { return Pixel{sources[0].get(v), sources[1].get(v), sources[2].get(v)} }
+	}, "order", order)
+}
+
+// AdjustHue appends a hue rotation, as done by PPM.AdjustHue, to the pipeline.
+func (p *Pipeline) AdjustHue(degrees float64) *Pipeline {
+	return p.addPixel("AdjustHue", func(max uint8) func(v Pixel) Pixel {
+		return hslPixel(max, func(h, s, l float64) (float64, float64, float64) { return h + degrees, s, l })
+	}, "degrees", degrees)
+}
+
+// AdjustSaturation appends a saturation change, as done by PPM.AdjustSaturation, to the
+// pipeline.
+func (p *Pipeline) AdjustSaturation(factor float64) *Pipeline {
+	return p.addPixel("AdjustSaturation", func(max uint8) func(v Pixel) Pixel {
+		return hslPixel(max, func(h, s, l float64) (float64, float64, float64) { return h, clamp01(s * factor), l })
+	}, "factor", factor)
+}
+
+// AdjustLightness appends a lightness change, as done by PPM.AdjustLightness, to the
+// pipeline.
+func (p *Pipeline) AdjustLightness(factor float64) *Pipeline {
+	return p.addPixel("AdjustLightness", func(max uint8) func(v Pixel) Pixel {
+		return hslPixel(max, func(h, s, l float64) (float64, float64, float64) { return h, s, clamp01(l * factor) })
+	}, "factor", factor)
+}
+
+// MapPixels appends a custom per-pixel transform, as done by PPM.MapPixels, to the
+// pipeline. fn must be safe for concurrent use.
+func (p *Pipeline) MapPixels(fn func(p Pixel) Pixel) *Pipeline {
+	return p.addPixel("MapPixels", func(max uint8) func(v Pixel) Pixel {
+		return func(v Pixel) Pixel {
+			v = fn(v)
+			return Pixel{min(v.R, max), min(v.G, max), min(v.B, max)}
+		}
+	})
+}
+
+// Bin appends the binning of factor x factor blocks, as done by PPM.Bin, to the pipeline.
+func (p *Pipeline) Bin(factor int, mode BinMode) *Pipeline {
+	return p.Add("Bin", func(ppm *PPM) error { return ppm.Bin(factor, mode) })
+}
+
+// Resize appends the scaling to width x height pixels, as done by PPM.Resize, to the
+// pipeline.
+func (p *Pipeline) Resize(width, height int) *Pipeline {
+	if err := checkResize(width, height); err != nil {
+		return p.fail("Resize", err)
+	}
+	return p.Add("Resize", func(ppm *PPM) error { return ppm.Resize(width, height) })
+}
+
+// Convolve appends a convolution, as done by PPM.Convolve, to the pipeline.
+func (p *Pipeline) Convolve(kernel [][]float64) *Pipeline {
+	if err := checkKernel(kernel); err != nil {
+		return p.fail("Convolve", err)
+	}
+	return p.Add("Convolve", func(ppm *PPM) error { return ppm.Convolve(kernel) })
+}
+
+// maxBlurRadius bounds the radius, in pixels, of the Gaussian kernels of Blur steps, which
+// are built with the pipeline.
+const maxBlurRadius = 256
+
+// checkBlurSigma validates the standard deviation of a Blur step.
+func checkBlurSigma(sigma float64) error {
+	if !(sigma > 0) {
+		return fmt.Errorf("invalid blur sigma: %v", sigma)
+	}
+	if math.Ceil(3*sigma) > maxBlurRadius {
+		return fmt.Errorf("blur sigma %v exceeds the maximum of %v", sigma, maxBlurRadius/3.0)
+	}
+	return nil
+}
+
+// Blur appends a Gaussian blur of standard deviation sigma, in pixels, to the pipeline.
+// sigma must be positive, and the radius of the kernel, 3·sigma, at most 256 pixels.
+func (p *Pipeline) Blur(sigma float64) *Pipeline {
+	if err := checkBlurSigma(sigma); err != nil {
+		return p.fail("Blur", err)
+	}
+	kernel := kernels.Gaussian(sigma)
+	return p.Add("Blur", func(ppm *PPM) error { return ppm.Convolve(kernel) })
+}
+
+// Flip appends a horizontal flip, as done by PPM.Flip, to the pipeline.
+func (p *Pipeline) Flip() *Pipeline {
+	return p.Add("Flip", func(ppm *PPM) error { ppm.Flip(); return nil })
+}
+
+// Flop appends a vertical flip, as done by PPM.Flop, to the pipeline.
+func (p *Pipeline) Flop() *Pipeline {
+	return p.Add("Flop", func(ppm *PPM) error { ppm.Flop(); return nil })
+}
+
+// Rotate90CW appends a clockwise quarter turn, as done by PPM.Rotate90CW, to the pipeline.
+func (p *Pipeline) Rotate90CW() *Pipeline {
+	return p.Add("Rotate90CW", func(ppm *PPM) error { ppm.Rotate90CW(); return nil })
+}
+
+// pipelineSpec is one entry of a declarative pipeline.
+type pipelineSpec struct {
+	Op     string                 `json:"op" yaml:"op"`
+	Params map[string]interface{} `json:"params" yaml:"params"`
+}
+
+// LoadPipeline parses a declarative pipeline from JSON or YAML. The document is a list of
+// steps, each naming an operation and its parameters:
+//
+//	[
+//	  {"op": "AdjustHue", "params": {"degrees": 30}},
+//	  {"op": "Posterize", "params": {"levels": 4}},
+//	  {"op": "Flip"}
+//	]
+//
+// or, in YAML:
+//
+//	# The same pipeline.
+//	- op: AdjustHue
+//	  params: {degrees: 30}
+//	- op: Posterize
+//	  params:
+//	    levels: 4
+//	- op: Flip
+//
+// Documents starting with '[' are read as JSON, others as YAML. PipelineOperations lists
+// the supported operation names.
+func LoadPipeline(r io.Reader) (*Pipeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pipeline: %v", err)
+	}
+	var specs []pipelineSpec
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&specs); err != nil {
+			return nil, fmt.Errorf("error decoding pipeline: %v", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&specs); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error decoding pipeline: %v", err)
+		}
+		for _, spec := range specs {
+			yamlParams(spec.Params)
+		}
+	}
+
+	p := &Pipeline{}
+	for i, spec := range specs {
+		build, ok := pipelineOperations[spec.Op]
+		if !ok {
+			return nil, fmt.Errorf("step %d: unknown operation %q", i, spec.Op)
+		}
+		params := pipelineParams(spec.Params)
+		apply, err := build(params)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %v", i, spec.Op, err)
+		}
+		if err := params.checkUsed(); err != nil {
+			return nil, fmt.Errorf("step %d (%s): %v", i, spec.Op, err)
+		}
+		p.Add(spec.Op, apply)
+	}
+	return p, nil
+}
+
+// yamlParams converts the integer parameters decoded from YAML to float64, as decoded from
+// JSON, so that both formats are read alike.
+func yamlParams(params map[string]interface{}) {
+	for name, v := range params {
+		if i, ok := v.(int); ok {
+			params[name] = float64(i)
+		}
+	}
+}
+
+// PipelineOperations returns the sorted names of the operations accepted by LoadPipeline.
+func PipelineOperations() []string {
+	names := make([]string, 0, len(pipelineOperations))
+	for name := range pipelineOperations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pipelineParams holds the parameters of a declarative step. Read parameters are
+// removed so that unknown ones can be reported.
+type pipelineParams map[string]interface{}
+
+// number returns a numeric parameter, or def when it is absent.
+func (p pipelineParams) number(name string, def float64) (float64, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	delete(p, name)
+	f, ok := v.(float64)
+	if !ok {
+		return 0, fmt.Errorf("parameter %s must be a number", name)
+	}
+	return f, nil
+}
+
+// integer returns an integer parameter, or def when it is absent.
+func (p pipelineParams) integer(name string, def int) (int, error) {
+	f, err := p.number(name, float64(def))
+	if err != nil {
+		return 0, err
+	}
+	if f != float64(int(f)) {
+		return 0, fmt.Errorf("parameter %s must be an integer", name)
+	}
+	return int(f), nil
+}
+
+// boolean returns a boolean parameter, or def when it is absent.
+func (p pipelineParams) boolean(name string, def bool) (bool, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	delete(p, name)
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("parameter %s must be a boolean", name)
+	}
+	return b, nil
+}
+
+// str returns a string parameter, or def when it is absent.
+func (p pipelineParams) str(name string, def string) (string, error) {
+	v, ok := p[name]
+	if !ok {
+		return def, nil
+	}
+	delete(p, name)
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("parameter %s must be a string", name)
+	}
+	return s, nil
+}
+
+// choice returns a string parameter mapped through choices, or def when it is absent.
+func (p pipelineParams) choice(name string, def int, choices map[string]int) (int, error) {
+	s, err := p.str(name, "")
+	if err != nil || s == "" {
+		return def, err
+	}
+	v, ok := choices[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("invalid value for parameter %s: %q", name, s)
+	}
+	return v, nil
+}
+
+// checkUsed reports parameters that were not read by the operation.
+func (p pipelineParams) checkUsed() error {
+	for name := range p {
+		return fmt.Errorf("unknown parameter %s", name)
+	}
+	return nil
+}
+
+// pipelineOperations maps declarative operation names to constructors reading their parameters.
+var pipelineOperations = map[string]func(p pipelineParams) (func(*PPM) error, error){
+	"Invert":     simpleOperation((*PPM).Invert),
+	"Flip":       simpleOperation((*PPM).Flip),
+	"Flop":       simpleOperation((*PPM).Flop),
+	"Rotate90CW": simpleOperation((*PPM).Rotate90CW),
+	"AdjustHue": func(p pipelineParams) (func(*PPM) error, error) {
+		degrees, err := p.number("degrees", 0)
+		return func(ppm *PPM) error { ppm.AdjustHue(degrees); return nil }, err
+	},
+	"AdjustSaturation": func(p pipelineParams) (func(*PPM) error, error) {
+		factor, err := p.number("factor", 1)
+		return func(ppm *PPM) error { ppm.AdjustSaturation(factor); return nil }, err
+	},
+	"AdjustLightness": func(p pipelineParams) (func(*PPM) error, error) {
+		factor, err := p.number("factor", 1)
+		return func(ppm *PPM) error { ppm.AdjustLightness(factor); return nil }, err
+	},
+	"AutoWhiteBalance": func(p pipelineParams) (func(*PPM) error, error) {
+		method, err := p.choice("method", int(GrayWorld), map[string]int{
+			"grayworld":  int(GrayWorld),
+			"whitepatch": int(WhitePatch),
+		})
+		return func(ppm *PPM) error { ppm.AutoWhiteBalance(WhiteBalanceMethod(method)); return nil }, err
+	},
+	"Temperature": func(p pipelineParams) (func(*PPM) error, error) {
+		shift, err := p.number("kelvinShift", 0)
+		return func(ppm *PPM) error { ppm.Temperature(shift); return nil }, err
+	},
+	"SwapChannels": func(p pipelineParams) (func(*PPM) error, error) {
+		order, err := p.str("order", "RGB")
+		return func(ppm *PPM) error { return ppm.SwapChannels(order) }, err
+	},
+	"Bin": func(p pipelineParams) (func(*PPM) error, error) {
+		factor, err := p.integer("factor", 2)
+		if err != nil {
+			return nil, err
+		}
+		mode, err := p.choice("mode", int(BinAverage), map[string]int{
+			"average":    int(BinAverage),
+			"sum":        int(BinSum),
+			"sumrescale": int(BinSumRescale),
+		})
+		return func(ppm *PPM) error { return ppm.Bin(factor, BinMode(mode)) }, err
+	},
+	"Blur": func(p pipelineParams) (func(*PPM) error, error) {
+		sigma, err := p.number("sigma", 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBlurSigma(sigma); err != nil {
+			return nil, err
+		}
+		kernel := kernels.Gaussian(sigma)
+		return func(ppm *PPM) error { return ppm.Convolve(kernel) }, nil
+	},
+	"Resize": func(p pipelineParams) (func(*PPM) error, error) {
+		width, err := p.integer("width", 0)
+		if err != nil {
+			return nil, err
+		}
+		height, err := p.integer("height", 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkResize(width, height); err != nil {
+			return nil, err
+		}
+		return func(ppm *PPM) error { return ppm.Resize(width, height) }, nil
+	},
+	"Posterize": func(p pipelineParams) (func(*PPM) error, error) {
+		levels, err := p.integer("levels", 4)
+		return func(ppm *PPM) error { return ppm.Posterize(levels) }, err
+	},
+	"ReduceBits": func(p pipelineParams) (func(*PPM) error, error) {
+		bits, err := p.integer("bits", 4)
+		if err != nil {
+			return nil, err
+		}
+		if bits < 0 {
+			return nil, fmt.Errorf("invalid bit depth: %d", bits)
+		}
+		dither, err := p.boolean("dither", false)
+		return func(ppm *PPM) error { return ppm.ReduceBits(uint(bits), dither) }, err
+	},
+	"Quantize": func(p pipelineParams) (func(*PPM) error, error) {
+		colors, err := p.integer("colors", 16)
+		if err != nil {
+			return nil, err
+		}
+		dither, err := p.boolean("dither", false)
+		return func(ppm *PPM) error { _, err := ppm.Quantize(colors, dither); return err }, err
+	},
+}
+
+// simpleOperation adapts a parameterless method to a pipeline operation constructor.
+func simpleOperation(fn func(*PPM)) func(p pipelineParams) (func(*PPM) error, error) {
+	return func(p pipelineParams) (func(*PPM) error, error) {
+		return func(ppm *PPM) error { fn(ppm); return nil }, nil
+	}
+}
@@ -0,0 +1,103 @@
+package Netpbm
+
+import (
+	"image"
+	"math"
+)
+
+// scatterRadius is the radius of the dots drawn by PlotPoints.
+const scatterRadius = 1
+
+// viewport maps data coordinates to the pixels of the clip rectangle of a Canvas, the y
+// axis pointing up.
+type viewport struct {
+	area                   image.Rectangle
+	x0, y0, scaleX, scaleY float64
+}
+
+// newViewport returns the viewport showing xRange and yRange in area.
+func newViewport(area image.Rectangle, xRange, yRange [2]float64) viewport {
+	v := viewport{area: area, x0: xRange[0], y0: yRange[0]}
+	if d := xRange[1] - xRange[0]; d != 0 {
+		v.scaleX = float64(area.Dx()-1) / d
+	}
+	if d := yRange[1] - yRange[0]; d != 0 {
+		v.scaleY = float64(area.Dy()-1) / d
+	}
+	return v
+}
+
+// pixel returns the pixel of the data point (x, y). Points far outside the area are
+// brought closer so that the lines to them keep fitting in an int.
+func (v viewport) pixel(x, y float64) Point {
+	px := float64(v.area.Min.X) + (x-v.x0)*v.scaleX
+	py := float64(v.area.Max.Y-1) - (y-v.y0)*v.scaleY
+	if v.scaleX == 0 {
+		px = float64(v.area.Min.X+v.area.Max.X) / 2
+	}
+	if v.scaleY == 0 {
+		py = float64(v.area.Min.Y+v.area.Max.Y) / 2
+	}
+	span := float64(v.area.Dx() + v.area.Dy())
+	px = math.Max(float64(v.area.Min.X)-span, math.Min(px, float64(v.area.Max.X)+span))
+	py = math.Max(float64(v.area.Min.Y)-span, math.Min(py, float64(v.area.Max.Y)+span))
+	return Point{int(math.Round(px)), int(math.Round(py))}
+}
+
+// PlotFunction draws the graph of f with the specified color, scaling xRange to the width
+// and yRange to the height of the clip rectangle of the canvas, with y values growing
+// upwards. f is sampled once per column; the graph is interrupted where f is not finite.
+func (c *Canvas) PlotFunction(f func(float64) float64, xRange, yRange [2]float64, color Pixel) {
+	if c.clip.Empty() {
+		return
+	}
+	v := newViewport(c.clip, xRange, yRange)
+	saved := c.color
+	c.color = color
+	defer func() { c.color = saved }()
+
+	plotClipped := c.clippedPlotter()
+	var prev Point
+	connected := false
+	for px := c.clip.Min.X; px < c.clip.Max.X; px++ {
+		x := xRange[0]
+		if v.scaleX != 0 {
+			x += float64(px-c.clip.Min.X) / v.scaleX
+		}
+		y := f(x)
+		if math.IsNaN(y) || math.IsInf(y, 0) {
+			connected = false
+			continue
+		}
+		p := Point{px, v.pixel(x, y).Y}
+		if connected {
+			bresenham(prev, p, plotClipped)
+		} else {
+			plotClipped(p.X, p.Y)
+		}
+		prev, connected = p, true
+	}
+}
+
+// PlotPoints draws a dot of the current color for every point, scaling the bounding box of
+// the points to the clip rectangle of the canvas, with y values growing upwards.
+func (c *Canvas) PlotPoints(points []image.Point) {
+	if len(points) == 0 || c.clip.Empty() {
+		return
+	}
+	bounds := image.Rectangle{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		bounds.Min.X, bounds.Max.X = min(bounds.Min.X, p.X), max(bounds.Max.X, p.X)
+		bounds.Min.Y, bounds.Max.Y = min(bounds.Min.Y, p.Y), max(bounds.Max.Y, p.Y)
+	}
+	// Keep the dots inside the clip rectangle.
+	area := c.clip.Inset(scatterRadius)
+	if area.Empty() {
+		area = c.clip
+	}
+	v := newViewport(area, [2]float64{float64(bounds.Min.X), float64(bounds.Max.X)}, [2]float64{float64(bounds.Min.Y), float64(bounds.Max.Y)})
+	plotClipped := c.clippedPlotter()
+	for _, p := range points {
+		filledCircle(v.pixel(float64(p.X), float64(p.Y)), scatterRadius, plotClipped)
+	}
+}
@@ -0,0 +1,43 @@
+package Netpbm
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// scratchSize is the size of the buffers the decoders and encoders reuse across images.
+const scratchSize = 64 << 10
+
+// scanBuffers recycles the token buffers of the decoders.
+var scanBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, scratchSize)
+	return &b
+}}
+
+// writers recycles the buffered writers of the encoders.
+var writers = sync.Pool{New: func() interface{} {
+	return bufio.NewWriterSize(nil, scratchSize)
+}}
+
+// newScanner returns a scanner reading r with a pooled buffer, and the function returning
+// the buffer to the pool once scanning is done.
+func newScanner(r io.Reader) (*bufio.Scanner, func()) {
+	buf := scanBuffers.Get().(*[]byte)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(*buf, bufio.MaxScanTokenSize)
+	return scanner, func() { scanBuffers.Put(buf) }
+}
+
+// getWriter returns a pooled buffered writer to w, to be released with putWriter.
+func getWriter(w io.Writer) *bufio.Writer {
+	writer := writers.Get().(*bufio.Writer)
+	writer.Reset(w)
+	return writer
+}
+
+// putWriter returns a buffered writer to the pool, dropping its destination.
+func putWriter(writer *bufio.Writer) {
+	writer.Reset(nil)
+	writers.Put(writer)
+}
@@ -0,0 +1,193 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// quantizeLevel snaps v to the nearest of levels evenly spaced values between 0 and max.
+func quantizeLevel(v float64, levels int, max float64) float64 {
+	step := max / float64(levels-1)
+	q := math.Round(v/step) * step
+	return math.Max(0, math.Min(max, q))
+}
+
+// posterizePlane quantizes a plane of samples to the given number of levels, optionally
+// spreading the quantization error to the neighbors with Floyd–Steinberg dithering.
+func posterizePlane(width, height int, levels int, max uint8, dither bool, get func(x, y int) uint8, set func(x, y int, v uint8)) {
+	if !dither {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				set(x, y, uint8(quantizeLevel(float64(get(x, y)), levels, float64(max))))
+			}
+		}
+		return
+	}
+
+	// Error accumulated for the current and the next row.
+	current := make([]float64, width+2)
+	next := make([]float64, width+2)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := float64(get(x, y)) + current[x+1]
+			q := quantizeLevel(v, levels, float64(max))
+			set(x, y, uint8(q))
+			e := v - q
+			current[x+2] += e * 7 / 16
+			next[x] += e * 3 / 16
+			next[x+1] += e * 5 / 16
+			next[x+2] += e * 1 / 16
+		}
+		current, next = next, current
+		for i := range next {
+			next[i] = 0
+		}
+	}
+}
+
+// checkLevels validates a number of quantization levels.
+func checkLevels(levels int) error {
+	if levels < 2 || levels > 256 {
+		return fmt.Errorf("invalid number of levels: %d", levels)
+	}
+	return nil
+}
+
+// bitsToLevels converts a bit depth to a number of quantization levels.
+func bitsToLevels(bits uint) (int, error) {
+	if bits < 1 || bits > 8 {
+		return 0, fmt.Errorf("invalid bit depth: %d", bits)
+	}
+	return 1 << bits, nil
+}
+
+// Posterize reduces the PGM image to the given number of evenly spaced gray levels.
+func (pgm *PGM) Posterize(levels int) error {
+	if err := checkLevels(levels); err != nil {
+		return err
+	}
+	pgm.record("Posterize", "levels", levels)
+	pgm.posterize(levels, false)
+	return nil
+}
+
+// ReduceBits reduces the PGM image to the given bit depth while keeping its maximum value,
+// optionally applying Floyd–Steinberg dithering to hide banding.
+func (pgm *PGM) ReduceBits(bits uint, dither bool) error {
+	levels, err := bitsToLevels(bits)
+	if err != nil {
+		return err
+	}
+	pgm.record("ReduceBits", "bits", bits, "dither", dither)
+	pgm.posterize(levels, dither)
+	return nil
+}
+
+// posterize quantizes the PGM image to the given number of levels.
+func (pgm *PGM) posterize(levels int, dither bool) {
+	max := uint8(255)
+	if pgm.max < 255 {
+		max = uint8(pgm.max)
+	}
+	posterizePlane(pgm.width, pgm.height, levels, max, dither,
+		func(x, y int) uint8 { return pgm.data[y*pgm.width+x] },
+		func(x, y int, v uint8) { pgm.data[y*pgm.width+x] = v })
+}
+
+// Posterize reduces every channel of the PPM image to the given number of evenly spaced levels.
+func (ppm *PPM) Posterize(levels int) error {
+	if err := checkLevels(levels); err != nil {
+		return err
+	}
+	ppm.record("Posterize", "levels", levels)
+	ppm.posterize(levels, false)
+	return nil
+}
+
+// ReduceBits reduces every channel of the PPM image to the given bit depth while keeping its
+// maximum value, optionally applying Floyd–Steinberg dithering to hide banding.
+func (ppm *PPM) ReduceBits(bits uint, dither bool) error {
+	levels, err := bitsToLevels(bits)
+	if err != nil {
+		return err
+	}
+	ppm.record("ReduceBits", "bits", bits, "dither", dither)
+	ppm.posterize(levels, dither)
+	return nil
+}
+
+// EstimateBandingRisk predicts how visible banding would be if the PGM image were reduced
+// to the given number of levels without dithering. Among the places where neighboring
+// pixels would end up on different levels, it measures the fraction where the original
+// difference is less than half a quantization step: such jumps are false contours
+// created by quantizing smooth gradients. The result ranges from 0 (every level change
+// follows a real edge) to 1 (every level change is a band boundary); values above about
+// 0.5 suggest adding dithering or more levels.
+func (pgm *PGM) EstimateBandingRisk(levels int) (float64, error) {
+	if err := checkLevels(levels); err != nil {
+		return 0, err
+	}
+	max := 255.0
+	if pgm.max < 255 {
+		max = float64(pgm.max)
+	}
+	step := max / float64(levels-1)
+
+	quantized := make([][]float64, pgm.height)
+	for y := range quantized {
+		quantized[y] = make([]float64, pgm.width)
+		for x := range quantized[y] {
+			quantized[y][x] = quantizeLevel(float64(pgm.data[y*pgm.width+x]), levels, max)
+		}
+	}
+
+	var changes, falseContours int
+	compare := func(x1, y1, x2, y2 int) {
+		if quantized[y1][x1] == quantized[y2][x2] {
+			return
+		}
+		changes++
+		d := float64(pgm.data[y1*pgm.width+x1]) - float64(pgm.data[y2*pgm.width+x2])
+		if d < 0 {
+			d = -d
+		}
+		if d < step/2 {
+			falseContours++
+		}
+	}
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			if x+1 < pgm.width {
+				compare(x, y, x+1, y)
+			}
+			if y+1 < pgm.height {
+				compare(x, y, x, y+1)
+			}
+		}
+	}
+	if changes == 0 {
+		return 0, nil
+	}
+	return float64(falseContours) / float64(changes), nil
+}
+
+// EstimateBandingRisk predicts how visible banding would be if the PPM image were
+// posterized, quantized or reduced to the given number of levels per channel without
+// dithering. It is computed on the luminance of the image, see PGM.EstimateBandingRisk.
+func (ppm *PPM) EstimateBandingRisk(levels int) (float64, error) {
+	return ppm.ToPGM().EstimateBandingRisk(levels)
+}
+
+// posterize quantizes every channel of the PPM image to the given number of levels.
+func (ppm *PPM) posterize(levels int, dither bool) {
+	ppm.own()
+	posterizePlane(ppm.width, ppm.height, levels, ppm.max, dither,
+		func(x, y int) uint8 { return ppm.data[y*ppm.width+x].R },
+		func(x, y int, v uint8) { ppm.data[y*ppm.width+x].R = v })
+	posterizePlane(ppm.width, ppm.height, levels, ppm.max, dither,
+		func(x, y int) uint8 { return ppm.data[y*ppm.width+x].G },
+		func(x, y int, v uint8) { ppm.data[y*ppm.width+x].G = v })
+	posterizePlane(ppm.width, ppm.height, levels, ppm.max, dither,
+		func(x, y int) uint8 { return ppm.data[y*ppm.width+x].B },
+		func(x, y int, v uint8) { ppm.data[y*ppm.width+x].B = v })
+}
@@ -3,18 +3,30 @@ package Netpbm
 import (
 	"bufio"
 	"fmt"
-	"math"
+	"image"
+	"io"
 	"os"
-	"sort"
 	"strconv"
+	"unsafe"
 )
 
 // PPM structure represents a Portable Pixmap image
 type PPM struct {
-	data          [][]Pixel
+	data          []Pixel // Pixels of the image, row after row
 	width, height int
 	magicNumber   string
 	max           uint8
+	history
+	// shared is set while data is also referenced by a snapshot, copied before being written.
+	shared bool
+	// clip restricts drawing to a rectangle when set, see SetClip.
+	clip *image.Rectangle
+	// paint overrides the color of the drawn pixels while filling with a Paint.
+	paint Paint
+	// mode combines the drawn color with the pixels, see SetDrawMode.
+	mode DrawMode
+	// bounds handles the drawn pixels outside the image, see SetBoundsPolicy.
+	bounds BoundsPolicy
 }
 
 // Pixel structure represents a single pixel with RGB values
@@ -35,60 +47,134 @@ func ReadPPM(filename string) (*PPM, error) {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanWords)
+	return DecodePPM(file)
+}
+
+// DecodePPM reads a PPM image from r
+func DecodePPM(r io.Reader) (*PPM, error) {
+	return decodePPM(r, nil)
+}
 
-	ppm := &PPM{}
+// DecodePPMInto reads a PPM image from r into dst, which must have the same dimensions,
+// reusing its pixel buffer instead of allocating a new one. Batch pipelines decoding many
+// frames of the same size can thus keep a single image.
+func DecodePPMInto(r io.Reader, dst *PPM) error {
+	_, err := decodePPM(r, dst)
+	return err
+}
+
+// decodePPM reads a PPM image from r into dst, or into a new image if dst is nil.
+func decodePPM(r io.Reader, dst *PPM) (*PPM, error) {
+	reader := bufio.NewReader(r)
+	if magic, err := reader.Peek(2); err == nil && string(magic) == "P6" {
+		return decodeRawPPM(reader, dst)
+	}
+	scanner, release := newScanner(reader)
+	defer release()
+	scanner.Split(bufio.ScanWords)
 
 	// Read the magic number
 	scanner.Scan()
-	ppm.magicNumber = scanner.Text()
+	magicNumber := scanner.Text()
 
 	// Read width and height
 	scanner.Scan()
-	ppm.width, _ = strconv.Atoi(scanner.Text())
+	width, _ := strconv.Atoi(scanner.Text())
 	scanner.Scan()
-	ppm.height, _ = strconv.Atoi(scanner.Text())
+	height, _ := strconv.Atoi(scanner.Text())
 
 	// Read the maximum pixel value
 	scanner.Scan()
 	maxValue, _ := strconv.Atoi(scanner.Text())
-	ppm.max = uint8(maxValue)
 
-	// Initialize the data slice
-	ppm.data = make([][]Pixel, ppm.height)
-	for i := range ppm.data {
-		ppm.data[i] = make([]Pixel, ppm.width)
+	ppm, err := decodeTarget(dst, width, height)
+	if err != nil {
+		return nil, err
 	}
+	ppm.magicNumber = magicNumber
+	ppm.max = uint8(maxValue)
 
 	// Read pixel values
 	for i := 0; i < ppm.height; i++ {
 		for j := 0; j < ppm.width; j++ {
 			scanner.Scan()
-			ppm.data[i][j].R, _ = strconv.ParseUint(scanner.Text(), 10, 8)
+			r, _ := strconv.ParseUint(scanner.Text(), 10, 8)
 			scanner.Scan()
-			ppm.data[i][j].G, _ = strconv.ParseUint(scanner.Text(), 10, 8)
+			g, _ := strconv.ParseUint(scanner.Text(), 10, 8)
 			scanner.Scan()
-			ppm.data[i][j].B, _ = strconv.ParseUint(scanner.Text(), 10, 8)
+			b, _ := strconv.ParseUint(scanner.Text(), 10, 8)
+			ppm.data[i*ppm.width+j] = Pixel{uint8(r), uint8(g), uint8(b)}
 		}
 	}
 
 	return ppm, nil
 }
 
+// decodeRawPPM reads a P6 image, whose samples follow the header as one byte each, into
+// dst, or into a new image if dst is nil.
+func decodeRawPPM(reader *bufio.Reader, dst *PPM) (*PPM, error) {
+	h, err := readHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if h.Max > 255 {
+		return nil, fmt.Errorf("unsupported max value: %d", h.Max)
+	}
+	ppm, err := decodeTarget(dst, h.Width, h.Height)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(reader, pixelBytes(ppm.data)); err != nil {
+		return nil, fmt.Errorf("error reading pixel data: %v", err)
+	}
+	ppm.magicNumber = h.MagicNumber
+	ppm.max = uint8(h.Max)
+	return ppm, nil
+}
+
+// decodeTarget returns the image a decoded width x height image is stored in: dst, whose
+// pixels are about to be overwritten, or a new image if dst is nil.
+func decodeTarget(dst *PPM, width, height int) (*PPM, error) {
+	if dst == nil {
+		return &PPM{width: width, height: height, data: make([]Pixel, width*height)}, nil
+	}
+	if width != dst.width || height != dst.height {
+		return nil, fmt.Errorf("image size %dx%d does not match destination size %dx%d", width, height, dst.width, dst.height)
+	}
+	if dst.shared {
+		// The pixels are all overwritten, there is no need to copy them.
+		dst.data, dst.shared = make([]Pixel, width*height), false
+	}
+	return dst, nil
+}
+
+// pixelBytes returns the memory of pixels as bytes, laid out as the samples of P6 files
+// since Pixel is three bytes without padding.
+func pixelBytes(pixels []Pixel) []byte {
+	if len(pixels) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&pixels[0])), 3*len(pixels))
+}
+
 // Size returns the width and height of the PPM image
 func (ppm *PPM) Size() (int, int) {
 	return ppm.width, ppm.height
 }
 
-// At returns the pixel value at the specified coordinates (x, y)
+// At returns the pixel value at the specified coordinates (x, y), panicking if they lie
+// outside the image; see AtOK
 func (ppm *PPM) At(x, y int) Pixel {
-	return ppm.data[y][x]
+	checkPixel(x, y, ppm.width, ppm.height)
+	return ppm.data[y*ppm.width+x]
 }
 
-// Set updates the pixel value at the specified coordinates (x, y)
+// Set updates the pixel value at the specified coordinates (x, y), panicking if they lie
+// outside the image; see SetClamped
 func (ppm *PPM) Set(x, y int, value Pixel) {
-	ppm.data[y][x] = value
+	checkPixel(x, y, ppm.width, ppm.height)
+	ppm.own()
+	ppm.data[y*ppm.width+x] = value
 }
 
 // Save writes the PPM image to the specified file
@@ -99,48 +185,80 @@ func (ppm *PPM) Save(filename string) error {
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	return ppm.Encode(file)
+}
+
+// Encode writes the PPM image to w
+func (ppm *PPM) Encode(w io.Writer) error {
+	writer := getWriter(w)
+	defer putWriter(writer)
 
 	// Write magic number, width, height, and maximum pixel value
 	fmt.Fprintf(writer, "%s\n%d %d\n%d\n", ppm.magicNumber, ppm.width, ppm.height, ppm.max)
 
+	if ppm.magicNumber == "P6" {
+		// Write format P6 (binary), one byte per sample
+		if _, err := writer.Write(pixelBytes(ppm.data)); err != nil {
+			return fmt.Errorf("error writing binary data: %v", err)
+		}
+		return writer.Flush()
+	}
+
 	// Write pixel values
 	for i := 0; i < ppm.height; i++ {
-		for j := 0; j < ppm.width; j++ {
-			fmt.Fprintf(writer, "%d %d %d ", ppm.data[i][j].R, ppm.data[i][j].G, ppm.data[i][j].B)
+		for _, p := range ppm.row(i) {
+			fmt.Fprintf(writer, "%d %d %d ", p.R, p.G, p.B)
 		}
 		fmt.Fprintln(writer)
 	}
 
-	return nil
+	return writer.Flush()
 }
 
 // Invert inverts the colors of the PPM image
 func (ppm *PPM) Invert() {
-	for i := 0; i < ppm.height; i++ {
-		for j := 0; j < ppm.width; j++ {
-			ppm.data[i][j].R = ppm.max - ppm.data[i][j].R
-			ppm.data[i][j].G = ppm.max - ppm.data[i][j].G
-			ppm.data[i][j].B = ppm.max - ppm.data[i][j].B
+	ppm.record("Invert")
+	ppm.own()
+	parallelRows(ppm.height, func(i int) {
+		row := ppm.row(i)
+		for j := range row {
+			row[j] = Pixel{ppm.max - row[j].R, ppm.max - row[j].G, ppm.max - row[j].B}
 		}
-	}
+	})
 }
 
 // Flip flips the PPM image horizontally
 func (ppm *PPM) Flip() {
-	for i := 0; i < ppm.height; i++ {
+	ppm.record("Flip")
+	ppm.own()
+	parallelRows(ppm.height, func(i int) {
+		row := ppm.row(i)
 		for j := 0; j < ppm.width/2; j++ {
-			ppm.data[i][j], ppm.data[i][ppm.width-1-j] = ppm.data[i][ppm.width-1-j], ppm.data[i][j]
+			row[j], row[ppm.width-1-j] = row[ppm.width-1-j], row[j]
 		}
-	}
+	})
 }
 
 // Flop flips the PPM image vertically
 func (ppm *PPM) Flop() {
-	for i := 0; i < ppm.height/2; i++ {
-		ppm.data[i], ppm.data[ppm.height-1-i] = ppm.data[ppm.height-1-i], ppm.data[i]
-	}
+	ppm.record("Flop")
+	ppm.own()
+	parallelRows(ppm.height/2, func(i int) {
+		top, bottom := ppm.row(i), ppm.row(ppm.height-1-i)
+		for j := range top {
+			top[j], bottom[j] = bottom[j], top[j]
+		}
+	})
+}
+
+// MagicNumber returns the magic number of the PPM image, "P3" or "P6"
+func (ppm *PPM) MagicNumber() string {
+	return ppm.magicNumber
+}
+
+// MaxValue returns the maximum pixel value of the PPM image
+func (ppm *PPM) MaxValue() uint8 {
+	return ppm.max
 }
 
 // SetMagicNumber sets the magic number of the PPM image
@@ -155,34 +273,24 @@ func (ppm *PPM) SetMaxValue(maxValue uint8) {
 
 // Rotate90CW rotates the PPM image 90 degrees clockwise
 func (ppm *PPM) Rotate90CW() {
-	newData := make([][]Pixel, ppm.width)
-	for i := range newData {
-		newData[i] = make([]Pixel, ppm.height)
-	}
+	ppm.record("Rotate90CW")
+	newData := make([]Pixel, len(ppm.data))
 	for i := 0; i < ppm.height; i++ {
 		for j := 0; j < ppm.width; j++ {
-			newData[j][ppm.height-1-i] = ppm.data[i][j]
+			newData[j*ppm.height+ppm.height-1-i] = ppm.data[i*ppm.width+j]
 		}
 	}
 	ppm.width, ppm.height = ppm.height, ppm.width
-	ppm.data = newData
+	// The rotated pixels are a new copy, no longer shared with snapshots.
+	ppm.data, ppm.shared = newData, false
 }
 
-// ToPGM converts the PPM image to a PGM image (grayscale)
+// ToPGM converts the PPM image to a PGM image (grayscale) using the Rec. 601 luminosity formula.
+// Unlike ToPGMWith, which rounds, the gray levels are truncated.
 func (ppm *PPM) ToPGM() *PGM {
-	pgm := &PGM{
-		width:       ppm.width,
-		height:      ppm.height,
-		magicNumber: "P2",
-		max:         ppm.max,
-		data:        make([][]uint8, ppm.height),
-	}
-	for i := range pgm.data {
-		pgm.data[i] = make([]uint8, ppm.width)
-		for j := 0; j < ppm.width; j++ {
-			// Convert RGB to grayscale using the luminosity formula
-			pgm.data[i][j] = uint8(0.299*float64(ppm.data[i][j].R) + 0.587*float64(ppm.data[i][j].G) + 0.114*float64(ppm.data[i][j].B))
-		}
+	pgm := newPGM(ppm.width, ppm.height, "P2", uint(ppm.max))
+	for i, p := range ppm.data {
+		pgm.data[i] = uint8(Rec601Luma(p))
 	}
 	return pgm
 }
@@ -193,180 +301,212 @@ func (ppm *PPM) ToPBM() *PBM {
 		width:       ppm.width,
 		height:      ppm.height,
 		magicNumber: "P1",
-		data:        make([][]bool, ppm.height),
+		data:        make([]bool, len(ppm.data)),
 	}
-	for i := range pbm.data {
-		pbm.data[i] = make([]bool, ppm.width)
-		for j := 0; j < ppm.width; j++ {
-			// Convert RGB to binary using a simple threshold (128)
-			grayValue := 0.299*float64(ppm.data[i][j].R) + 0.587*float64(ppm.data[i][j].G) + 0.114*float64(ppm.data[i][j].B)
-			pbm.data[i][j] = grayValue > 128
-		}
+	for i, p := range ppm.data {
+		// Convert RGB to binary using a simple threshold (128)
+		grayValue := 0.299*float64(p.R) + 0.587*float64(p.G) + 0.114*float64(p.B)
+		pbm.data[i] = grayValue > 128
 	}
 	return pbm
 }
 
 // DrawLine draws a line on the PPM image between two points with the specified color
 func (ppm *PPM) DrawLine(p1, p2 Point, color Pixel) {
-	// Implement the DrawLine function here
-	deltaX := p2.X - p1.X
-	deltaY := p2.Y - p1.Y
-	steps := int(math.Max(math.Abs(float64(deltaX)), math.Abs(float64(deltaY))))
-	xIncrement := float64(deltaX) / float64(steps)
-	yIncrement := float64(deltaY) / float64(steps)
-	x := float64(p1.X)
-	y := float64(p1.Y)
-	for i := 0; i <= steps; i++ {
-		ppm.Set(int(x), int(y), color)
-		x += xIncrement
-		y += yIncrement
-	}
+	bresenham(p1, p2, ppm.plotter(color))
 }
 
-// DrawRectangle draws a rectangle on the PPM image with the specified color
-func (ppm *PPM) DrawRectangle(p1 Point, width, height int, color Pixel) {
-	p2 := Point{p1.X + width, p1.Y}
-	p3 := Point{p1.X + width, p1.Y + height}
-	p4 := Point{p1.X, p1.Y + height}
-	ppm.DrawLine(p1, p2, color)
-	ppm.DrawLine(p2, p3, color)
-	ppm.DrawLine(p3, p4, color)
-	ppm.DrawLine(p4, p1, color)
+// DrawThickLine draws a line of the given thickness, in pixels, on the PPM image between
+// two points with the specified color. Ends are rounded.
+func (ppm *PPM) DrawThickLine(p1, p2 Point, thickness int, color Pixel) {
+	thickLine(p1, p2, thickness, ppm.plotter(color))
 }
 
-// DrawFilledRectangle draws a filled rectangle on the PPM image with the specified color
-func (ppm *PPM) DrawFilledRectangle(p1 Point, width, height int, color Pixel) {
-	for i := 0; i < height; i++ {
-		for j := 0; j < width; j++ {
-			ppm.Set(p1.X+j, p1.Y+i, color)
+// bresenham calls plot for every pixel of the line between p1 and p2, both included, using
+// integer arithmetic only. A line whose ends are the same point plots that point.
+func bresenham(p1, p2 Point, plot func(x, y int)) {
+	dx, dy := p2.X-p1.X, p2.Y-p1.Y
+	sx, sy := 1, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+	err := dx - dy
+	x, y := p1.X, p1.Y
+	for {
+		plot(x, y)
+		if x == p2.X && y == p2.Y {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x += sx
+		}
+		if e2 < dx {
+			err += dx
+			y += sy
 		}
 	}
 }
 
-// DrawCircle draws a circle on the PPM image with the specified color
-func (ppm *PPM) DrawCircle(center Point, radius int, color Pixel) {
-	for x := -radius; x <= radius; x++ {
-		for y := -radius; y <= radius; y++ {
-			if x*x+y*y <= radius*radius {
-				ppm.Set(center.X+x, center.Y+y, color)
+// discOffsets returns the offsets of the pixels of a disc of the given radius, used as a
+// brush for thick strokes. The disc of a thickness t covers t pixels across.
+func discOffsets(radius float64) []Point {
+	var offsets []Point
+	r := int(radius)
+	// Even thicknesses are centered between pixels, leaning to the top left.
+	c := 0.0
+	if int(2*radius)%2 == 0 {
+		c = 0.5
+	}
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			fx, fy := float64(dx)+c, float64(dy)+c
+			if fx*fx+fy*fy <= radius*radius {
+				offsets = append(offsets, Point{dx, dy})
 			}
 		}
 	}
+	return offsets
 }
 
-// DrawFilledCircle draws a filled circle on the PPM image with the specified color
-func (ppm *PPM) DrawFilledCircle(center Point, radius int, color Pixel) {
-	for x := -radius; x <= radius; x++ {
-		for y := -radius; y <= radius; y++ {
-			if x*x+y*y <= radius*radius {
-				ppm.Set(center.X+x, center.Y+y, color)
-			}
-		}
+// plot sets a pixel drawn by the drawing functions, handling pixels outside the image as
+// told by the bounds policy and ignoring those outside the clip rectangle.
+func (ppm *PPM) plot(x, y int, color Pixel) {
+	x, y, ok := ppm.bounds.resolve(x, y, ppm.width, ppm.height)
+	if !ok || !ppm.visible(x, y) {
+		return
 	}
+	if ppm.paint != nil {
+		color = ppm.paint.At(x, y)
+	}
+	ppm.Set(x, y, ppm.mode.apply(ppm.data[y*ppm.width+x], color, ppm.max))
 }
 
-// DrawTriangle draws a triangle on the PPM image with the specified color
-func (ppm *PPM) DrawTriangle(p1, p2, p3 Point, color Pixel) {
-	ppm.DrawLine(p1, p2, color)
-	ppm.DrawLine(p2, p3, color)
-	ppm.DrawLine(p3, p1, color)
+// drawPass plots the pixels of one drawing call on a PPM image. In the XOR and invert
+// modes, a pixel covered several times by the call, such as the crossings of the lines of
+// a grid, is only modified once. Methods drawing several shapes share a single pass.
+type drawPass struct {
+	ppm *PPM
+	// done holds the pixels already modified, in the XOR and invert modes only.
+	done map[Point]bool
 }
 
-// DrawFilledTriangle draws a filled triangle on the PPM image with the specified color
-func (ppm *PPM) DrawFilledTriangle(p1, p2, p3 Point, color Pixel) {
-	vertices := []Point{p1, p2, p3}
-	sort.Slice(vertices, func(i, j int) bool {
-		return vertices[i].Y < vertices[j].Y
-	})
-	slope1 := float64(vertices[1].X-vertices[0].X) / float64(vertices[1].Y-vertices[0].Y)
-	slope2 := float64(vertices[2].X-vertices[0].X) / float64(vertices[2].Y-vertices[0].Y)
-	x1 := float64(vertices[0].X)
-	x2 := float64(vertices[0].X)
-	for y := vertices[0].Y; y <= vertices[1].Y; y++ {
-		for x := int(math.Min(x1, x2)); x <= int(math.Max(x1, x2)); x++ {
-			ppm.Set(x, y, color)
-		}
-		x1 += slope1
-		x2 += slope2
+// pass starts a drawing call on the PPM image.
+func (ppm *PPM) pass() *drawPass {
+	d := &drawPass{ppm: ppm}
+	if ppm.mode != DrawCopy {
+		d.done = make(map[Point]bool)
 	}
-	slope3 := float64(vertices[2].X-vertices[1].X) / float64(vertices[2].Y-vertices[1].Y)
-	x1 = float64(vertices[1].X)
-	for y := vertices[1].Y + 1; y <= vertices[2].Y; y++ {
-		for x := int(math.Min(x1, x2)); x <= int(math.Max(x1, x2)); x++ {
-			ppm.Set(x, y, color)
+	return d
+}
+
+// plot plots the pixel (x, y) with color, unless the call already modified it.
+func (d *drawPass) plot(x, y int, color Pixel) {
+	if d.done != nil {
+		// Pixels are told apart after the bounds policy maps them into the image.
+		rx, ry, ok := d.ppm.bounds.resolve(x, y, d.ppm.width, d.ppm.height)
+		p := Point{rx, ry}
+		if !ok || d.done[p] {
+			return
 		}
-		x1 += slope3
-		x2 += slope2
+		d.done[p] = true
 	}
+	d.ppm.plot(x, y, color)
 }
 
-// DrawPolygon draws a polygon on the PPM image with the specified color
-func (ppm *PPM) DrawPolygon(points []Point, color Pixel) {
-	for i := 0; i < len(points); i++ {
-		p1 := points[i]
-		p2 := points[(i+1)%len(points)]
-		ppm.DrawLine(p1, p2, color)
-	}
+// plotter returns the function plotting pixels of the given color in the pass for the
+// drawing core.
+func (d *drawPass) plotter(color Pixel) func(x, y int) {
+	return func(x, y int) { d.plot(x, y, color) }
 }
 
-// DrawFilledPolygon draws a filled polygon on the PPM image with the specified color
-func (ppm *PPM) DrawFilledPolygon(points []Point, color Pixel) {
-	// Find the bounding edges of the polygon
-	minX, minY := points[0].X, points[0].Y
-	maxX, maxY := points[0].X, points[0].Y
-	for _, p := range points {
-		if p.X < minX {
-			minX = p.X
-		}
-		if p.X > maxX {
-			maxX = p.X
-		}
-		if p.Y < minY {
-			minY = p.Y
-		}
-		if p.Y > maxY {
-			maxY = p.Y
-		}
-	}
+// plotter returns the function plotting pixels of the given color for the drawing core,
+// in a new pass: a pixel covered several times by the shape is only modified once.
+func (ppm *PPM) plotter(color Pixel) func(x, y int) {
+	return ppm.pass().plotter(color)
+}
 
-	// Create an array to store intersections per row
-	intersections := make([]int, maxY-minY+1)
-	// Iterate over each edge of the polygon
-	for i := 0; i < len(points); i++ {
-		p1 := points[i]
-		p2 := points[(i+1)%len(points)]
+// DrawRectangle draws a rectangle on the PPM image with the specified color
+func (ppm *PPM) DrawRectangle(p1 Point, width, height int, color Pixel) {
+	rectangle(p1, width, height, ppm.plotter(color))
+}
 
-		// Find the minimum and maximum y-coordinates of the edge
-		yMin := int(math.Min(float64(p1.Y), float64(p2.Y)))
-		yMax := int(math.Max(float64(p1.Y), float64(p2.Y)))
+// DrawFilledRectangle draws a filled rectangle on the PPM image with the specified color
+func (ppm *PPM) DrawFilledRectangle(p1 Point, width, height int, color Pixel) {
+	filledRectangle(p1, width, height, ppm.plotter(color))
+}
 
-		// Skip horizontal edges
-		if yMin == yMax {
-			continue
-		}
+// DrawCircle draws the outline of a circle on the PPM image with the specified color, using
+// the midpoint circle algorithm
+func (ppm *PPM) DrawCircle(center Point, radius int, color Pixel) {
+	circle(center, radius, ppm.plotter(color))
+}
 
-		// Iterate over each row the edge crosses and update intersections
-		for y := yMin; y <= yMax; y++ {
-			// Calculate x-coordinate of the intersection
-			xIntersection := int(float64(p1.X) + float64(y-yMin)*(float64(p2.X)-float64(p1.X))/(float64(p2.Y)-float64(p1.Y)))
+// DrawThickCircle draws the outline of a circle of the given line width, in pixels, on the
+// PPM image with the specified color. The line is centered on the circumference.
+func (ppm *PPM) DrawThickCircle(center Point, radius, width int, color Pixel) {
+	thickCircle(center, radius, width, ppm.plotter(color))
+}
 
-			// Increment the intersection count for the current row
-			intersections[y-minY] = xIntersection
-		}
+// midpointCircle calls plot with the offsets from the center of every pixel of the
+// circumference of a circle, computed with integer arithmetic in one octant and mirrored
+// to the others. Every pixel is plotted once.
+func midpointCircle(radius int, plot func(dx, dy int)) {
+	if radius <= 0 {
+		plot(0, 0)
+		return
 	}
-
-	// Fill the polygon by connecting intersections on each row
-	for y := 0; y <= maxY-minY; y++ {
-		// Skip rows with no intersections
-		if intersections[y] == 0 {
-			continue
+	x, y := radius, 0
+	err := 1 - radius
+	for x >= y {
+		points := [8]Point{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}}
+	next:
+		for i, p := range points {
+			// Skip the points repeated on the axes and the diagonals.
+			for _, q := range points[:i] {
+				if p == q {
+					continue next
+				}
+			}
+			plot(p.X, p.Y)
 		}
-
-		// Connect intersections on the current row
-		for x := intersections[y]; x <= maxX-minX; x++ {
-			ppm.Set(x+minX, y+minY, color)
+		y++
+		if err < 0 {
+			err += 2*y + 1
+		} else {
+			x--
+			err += 2*(y-x) + 1
 		}
 	}
+}
 
-	return ppm
+// DrawFilledCircle draws a filled circle on the PPM image with the specified color
+func (ppm *PPM) DrawFilledCircle(center Point, radius int, color Pixel) {
+	filledCircle(center, radius, ppm.plotter(color))
+}
+
+// DrawTriangle draws a triangle on the PPM image with the specified color
+func (ppm *PPM) DrawTriangle(p1, p2, p3 Point, color Pixel) {
+	polygon([]Point{p1, p2, p3}, ppm.plotter(color))
+}
+
+// DrawFilledTriangle draws a filled triangle on the PPM image with the specified color
+func (ppm *PPM) DrawFilledTriangle(p1, p2, p3 Point, color Pixel) {
+	filledPolygon([]Point{p1, p2, p3}, ppm.plotter(color))
+}
+
+// DrawPolygon draws a polygon on the PPM image with the specified color
+func (ppm *PPM) DrawPolygon(points []Point, color Pixel) {
+	polygon(points, ppm.plotter(color))
+}
+
+// DrawFilledPolygon draws a filled polygon on the PPM image with the specified color. The
+// interior is filled with the even–odd rule, so concave and self-intersecting polygons are
+// supported, and the fill covers the outline drawn by DrawPolygon.
+func (ppm *PPM) DrawFilledPolygon(points []Point, color Pixel) {
+	filledPolygon(points, ppm.plotter(color))
 }
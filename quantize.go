@@ -0,0 +1,322 @@
+package Netpbm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// colorCount is a distinct color of an image with its number of occurrences.
+type colorCount struct {
+	color Pixel
+	count int
+}
+
+// colorBox is a group of colors handled by the median cut algorithm.
+type colorBox []colorCount
+
+// channelRange returns the channel with the widest spread of values in the box and that spread.
+func (box colorBox) channelRange() (Channel, int) {
+	lo := [3]int{255, 255, 255}
+	hi := [3]int{0, 0, 0}
+	for _, c := range box {
+		v := [3]int{int(c.color.R), int(c.color.G), int(c.color.B)}
+		for i := range v {
+			if v[i] < lo[i] {
+				lo[i] = v[i]
+			}
+			if v[i] > hi[i] {
+				hi[i] = v[i]
+			}
+		}
+	}
+	widest := RedChannel
+	for c := GreenChannel; c <= BlueChannel; c++ {
+		if hi[c]-lo[c] > hi[widest]-lo[widest] {
+			widest = c
+		}
+	}
+	return widest, hi[widest] - lo[widest]
+}
+
+// split divides the box at the weighted median of its widest channel.
+func (box colorBox) split() (colorBox, colorBox) {
+	channel, _ := box.channelRange()
+	sort.Slice(box, func(i, j int) bool {
+		return channel.get(box[i].color) < channel.get(box[j].color)
+	})
+	total := 0
+	for _, c := range box {
+		total += c.count
+	}
+	seen := 0
+	for i, c := range box {
+		seen += c.count
+		if seen*2 >= total && i+1 < len(box) {
+			return box[:i+1], box[i+1:]
+		}
+	}
+	return box[:len(box)-1], box[len(box)-1:]
+}
+
+// average returns the mean color of the box weighted by occurrences.
+func (box colorBox) average() Pixel {
+	var r, g, b, n int
+	for _, c := range box {
+		r += int(c.color.R) * c.count
+		g += int(c.color.G) * c.count
+		b += int(c.color.B) * c.count
+		n += c.count
+	}
+	if n == 0 {
+		return Pixel{}
+	}
+	return Pixel{uint8((r + n/2) / n), uint8((g + n/2) / n), uint8((b + n/2) / n)}
+}
+
+// colorCounts returns the distinct colors of the PPM image with their occurrences.
+func (ppm *PPM) colorCounts() []colorCount {
+	counts := ppm.ColorHistogram()
+	colors := make([]colorCount, 0, len(counts))
+	for color, count := range counts {
+		colors = append(colors, colorCount{color, count})
+	}
+	// Sort for a deterministic result independent of map iteration order.
+	sort.Slice(colors, func(i, j int) bool {
+		a, b := colors[i].color, colors[j].color
+		if a.R != b.R {
+			return a.R < b.R
+		}
+		if a.G != b.G {
+			return a.G < b.G
+		}
+		return a.B < b.B
+	})
+	return colors
+}
+
+// medianCut builds a palette of at most numColors colors representative of the image.
+func (ppm *PPM) medianCut(numColors int) []Pixel {
+	boxes := []colorBox{colorBox(ppm.colorCounts())}
+	for len(boxes) < numColors {
+		// Split the box with the widest channel spread.
+		best, bestRange := -1, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if _, r := box.channelRange(); r > bestRange || best == -1 {
+				best, bestRange = i, r
+			}
+		}
+		if best == -1 {
+			break
+		}
+		a, b := boxes[best].split()
+		boxes[best] = a
+		boxes = append(boxes, b)
+	}
+
+	palette := make([]Pixel, 0, len(boxes))
+	for _, box := range boxes {
+		if len(box) > 0 {
+			palette = append(palette, box.average())
+		}
+	}
+	return palette
+}
+
+// Quantize reduces the PPM image to at most numColors colors chosen by the median cut
+// algorithm, optionally applying Floyd–Steinberg dithering, and returns the palette used.
+func (ppm *PPM) Quantize(numColors int, dither bool) ([]Pixel, error) {
+	if numColors < 1 {
+		return nil, fmt.Errorf("invalid number of colors: %d", numColors)
+	}
+	ppm.record("Quantize", "numColors", numColors, "dither", dither)
+	palette := ppm.medianCut(numColors)
+	ppm.mapToPalette(palette, dither)
+	return palette, nil
+}
+
+// MapToPalette replaces every pixel of the PPM image with the nearest color of a fixed palette,
+// optionally applying Floyd–Steinberg dithering.
+func (ppm *PPM) MapToPalette(palette []Pixel, dither bool) error {
+	if len(palette) == 0 {
+		return fmt.Errorf("empty palette")
+	}
+	ppm.record("MapToPalette", "colors", len(palette), "dither", dither)
+	ppm.mapToPalette(palette, dither)
+	return nil
+}
+
+// nearestColor returns the palette entry closest to (r, g, b) in Euclidean RGB distance.
+func nearestColor(palette []Pixel, r, g, b float64) Pixel {
+	best := palette[0]
+	bestDist := -1.0
+	for _, p := range palette {
+		dr, dg, db := r-float64(p.R), g-float64(p.G), b-float64(p.B)
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = p, dist
+		}
+	}
+	return best
+}
+
+// mapToPalette replaces every pixel with its nearest palette color.
+func (ppm *PPM) mapToPalette(palette []Pixel, dither bool) {
+	ppm.own()
+	if !dither {
+		cache := make(map[Pixel]Pixel)
+		for i := 0; i < ppm.height; i++ {
+			for j := 0; j < ppm.width; j++ {
+				p := ppm.data[i*ppm.width+j]
+				q, ok := cache[p]
+				if !ok {
+					q = nearestColor(palette, float64(p.R), float64(p.G), float64(p.B))
+					cache[p] = q
+				}
+				ppm.data[i*ppm.width+j] = q
+			}
+		}
+		return
+	}
+
+	// Error accumulated for the current and the next row, per channel.
+	current := make([][3]float64, ppm.width+2)
+	next := make([][3]float64, ppm.width+2)
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			p := ppm.data[i*ppm.width+j]
+			v := [3]float64{
+				float64(p.R) + current[j+1][0],
+				float64(p.G) + current[j+1][1],
+				float64(p.B) + current[j+1][2],
+			}
+			q := nearestColor(palette, v[0], v[1], v[2])
+			ppm.data[i*ppm.width+j] = q
+			e := [3]float64{v[0] - float64(q.R), v[1] - float64(q.G), v[2] - float64(q.B)}
+			for c := 0; c < 3; c++ {
+				current[j+2][c] += e[c] * 7 / 16
+				next[j][c] += e[c] * 3 / 16
+				next[j+1][c] += e[c] * 5 / 16
+				next[j+2][c] += e[c] * 1 / 16
+			}
+		}
+		current, next = next, current
+		for k := range next {
+			next[k] = [3]float64{}
+		}
+	}
+}
+
+// WebSafePalette returns the 216 colors of the web-safe palette.
+func WebSafePalette() []Pixel {
+	palette := make([]Pixel, 0, 216)
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				palette = append(palette, Pixel{uint8(r * 51), uint8(g * 51), uint8(b * 51)})
+			}
+		}
+	}
+	return palette
+}
+
+// GameBoyPalette returns the four shades of green of the original Game Boy screen.
+func GameBoyPalette() []Pixel {
+	return []Pixel{
+		{15, 56, 15},
+		{48, 98, 48},
+		{139, 172, 15},
+		{155, 188, 15},
+	}
+}
+
+// ColorHistogram returns the number of occurrences of every distinct color of the PPM image.
+func (ppm *PPM) ColorHistogram() map[Pixel]int {
+	histogram := make(map[Pixel]int)
+	for i := 0; i < ppm.height; i++ {
+		for j := 0; j < ppm.width; j++ {
+			histogram[ppm.data[i*ppm.width+j]]++
+		}
+	}
+	return histogram
+}
+
+// maxKMeansIterations bounds the refinement performed by DominantColors.
+const maxKMeansIterations = 20
+
+// DominantColors clusters the colors of the PPM image into at most k groups with k-means
+// in RGB space and returns the cluster centers together with the fraction of pixels each
+// one covers, most frequent first. Clustering starts from the median cut palette, so the
+// result is deterministic.
+func (ppm *PPM) DominantColors(k int) ([]Pixel, []float64) {
+	if k < 1 || ppm.width == 0 || ppm.height == 0 {
+		return nil, nil
+	}
+	colors := ppm.colorCounts()
+	palette := ppm.medianCut(k)
+	centers := make([][3]float64, len(palette))
+	for i, p := range palette {
+		centers[i] = [3]float64{float64(p.R), float64(p.G), float64(p.B)}
+	}
+
+	assignment := make([]int, len(colors))
+	weights := make([]int, len(centers))
+	for iteration := 0; iteration < maxKMeansIterations; iteration++ {
+		changed := iteration == 0
+		for i, c := range colors {
+			best, bestDist := 0, -1.0
+			for j, center := range centers {
+				dr := float64(c.color.R) - center[0]
+				dg := float64(c.color.G) - center[1]
+				db := float64(c.color.B) - center[2]
+				if dist := dr*dr + dg*dg + db*db; bestDist < 0 || dist < bestDist {
+					best, bestDist = j, dist
+				}
+			}
+			if assignment[i] != best {
+				assignment[i] = best
+				changed = true
+			}
+		}
+
+		sums := make([][3]float64, len(centers))
+		for j := range weights {
+			weights[j] = 0
+		}
+		for i, c := range colors {
+			j := assignment[i]
+			sums[j][0] += float64(c.color.R) * float64(c.count)
+			sums[j][1] += float64(c.color.G) * float64(c.count)
+			sums[j][2] += float64(c.color.B) * float64(c.count)
+			weights[j] += c.count
+		}
+		for j := range centers {
+			if weights[j] > 0 {
+				w := float64(weights[j])
+				centers[j] = [3]float64{sums[j][0] / w, sums[j][1] / w, sums[j][2] / w}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	order := make([]int, 0, len(centers))
+	for j := range centers {
+		if weights[j] > 0 {
+			order = append(order, j)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return weights[order[a]] > weights[order[b]] })
+
+	total := float64(ppm.width * ppm.height)
+	dominant := make([]Pixel, len(order))
+	fractions := make([]float64, len(order))
+	for i, j := range order {
+		dominant[i] = Pixel{clampUint8(centers[j][0], 255), clampUint8(centers[j][1], 255), clampUint8(centers[j][2], 255)}
+		fractions[i] = float64(weights[j]) / total
+	}
+	return dominant, fractions
+}
@@ -0,0 +1,134 @@
+package Netpbm
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// RandomPBM returns a PBM image of random pixels. The same seed always produces the same image.
+func RandomPBM(width, height int, seed int64) *PBM {
+	rng := rand.New(rand.NewSource(seed))
+	pbm := newPBM(width, height, "P1")
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pbm.data[y*pbm.width+x] = rng.Intn(2) == 1
+		}
+	}
+	return pbm
+}
+
+// RandomPGM returns a PGM image of random gray values. The same seed always produces the same image.
+func RandomPGM(width, height int, seed int64) *PGM {
+	rng := rand.New(rand.NewSource(seed))
+	pgm := newPGM(width, height, "P2", 255)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pgm.data[y*pgm.width+x] = uint8(rng.Intn(256))
+		}
+	}
+	return pgm
+}
+
+// RandomPPM returns a PPM image of random colors. The same seed always produces the same image.
+func RandomPPM(width, height int, seed int64) *PPM {
+	rng := rand.New(rand.NewSource(seed))
+	ppm := newPPM(width, height, "P3", 255)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ppm.data[y*ppm.width+x] = randomPixel(rng)
+		}
+	}
+	return ppm
+}
+
+// randomPixel returns a random color.
+func randomPixel(rng *rand.Rand) Pixel {
+	return Pixel{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))}
+}
+
+// RandomPolygons returns a PPM image with a random background covered by count random
+// filled polygons of random colors, some of them concave, as structured input for
+// property-based tests. The same seed always produces the same image.
+func RandomPolygons(width, height, count int, seed int64) *PPM {
+	rng := rand.New(rand.NewSource(seed))
+	ppm := newPPM(width, height, "P3", 255)
+	background := randomPixel(rng)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ppm.data[y*ppm.width+x] = background
+		}
+	}
+	if width == 0 || height == 0 {
+		return ppm
+	}
+
+	for n := 0; n < count; n++ {
+		// Vertices sorted by angle around a center form a simple, possibly concave, polygon.
+		cx, cy := rng.Float64()*float64(width), rng.Float64()*float64(height)
+		radius := (0.1 + rng.Float64()*0.4) * math.Min(float64(width), float64(height))
+		sides := 3 + rng.Intn(6)
+		angles := make([]float64, sides)
+		for i := range angles {
+			angles[i] = rng.Float64() * 2 * math.Pi
+		}
+		sort.Float64s(angles)
+		points := make([][2]float64, sides)
+		for i, a := range angles {
+			r := radius * (0.4 + rng.Float64()*0.6)
+			points[i] = [2]float64{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+		}
+
+		color := randomPixel(rng)
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if insidePolygon(points, float64(x)+0.5, float64(y)+0.5) {
+					ppm.data[y*ppm.width+x] = color
+				}
+			}
+		}
+	}
+	return ppm
+}
+
+// RandomText returns a PPM image with a random background and count random strings of
+// printable ASCII characters drawn with the built-in font, at random positions, scales and
+// colors, as structured input for property-based tests of text-heavy images. The same seed
+// always produces the same image.
+func RandomText(width, height, count int, seed int64) *PPM {
+	rng := rand.New(rand.NewSource(seed))
+	ppm := newPPM(width, height, "P3", 255)
+	background := randomPixel(rng)
+	for i := range ppm.data {
+		ppm.data[i] = background
+	}
+	if width == 0 || height == 0 {
+		return ppm
+	}
+
+	for n := 0; n < count; n++ {
+		text := make([]rune, 1+rng.Intn(12))
+		for i := range text {
+			text[i] = rune(' ' + rng.Intn('~'-' '+1))
+		}
+		scale := 1 + rng.Intn(3)
+		// Strings may start left of or above the image and run past its edges.
+		w, h := MeasureText(string(text), scale)
+		p := Point{rng.Intn(width+w) - w/2, rng.Intn(height+h) - h/2}
+		drawText(p, string(text), scale, ppm.plotter(randomPixel(rng)))
+	}
+	return ppm
+}
+
+// insidePolygon reports whether (x, y) lies inside the polygon according to the even–odd rule.
+func insidePolygon(points [][2]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		xi, yi := points[i][0], points[i][1]
+		xj, yj := points[j][0], points[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
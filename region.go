@@ -0,0 +1,107 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// readRawHeader parses the header of the Netpbm image of r and returns it along with the
+// offset of the pixel data following it.
+func readRawHeader(r io.ReaderAt) (Header, int64, error) {
+	section := io.NewSectionReader(r, 0, math.MaxInt64)
+	reader := bufio.NewReader(section)
+	h, err := readHeader(reader)
+	if err != nil {
+		return h, 0, err
+	}
+	offset, _ := section.Seek(0, io.SeekCurrent)
+	return h, offset - int64(reader.Buffered()), nil
+}
+
+// DecodeRegion reads the part of a raw P4, P5 or P6 image from r within rect, clipped to
+// the bounds of the image. Only the bytes of the rows and columns of the region are read,
+// so a small crop can be extracted from a huge file, such as an *os.File, without decoding
+// it entirely. The returned image has the size of the clipped region.
+func DecodeRegion(r io.ReaderAt, rect image.Rectangle) (Image, error) {
+	h, offset, err := readRawHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var sampleSize int
+	switch h.MagicNumber {
+	case "P4":
+	case "P5":
+		sampleSize = 1
+	case "P6":
+		sampleSize = 3
+	default:
+		return nil, fmt.Errorf("region decoding requires a raw format, got %s", h.MagicNumber)
+	}
+	if h.Max > 255 {
+		return nil, fmt.Errorf("unsupported max value: %d", h.Max)
+	}
+	rect = rect.Intersect(image.Rect(0, 0, h.Width, h.Height))
+	if rect.Empty() {
+		return nil, fmt.Errorf("region outside image bounds %dx%d", h.Width, h.Height)
+	}
+	width, height := rect.Dx(), rect.Dy()
+
+	// Every row of the region is read at its offset: the columns of the region for P5 and
+	// P6, the bytes holding them for P4 whose rows are padded to whole bytes.
+	rowSize, first, size := int64(h.Width*sampleSize), int64(rect.Min.X*sampleSize), width*sampleSize
+	if sampleSize == 0 {
+		rowSize, first = int64((h.Width+7)/8), int64(rect.Min.X/8)
+		size = (rect.Max.X-1)/8 - rect.Min.X/8 + 1
+	}
+	buf := make([]byte, size)
+	readRow := func(y int) error {
+		n, err := r.ReadAt(buf, offset+int64(rect.Min.Y+y)*rowSize+first)
+		if n < len(buf) {
+			if err == io.EOF {
+				return fmt.Errorf("unexpected end of file at line %d", rect.Min.Y+y)
+			}
+			return fmt.Errorf("error reading pixel data at line %d: %v", rect.Min.Y+y, err)
+		}
+		return nil
+	}
+
+	switch h.MagicNumber {
+	case "P4":
+		pbm := newPBM(width, height, h.MagicNumber)
+		for y := 0; y < height; y++ {
+			if err := readRow(y); err != nil {
+				return nil, err
+			}
+			row := pbm.row(y)
+			for x := range row {
+				bit := rect.Min.X%8 + x
+				row[x] = buf[bit/8]>>(7-bit%8)&1 != 0
+			}
+		}
+		return pbm, nil
+	case "P5":
+		pgm := newPGM(width, height, h.MagicNumber, h.Max)
+		for y := 0; y < height; y++ {
+			if err := readRow(y); err != nil {
+				return nil, err
+			}
+			copy(pgm.row(y), buf)
+		}
+		return pgm, nil
+	}
+	ppm := newPPM(width, height, h.MagicNumber, uint8(h.Max))
+	for y := 0; y < height; y++ {
+		if err := readRow(y); err != nil {
+			return nil, err
+		}
+		row := ppm.row(y)
+		for x := range row {
+			row[x] = Pixel{buf[3*x], buf[3*x+1], buf[3*x+2]}
+		}
+	}
+	return ppm, nil
+}
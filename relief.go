@@ -0,0 +1,105 @@
+package Netpbm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Light describes a distant light source, with angles in degrees.
+type Light struct {
+	// Azimuth is the direction the light comes from, clockwise from the top of the image.
+	Azimuth float64
+	// Elevation is the angle of the light above the horizon, 90 being straight overhead.
+	Elevation float64
+}
+
+// terrainStops is the elevation palette of RenderRelief, from the lowest to the highest
+// normalized height.
+var terrainStops = []struct {
+	height float64
+	color  Pixel
+}{
+	{0, Pixel{30, 60, 140}},
+	{0.2, Pixel{60, 120, 190}},
+	{0.22, Pixel{210, 200, 140}},
+	{0.3, Pixel{70, 150, 60}},
+	{0.6, Pixel{120, 100, 60}},
+	{0.85, Pixel{150, 140, 130}},
+	{1, Pixel{250, 250, 250}},
+}
+
+// reliefSky is the color of RenderRelief above the horizon.
+var reliefSky = Pixel{170, 200, 230}
+
+// terrainColor interpolates the elevation palette at a normalized height.
+func terrainColor(h float64) Pixel {
+	for i := 1; i < len(terrainStops); i++ {
+		lo, hi := terrainStops[i-1], terrainStops[i]
+		if h <= hi.height {
+			return mixPixel(lo.color, hi.color, (h-lo.height)/(hi.height-lo.height))
+		}
+	}
+	return terrainStops[len(terrainStops)-1].color
+}
+
+// RenderRelief renders a PGM heightmap as a shaded landscape. Heights are colored with a
+// terrain palette and lit by light (hillshading). viewAngle, in degrees, tilts the camera:
+// 90 looks straight down, lower angles look from the bottom edge of the map, showing the
+// heights in perspective with hidden parts occluded, as rendered by ray marching every
+// column from front to back. The result has the size of the heightmap.
+func RenderRelief(heightmap *PGM, light Light, viewAngle float64) (*PPM, error) {
+	if heightmap.max == 0 {
+		return nil, fmt.Errorf("invalid heightmap maximum value: 0")
+	}
+	if viewAngle <= 0 || viewAngle > 90 {
+		return nil, fmt.Errorf("invalid view angle: %v", viewAngle)
+	}
+	width, height := heightmap.width, heightmap.height
+	out := newPPM(width, height, "P3", 255)
+	if width == 0 || height == 0 {
+		return out, nil
+	}
+
+	// Heights are exaggerated to a quarter of the map height so that relief is visible.
+	scale := float64(height) / 4
+	at := func(x, y int) float64 {
+		v := heightmap.data[clampInt(y, 0, height-1)*heightmap.width+clampInt(x, 0, width-1)]
+		return clamp01(float64(v) / float64(heightmap.max))
+	}
+
+	az := light.Azimuth * math.Pi / 180
+	el := light.Elevation * math.Pi / 180
+	lx, ly, lz := math.Cos(el)*math.Sin(az), -math.Cos(el)*math.Cos(az), math.Sin(el)
+	shade := func(x, y int) float64 {
+		// Surface normal from the central differences of the heights.
+		dzdx := (at(x+1, y) - at(x-1, y)) * scale / 2
+		dzdy := (at(x, y+1) - at(x, y-1)) * scale / 2
+		n := math.Sqrt(dzdx*dzdx + dzdy*dzdy + 1)
+		diffuse := math.Max(0, (-dzdx*lx-dzdy*ly+lz)/n)
+		return 0.2 + 0.8*diffuse
+	}
+
+	view := viewAngle * math.Pi / 180
+	sin, cos := math.Sin(view), math.Cos(view)
+	for x := 0; x < width; x++ {
+		horizon := height // Topmost row already drawn in this column.
+		for y := height - 1; y >= 0 && horizon > 0; y-- {
+			h := at(x, y)
+			sy := int(math.Round(float64(height-1) - float64(height-1-y)*sin - h*scale*cos))
+			if sy >= horizon {
+				continue
+			}
+			c := terrainColor(h)
+			s := shade(x, y)
+			p := Pixel{clampUint8(float64(c.R)*s, 255), clampUint8(float64(c.G)*s, 255), clampUint8(float64(c.B)*s, 255)}
+			for row := clampInt(sy, 0, height); row < horizon; row++ {
+				out.data[row*out.width+x] = p
+			}
+			horizon = clampInt(sy, 0, height)
+		}
+		for row := 0; row < horizon; row++ {
+			out.data[row*out.width+x] = reliefSky
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package Netpbm
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// checkResize validates the size an image is resized to.
+func checkResize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid size: %dx%d", width, height)
+	}
+	return nil
+}
+
+// checkResizeSource rejects the empty images, which have no pixel to resize from.
+func checkResizeSource(width, height int) error {
+	if width == 0 || height == 0 {
+		return fmt.Errorf("cannot resize empty image of size %dx%d", width, height)
+	}
+	return nil
+}
+
+// resampleAxis returns the function mapping a coordinate along an axis resized from src to
+// dst pixels to the two source pixels surrounding it and the weight of the second one.
+// Pixel centers are aligned, so the edges of the image stay in place. src must not be 0.
+func resampleAxis(src, dst int) func(i int) (int, int, float64) {
+	scale := float64(src) / float64(dst)
+	return func(i int) (int, int, float64) {
+		s := math.Max((float64(i)+0.5)*scale-0.5, 0)
+		i0 := int(s)
+		if i0 >= src-1 {
+			return src - 1, src - 1, 0
+		}
+		return i0, i0 + 1, s - float64(i0)
+	}
+}
+
+// Resize scales the PBM image to width x height pixels, each pixel taking the value of the
+// nearest source pixel.
+func (pbm *PBM) Resize(width, height int) error {
+	return pbm.ResizeCtx(context.Background(), width, height)
+}
+
+// ResizeCtx is Resize returning the error of ctx, leaving the image unchanged, if ctx is
+// canceled before all the rows are computed.
+func (pbm *PBM) ResizeCtx(ctx context.Context, width, height int) error {
+	if err := checkResize(width, height); err != nil {
+		return err
+	}
+	if err := checkResizeSource(pbm.width, pbm.height); err != nil {
+		return err
+	}
+	resized := make([]bool, width*height)
+	err := parallelRowsCtx(ctx, height, func(y int) {
+		sy := y * pbm.height / height
+		for x := 0; x < width; x++ {
+			resized[y*width+x] = pbm.data[sy*pbm.width+x*pbm.width/width]
+		}
+	})
+	if err != nil {
+		return err
+	}
+	pbm.record("Resize", "width", width, "height", height)
+	pbm.data = resized
+	pbm.width, pbm.height = width, height
+	return nil
+}
+
+// Resize scales the PGM image to width x height pixels, interpolating the samples
+// bilinearly.
+func (pgm *PGM) Resize(width, height int) error {
+	return pgm.ResizeCtx(context.Background(), width, height)
+}
+
+// ResizeCtx is Resize returning the error of ctx, leaving the image unchanged, if ctx is
+// canceled before all the rows are computed.
+func (pgm *PGM) ResizeCtx(ctx context.Context, width, height int) error {
+	if err := checkResize(width, height); err != nil {
+		return err
+	}
+	if err := checkResizeSource(pgm.width, pgm.height); err != nil {
+		return err
+	}
+	alongX, alongY := resampleAxis(pgm.width, width), resampleAxis(pgm.height, height)
+	resized := make([]uint8, width*height)
+	err := parallelRowsCtx(ctx, height, func(y int) {
+		y0, y1, fy := alongY(y)
+		top, bottom := pgm.row(y0), pgm.row(y1)
+		for x := 0; x < width; x++ {
+			x0, x1, fx := alongX(x)
+			t := float64(top[x0]) + (float64(top[x1])-float64(top[x0]))*fx
+			b := float64(bottom[x0]) + (float64(bottom[x1])-float64(bottom[x0]))*fx
+			resized[y*width+x] = uint8(math.Round(t + (b-t)*fy))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	pgm.record("Resize", "width", width, "height", height)
+	pgm.data = resized
+	pgm.width, pgm.height = width, height
+	return nil
+}
+
+// Resize scales the PPM image to width x height pixels, interpolating the colors
+// bilinearly, channel by channel.
+func (ppm *PPM) Resize(width, height int) error {
+	return ppm.ResizeCtx(context.Background(), width, height)
+}
+
+// ResizeCtx is Resize returning the error of ctx, leaving the image unchanged, if ctx is
+// canceled before all the rows are computed.
+func (ppm *PPM) ResizeCtx(ctx context.Context, width, height int) error {
+	if err := checkResize(width, height); err != nil {
+		return err
+	}
+	if err := checkResizeSource(ppm.width, ppm.height); err != nil {
+		return err
+	}
+	alongX, alongY := resampleAxis(ppm.width, width), resampleAxis(ppm.height, height)
+	lerp := func(a, b uint8, f float64) float64 {
+		return float64(a) + (float64(b)-float64(a))*f
+	}
+	resized := make([]Pixel, width*height)
+	err := parallelRowsCtx(ctx, height, func(y int) {
+		y0, y1, fy := alongY(y)
+		top, bottom := ppm.row(y0), ppm.row(y1)
+		for x := 0; x < width; x++ {
+			x0, x1, fx := alongX(x)
+			tl, tr, bl, br := top[x0], top[x1], bottom[x0], bottom[x1]
+			channel := func(tl, tr, bl, br uint8) uint8 {
+				t, b := lerp(tl, tr, fx), lerp(bl, br, fx)
+				return uint8(math.Round(t + (b-t)*fy))
+			}
+			resized[y*width+x] = Pixel{
+				channel(tl.R, tr.R, bl.R, br.R),
+				channel(tl.G, tr.G, bl.G, br.G),
+				channel(tl.B, tr.B, bl.B, br.B),
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	ppm.record("Resize", "width", width, "height", height)
+	// The resized pixels are a new copy, no longer shared with snapshots.
+	ppm.data, ppm.shared = resized, false
+	ppm.width, ppm.height = width, height
+	return nil
+}
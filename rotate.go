@@ -0,0 +1,63 @@
+package Netpbm
+
+import "math"
+
+// rotateInverse returns the function mapping a pixel of a width x height image rotated by
+// angle degrees clockwise about its center to the point of the original image it comes
+// from.
+func rotateInverse(width, height int, angle float64) func(x, y int) (float64, float64) {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	return func(x, y int) (float64, float64) {
+		dx, dy := float64(x)-cx, float64(y)-cy
+		return cx + cos*dx + sin*dy, cy - sin*dx + cos*dy
+	}
+}
+
+// Rotate rotates the PGM image by angle degrees clockwise about its center, keeping its
+// size. Samples are interpolated bilinearly; the corners uncovered by the rotation are
+// filled with background.
+func (pgm *PGM) Rotate(angle float64, background uint8) {
+	pgm.record("Rotate", "angle", angle, "background", background)
+	src := pgm.data
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= pgm.width || y >= pgm.height {
+			return float64(background)
+		}
+		return float64(src[y*pgm.width+x])
+	}
+	inverse := rotateInverse(pgm.width, pgm.height, angle)
+	data := make([]uint8, pgm.width*pgm.height)
+	for y := 0; y < pgm.height; y++ {
+		for x := 0; x < pgm.width; x++ {
+			sx, sy := inverse(x, y)
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			top := at(x0, y0) + (at(x0+1, y0)-at(x0, y0))*fx
+			bottom := at(x0, y0+1) + (at(x0+1, y0+1)-at(x0, y0+1))*fx
+			data[y*pgm.width+x] = uint8(math.Round(top + (bottom-top)*fy))
+		}
+	}
+	pgm.data = data
+}
+
+// Rotate rotates the PBM image by angle degrees clockwise about its center, keeping its
+// size. Each pixel takes the value of the nearest source pixel; the corners uncovered by
+// the rotation are set to background.
+func (pbm *PBM) Rotate(angle float64, background bool) {
+	pbm.record("Rotate", "angle", angle, "background", background)
+	inverse := rotateInverse(pbm.width, pbm.height, angle)
+	data := make([]bool, pbm.width*pbm.height)
+	for y := 0; y < pbm.height; y++ {
+		for x := 0; x < pbm.width; x++ {
+			sx, sy := inverse(x, y)
+			ix, iy := int(math.Round(sx)), int(math.Round(sy))
+			if ix < 0 || iy < 0 || ix >= pbm.width || iy >= pbm.height {
+				data[y*pbm.width+x] = background
+			} else {
+				data[y*pbm.width+x] = pbm.data[iy*pbm.width+ix]
+			}
+		}
+	}
+	pbm.data = data
+}
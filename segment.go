@@ -0,0 +1,79 @@
+package Netpbm
+
+import "math"
+
+// SegmentKMeans partitions the pixels of the PPM image into at most k regions of similar
+// color with k-means clustering (see DominantColors), such as sky and ground. It returns
+// the label of every pixel, 1 to n, and the mean color of each region, centers[l-1] being
+// the color of the pixels labeled l, most frequent first.
+func (ppm *PPM) SegmentKMeans(k int) ([][]int, []Pixel) {
+	centers, _ := ppm.DominantColors(k)
+	if len(centers) == 0 {
+		return nil, nil
+	}
+	labels := make([][]int, ppm.height)
+	for y := 0; y < ppm.height; y++ {
+		row := ppm.row(y)
+		labels[y] = make([]int, ppm.width)
+		for x, p := range row {
+			best, bestDist := 0, -1
+			for i, c := range centers {
+				dr, dg, db := int(p.R)-int(c.R), int(p.G)-int(c.G), int(p.B)-int(c.B)
+				if dist := dr*dr + dg*dg + db*db; bestDist < 0 || dist < bestDist {
+					best, bestDist = i, dist
+				}
+			}
+			labels[y][x] = best + 1
+		}
+	}
+	return labels, centers
+}
+
+// SegmentKMeans partitions the pixels of the PGM image into at most k regions of similar
+// brightness with k-means clustering, such as text and background. It returns the label
+// of every pixel, 1 to n, and the mean value of each region, most frequent first.
+func (pgm *PGM) SegmentKMeans(k int) ([][]int, []uint8) {
+	labels, centers := pgm.ToPPM().SegmentKMeans(k)
+	values := make([]uint8, len(centers))
+	for i, c := range centers {
+		values[i] = c.R
+	}
+	return labels, values
+}
+
+// labelColor returns a color for the label, successive labels being far apart in hue.
+func labelColor(label int) Pixel {
+	h := math.Mod(float64(label)*137.508, 360)
+	v := 0.95
+	if label%2 == 0 {
+		v = 0.75
+	}
+	r, g, b := hsvToRGB(h, 0.7, v)
+	return Pixel{clampUint8(r*255, 255), clampUint8(g*255, 255), clampUint8(b*255, 255)}
+}
+
+// ColorizeLabels returns a PPM image showing a label map, as returned by SegmentKMeans or
+// LabelComponents, with a distinct color for every positive label. Pixels labeled 0 or
+// less are black.
+func ColorizeLabels(labels [][]int) *PPM {
+	height, width := len(labels), 0
+	if height > 0 {
+		width = len(labels[0])
+	}
+	ppm := newPPM(width, height, "P6", 255)
+	colors := make(map[int]Pixel)
+	for y, row := range labels {
+		for x, label := range row {
+			if label <= 0 || x >= width {
+				continue
+			}
+			c, ok := colors[label]
+			if !ok {
+				c = labelColor(label)
+				colors[label] = c
+			}
+			ppm.data[y*ppm.width+x] = c
+		}
+	}
+	return ppm
+}
@@ -0,0 +1,41 @@
+package Netpbm
+
+import "fmt"
+
+// Sequence is an ordered list of PPM frames of the same size, such as an animation or the
+// successive states of an image being processed.
+type Sequence struct {
+	frames []*PPM
+}
+
+// NewSequence returns a sequence holding the given frames.
+func NewSequence(frames ...*PPM) (*Sequence, error) {
+	seq := &Sequence{}
+	for _, frame := range frames {
+		if err := seq.Append(frame); err != nil {
+			return nil, err
+		}
+	}
+	return seq, nil
+}
+
+// Append adds frame at the end of the sequence. It must have the size of the other frames.
+func (s *Sequence) Append(frame *PPM) error {
+	if len(s.frames) > 0 {
+		if err := s.frames[0].checkSameSize(frame); err != nil {
+			return fmt.Errorf("frame %d: %v", len(s.frames), err)
+		}
+	}
+	s.frames = append(s.frames, frame)
+	return nil
+}
+
+// Len returns the number of frames of the sequence.
+func (s *Sequence) Len() int {
+	return len(s.frames)
+}
+
+// Frame returns the frame at index i.
+func (s *Sequence) Frame(i int) *PPM {
+	return s.frames[i]
+}
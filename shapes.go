@@ -0,0 +1,252 @@
+package Netpbm
+
+import "math"
+
+// midpointEllipse calls plot with the offsets from the center of the pixels of the
+// circumference of an ellipse of radii rx and ry, computed with the midpoint ellipse
+// algorithm in one quadrant and mirrored to the others.
+func midpointEllipse(rx, ry int, plot func(dx, dy int)) {
+	if rx <= 0 || ry <= 0 {
+		// Degenerate ellipses are lines.
+		for dx := -rx; dx <= rx; dx++ {
+			for dy := -ry; dy <= ry; dy++ {
+				plot(dx, dy)
+			}
+		}
+		return
+	}
+	quadrants := func(x, y int) {
+		plot(x, y)
+		if x != 0 {
+			plot(-x, y)
+		}
+		if y != 0 {
+			plot(x, -y)
+			if x != 0 {
+				plot(-x, -y)
+			}
+		}
+	}
+
+	a2, b2 := int64(rx)*int64(rx), int64(ry)*int64(ry)
+	x, y := 0, ry
+	// Region 1: the slope is above -1, step in x.
+	d := 4*b2 - 4*a2*int64(ry) + a2
+	for b2*int64(x) <= a2*int64(y) {
+		quadrants(x, y)
+		if d >= 0 {
+			y--
+			d -= 8 * a2 * int64(y)
+		}
+		x++
+		d += 4 * b2 * (2*int64(x) + 1)
+	}
+	// Region 2: the slope is below -1, step in y.
+	d = b2*(2*int64(x)+1)*(2*int64(x)+1) + 4*a2*(int64(y)-1)*(int64(y)-1) - 4*a2*b2
+	for y >= 0 {
+		quadrants(x, y)
+		if d <= 0 {
+			x++
+			d += 8 * b2 * int64(x)
+		}
+		y--
+		d -= 4 * a2 * (2*int64(y) + 1)
+	}
+}
+
+// DrawEllipse draws the outline of an ellipse of horizontal radius rx and vertical radius
+// ry on the PPM image with the specified color.
+func (ppm *PPM) DrawEllipse(center Point, rx, ry int, color Pixel) {
+	ellipse(center, rx, ry, ppm.plotter(color))
+}
+
+// DrawFilledEllipse draws a filled ellipse of horizontal radius rx and vertical radius ry
+// on the PPM image with the specified color. It covers exactly the pixels inside the
+// outline drawn by DrawEllipse.
+func (ppm *PPM) DrawFilledEllipse(center Point, rx, ry int, color Pixel) {
+	filledEllipse(center, rx, ry, ppm.plotter(color))
+}
+
+// inSweep reports whether the direction (dx, dy) lies between the angles start and end,
+// in degrees measured clockwise from the positive x axis as the y axis points down. A sweep
+// of 360 degrees or more covers every direction.
+func inSweep(dx, dy int, start, end float64) bool {
+	sweep := end - start
+	if sweep >= 360 || sweep <= -360 {
+		return true
+	}
+	if sweep < 0 {
+		start, sweep = end, -sweep
+	}
+	angle := math.Atan2(float64(dy), float64(dx)) * 180 / math.Pi
+	return math.Mod(math.Mod(angle-start, 360)+360, 360) <= sweep
+}
+
+// DrawArc draws the part of the circle of the given radius between startAngle and
+// endAngle, in degrees clockwise from the 3 o'clock direction, on the PPM image with the
+// specified color.
+func (ppm *PPM) DrawArc(center Point, radius int, startAngle, endAngle float64, color Pixel) {
+	plot := ppm.plotter(color)
+	midpointCircle(radius, func(dx, dy int) {
+		if inSweep(dx, dy, startAngle, endAngle) {
+			plot(center.X+dx, center.Y+dy)
+		}
+	})
+}
+
+// DrawPieSlice draws a filled slice of the disc of the given radius between startAngle and
+// endAngle, in degrees clockwise from the 3 o'clock direction, on the PPM image with the
+// specified color, as used for pie charts and dials.
+func (ppm *PPM) DrawPieSlice(center Point, radius int, startAngle, endAngle float64, color Pixel) {
+	plot := ppm.plotter(color)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius && (dx == 0 && dy == 0 || inSweep(dx, dy, startAngle, endAngle)) {
+				plot(center.X+dx, center.Y+dy)
+			}
+		}
+	}
+}
+
+// roundedCorners returns, for every distance dy from the center of a corner of the given
+// radius, the horizontal extent of the corner outline drawn by the midpoint algorithm.
+func roundedCorners(radius int) []int {
+	spans := make([]int, radius+1)
+	midpointCircle(radius, func(dx, dy int) {
+		if dy >= 0 && dx > spans[dy] {
+			spans[dy] = dx
+		}
+	})
+	return spans
+}
+
+// clampCornerRadius keeps the radius of the corners of a w x h rectangle within half its
+// smallest side.
+func clampCornerRadius(w, h, radius int) int {
+	return clampInt(radius, 0, (min(w, h)-1)/2)
+}
+
+// DrawRoundedRectangle draws the outline of a rectangle with rounded corners of the given
+// radius on the PPM image with the specified color. The outline covers w x h pixels from
+// p, like DrawFilledRoundedRectangle.
+func (ppm *PPM) DrawRoundedRectangle(p Point, w, h, cornerRadius int, color Pixel) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	r := clampCornerRadius(w, h, cornerRadius)
+	x0, y0, x1, y1 := p.X+r, p.Y+r, p.X+w-1-r, p.Y+h-1-r
+	plot := ppm.plotter(color)
+	for x := x0; x <= x1; x++ {
+		plot(x, p.Y)
+		if h > 1 {
+			plot(x, p.Y+h-1)
+		}
+	}
+	for y := y0; y <= y1; y++ {
+		plot(p.X, y)
+		if w > 1 {
+			plot(p.X+w-1, y)
+		}
+	}
+	if r == 0 {
+		return
+	}
+	midpointCircle(r, func(dx, dy int) {
+		// Each quadrant of the circle goes to its corner; the axes are drawn by the edges.
+		if dx == 0 || dy == 0 {
+			return
+		}
+		cx, cy := x1, y1
+		if dx < 0 {
+			cx = x0
+		}
+		if dy < 0 {
+			cy = y0
+		}
+		plot(cx+dx, cy+dy)
+	})
+}
+
+// DrawFilledRoundedRectangle draws a filled w x h rectangle from p with rounded corners of
+// the given radius on the PPM image with the specified color.
+func (ppm *PPM) DrawFilledRoundedRectangle(p Point, w, h, cornerRadius int, color Pixel) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	r := clampCornerRadius(w, h, cornerRadius)
+	spans := roundedCorners(r)
+	plot := ppm.plotter(color)
+	for y := 0; y < h; y++ {
+		inset := 0
+		if dy := r - y; dy > 0 {
+			inset = r - spans[dy]
+		} else if dy := y - (h - 1 - r); dy > 0 {
+			inset = r - spans[dy]
+		}
+		for x := inset; x < w-inset; x++ {
+			plot(p.X+x, p.Y+y)
+		}
+	}
+}
+
+// RegularPolygonPoints returns the vertices of a regular polygon with the given number of
+// sides inscribed in the circle of center and radius. With no rotation the first vertex is
+// straight above the center; rotation turns the polygon clockwise, in degrees.
+func RegularPolygonPoints(center Point, radius, sides int, rotation float64) []Point {
+	if sides < 3 {
+		return nil
+	}
+	points := make([]Point, sides)
+	for i := range points {
+		points[i] = polarPoint(center, float64(radius), rotation-90+360*float64(i)/float64(sides))
+	}
+	return points
+}
+
+// StarPoints returns the vertices of a star with the given number of points, alternating
+// between the outer and the inner radius, the first point straight above the center.
+func StarPoints(center Point, outerRadius, innerRadius, points int) []Point {
+	if points < 2 {
+		return nil
+	}
+	vertices := make([]Point, 2*points)
+	for i := range vertices {
+		r := outerRadius
+		if i%2 == 1 {
+			r = innerRadius
+		}
+		vertices[i] = polarPoint(center, float64(r), -90+180*float64(i)/float64(points))
+	}
+	return vertices
+}
+
+// polarPoint returns the pixel at distance r from center in the direction angle, in degrees
+// clockwise from the x axis.
+func polarPoint(center Point, r, angle float64) Point {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	return Point{center.X + int(math.Round(r*cos)), center.Y + int(math.Round(r*sin))}
+}
+
+// DrawRegularPolygon draws the outline of a regular polygon on the PPM image with the
+// specified color (see RegularPolygonPoints).
+func (ppm *PPM) DrawRegularPolygon(center Point, radius, sides int, rotation float64, color Pixel) {
+	ppm.DrawPolygon(RegularPolygonPoints(center, radius, sides, rotation), color)
+}
+
+// DrawFilledRegularPolygon draws a filled regular polygon on the PPM image with the
+// specified color (see RegularPolygonPoints).
+func (ppm *PPM) DrawFilledRegularPolygon(center Point, radius, sides int, rotation float64, color Pixel) {
+	ppm.DrawFilledPolygon(RegularPolygonPoints(center, radius, sides, rotation), color)
+}
+
+// DrawStar draws the outline of a star on the PPM image with the specified color (see
+// StarPoints).
+func (ppm *PPM) DrawStar(center Point, outerRadius, innerRadius, points int, color Pixel) {
+	ppm.DrawPolygon(StarPoints(center, outerRadius, innerRadius, points), color)
+}
+
+// DrawFilledStar draws a filled star on the PPM image with the specified color (see
+// StarPoints).
+func (ppm *PPM) DrawFilledStar(center Point, outerRadius, innerRadius, points int, color Pixel) {
+	ppm.DrawFilledPolygon(StarPoints(center, outerRadius, innerRadius, points), color)
+}
@@ -0,0 +1,29 @@
+package Netpbm
+
+// Snapshot returns a read-only copy of the PPM image in its current state. The copy is
+// cheap: the pixels are shared with the image until either of them is written to, which
+// copies them first. A long Save or Encode of the snapshot can thus run in a goroutine
+// while the image keeps being modified, as long as Snapshot itself is not called
+// concurrently with writes to the image.
+func (ppm *PPM) Snapshot() *PPM {
+	ppm.shared = true
+	return &PPM{
+		data:        ppm.data,
+		width:       ppm.width,
+		height:      ppm.height,
+		magicNumber: ppm.magicNumber,
+		max:         ppm.max,
+		// Writing to the snapshot must not modify the image either.
+		shared: true,
+	}
+}
+
+// own makes the pixels of the PPM image private before they are modified in place,
+// copying them if they are shared with a snapshot.
+func (ppm *PPM) own() {
+	if !ppm.shared {
+		return
+	}
+	ppm.data = append([]Pixel(nil), ppm.data...)
+	ppm.shared = false
+}
@@ -0,0 +1,16 @@
+package Netpbm
+
+// Stamp copies sprite onto the PPM image with its top-left corner at the given point,
+// skipping the pixels of the transparent color when it is not nil, as for tiles and icons.
+// Like the drawing methods, it is clipped to the image and its clip rectangle.
+func (ppm *PPM) Stamp(sprite *PPM, at Point, transparent *Pixel) {
+	for y := 0; y < sprite.height; y++ {
+		row := sprite.row(y)
+		for x, p := range row {
+			if transparent != nil && p == *transparent {
+				continue
+			}
+			ppm.plot(at.X+x, at.Y+y, p)
+		}
+	}
+}
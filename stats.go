@@ -0,0 +1,225 @@
+package Netpbm
+
+import "math"
+
+// ChannelStats summarizes the samples of one channel of an image.
+type ChannelStats struct {
+	Min, Max     uint8
+	Mean, StdDev float64
+	Median       uint8
+}
+
+// sampleHistogram counts the occurrences of every sample value.
+type sampleHistogram [256]int
+
+// stats returns the summary of the samples counted in the histogram. All fields are zero
+// for an empty histogram.
+func (h *sampleHistogram) stats() ChannelStats {
+	var n int
+	var sum, sumSq float64
+	s := ChannelStats{Min: 255}
+	for v, count := range h {
+		if count == 0 {
+			continue
+		}
+		s.Min = min(s.Min, uint8(v))
+		s.Max = max(s.Max, uint8(v))
+		n += count
+		sum += float64(v) * float64(count)
+		sumSq += float64(v) * float64(v) * float64(count)
+	}
+	if n == 0 {
+		return ChannelStats{}
+	}
+	s.Mean = sum / float64(n)
+	s.StdDev = math.Sqrt(math.Max(0, sumSq/float64(n)-s.Mean*s.Mean))
+	s.Median = h.percentile(50)
+	return s
+}
+
+// percentile returns the smallest sample value such that at least p percent of the samples
+// are lower or equal; p is clamped to [0, 100].
+func (h *sampleHistogram) percentile(p float64) uint8 {
+	var n int
+	for _, count := range h {
+		n += count
+	}
+	if n == 0 {
+		return 0
+	}
+	target := math.Max(1, math.Ceil(math.Max(0, math.Min(p, 100))/100*float64(n)))
+	var cumulative int
+	for v, count := range h {
+		cumulative += count
+		if float64(cumulative) >= target {
+			return uint8(v)
+		}
+	}
+	return 255
+}
+
+// entropy returns the Shannon entropy of the distribution of the samples, in bits.
+func (h *sampleHistogram) entropy() float64 {
+	var n int
+	for _, count := range h {
+		n += count
+	}
+	var e float64
+	for _, count := range h {
+		if count > 0 {
+			p := float64(count) / float64(n)
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
+
+// histogram counts the samples of the PGM image.
+func (pgm *PGM) histogram() *sampleHistogram {
+	var h sampleHistogram
+	for _, v := range pgm.data {
+		h[v]++
+	}
+	return &h
+}
+
+// Stats returns the minimum, maximum, mean, standard deviation and median of the samples
+// of the PGM image.
+func (pgm *PGM) Stats() ChannelStats {
+	return pgm.histogram().stats()
+}
+
+// Percentile returns the sample value below or at which p percent of the samples of the
+// PGM image lie, p being between 0 and 100: 50 is the median, 1 and 99 are robust
+// estimates of the black and white points.
+func (pgm *PGM) Percentile(p float64) uint8 {
+	return pgm.histogram().percentile(p)
+}
+
+// Entropy returns the Shannon entropy of the samples of the PGM image, in bits per pixel:
+// 0 for a blank page, up to 8 for evenly spread gray levels.
+func (pgm *PGM) Entropy() float64 {
+	return pgm.histogram().entropy()
+}
+
+// histograms counts the samples of every channel of the PPM image.
+func (ppm *PPM) histograms() [3]*sampleHistogram {
+	var h [3]sampleHistogram
+	for _, p := range ppm.data {
+		h[RedChannel][p.R]++
+		h[GreenChannel][p.G]++
+		h[BlueChannel][p.B]++
+	}
+	return [3]*sampleHistogram{&h[0], &h[1], &h[2]}
+}
+
+// Stats returns the minimum, maximum, mean, standard deviation and median of every channel
+// of the PPM image, indexed by Channel.
+func (ppm *PPM) Stats() [3]ChannelStats {
+	var s [3]ChannelStats
+	for c, h := range ppm.histograms() {
+		s[c] = h.stats()
+	}
+	return s
+}
+
+// Percentile returns, channel by channel, the sample value below or at which p percent of
+// the samples of the PPM image lie, p being between 0 and 100.
+func (ppm *PPM) Percentile(p float64) Pixel {
+	h := ppm.histograms()
+	return Pixel{h[RedChannel].percentile(p), h[GreenChannel].percentile(p), h[BlueChannel].percentile(p)}
+}
+
+// Entropy returns the Shannon entropy of the luminance of the PPM image, in bits per pixel.
+func (ppm *PPM) Entropy() float64 {
+	var h sampleHistogram
+	for _, p := range ppm.data {
+		h[clampUint8(Rec601Luma(p), 255)]++
+	}
+	return h.entropy()
+}
+
+// meanAbsDeviation returns the mean absolute deviation of the samples from their mean.
+func (h *sampleHistogram) meanAbsDeviation() float64 {
+	s := h.stats()
+	var n int
+	var sum float64
+	for v, count := range h {
+		n += count
+		sum += math.Abs(float64(v)-s.Mean) * float64(count)
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// MeanAbsDeviation returns the mean absolute deviation of the samples of the PGM image from
+// their mean, relative to the maximum value: 0 for a uniform image.
+func (pgm *PGM) MeanAbsDeviation() float64 {
+	if pgm.max == 0 {
+		return 0
+	}
+	return pgm.histogram().meanAbsDeviation() / float64(pgm.max)
+}
+
+// IsBlank reports whether the PGM image is uniform enough to be an empty page, its
+// MeanAbsDeviation being at most threshold. Scans of empty pages, with sensor noise and
+// paper texture, typically stay below 0.01.
+func (pgm *PGM) IsBlank(threshold float64) bool {
+	return pgm.MeanAbsDeviation() <= threshold
+}
+
+// IsSolidColor reports whether all the pixels of the PGM image have the same value.
+func (pgm *PGM) IsSolidColor() bool {
+	for _, v := range pgm.data {
+		if v != pgm.data[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// MeanAbsDeviation returns the mean over the channels of the absolute deviation of the
+// samples of the PPM image from their mean, relative to the maximum value.
+func (ppm *PPM) MeanAbsDeviation() float64 {
+	if ppm.max == 0 {
+		return 0
+	}
+	var sum float64
+	for _, h := range ppm.histograms() {
+		sum += h.meanAbsDeviation()
+	}
+	return sum / 3 / float64(ppm.max)
+}
+
+// IsBlank reports whether the PPM image is uniform enough to be empty, its
+// MeanAbsDeviation being at most threshold.
+func (ppm *PPM) IsBlank(threshold float64) bool {
+	return ppm.MeanAbsDeviation() <= threshold
+}
+
+// IsSolidColor reports whether all the pixels of the PPM image have the same color.
+func (ppm *PPM) IsSolidColor() bool {
+	for _, p := range ppm.data {
+		if p != ppm.data[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// InkRatio returns the fraction of the pixels of the PBM image that are set (black), from
+// 0 for an empty bitmap to 1.
+func (pbm *PBM) InkRatio() float64 {
+	if pbm.width == 0 || pbm.height == 0 {
+		return 0
+	}
+	var ink int
+	for _, v := range pbm.data {
+		if v {
+			ink++
+		}
+	}
+	return float64(ink) / float64(pbm.width*pbm.height)
+}
@@ -0,0 +1,70 @@
+package Netpbm
+
+import "fmt"
+
+// AnaglyphMode selects how a stereo pair is combined into a red/cyan anaglyph.
+type AnaglyphMode int
+
+const (
+	// ColorAnaglyph takes the red channel from the left image and green and blue from the right one.
+	// Colors are best preserved but retinal rivalry may appear on saturated areas.
+	ColorAnaglyph AnaglyphMode = iota
+	// GrayAnaglyph uses the luminance of each image, giving a comfortable gray result.
+	GrayAnaglyph
+	// HalfColorAnaglyph uses the luminance of the left image and the colors of the right one.
+	HalfColorAnaglyph
+)
+
+// Anaglyph combines a stereo pair of PPM images of the same size into a red/cyan anaglyph
+// viewable with red (left eye) and cyan (right eye) glasses.
+func Anaglyph(left, right *PPM, mode AnaglyphMode) (*PPM, error) {
+	if err := left.checkSameSize(right); err != nil {
+		return nil, err
+	}
+	if mode < ColorAnaglyph || mode > HalfColorAnaglyph {
+		return nil, fmt.Errorf("invalid anaglyph mode: %d", mode)
+	}
+
+	max := left.max
+	if right.max > max {
+		max = right.max
+	}
+	out := newPPM(left.width, left.height, "P3", max)
+	for i := 0; i < left.height; i++ {
+		for j := 0; j < left.width; j++ {
+			l, r := left.data[i*left.width+j], right.data[i*right.width+j]
+			switch mode {
+			case ColorAnaglyph:
+				out.data[i*out.width+j] = Pixel{l.R, r.G, r.B}
+			case GrayAnaglyph:
+				lg, rg := clampUint8(Rec601Luma(l), max), clampUint8(Rec601Luma(r), max)
+				out.data[i*out.width+j] = Pixel{lg, rg, rg}
+			case HalfColorAnaglyph:
+				out.data[i*out.width+j] = Pixel{clampUint8(Rec601Luma(l), max), r.G, r.B}
+			}
+		}
+	}
+	return out, nil
+}
+
+// SideBySide packs a stereo pair of PPM images of the same size into one image twice as
+// wide, with the left image on the left for parallel viewing, or swapped when crossEyed is set.
+func SideBySide(left, right *PPM, crossEyed bool) (*PPM, error) {
+	if err := left.checkSameSize(right); err != nil {
+		return nil, err
+	}
+	if crossEyed {
+		left, right = right, left
+	}
+
+	max := left.max
+	if right.max > max {
+		max = right.max
+	}
+	out := newPPM(2*left.width, left.height, "P3", max)
+	for i := 0; i < left.height; i++ {
+		copy(out.row(i), left.row(i))
+		copy(out.data[i*out.width+left.width:], right.row(i))
+	}
+	return out, nil
+}
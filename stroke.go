@@ -0,0 +1,302 @@
+package Netpbm
+
+import "math"
+
+// LineCap selects how the ends of strokes and dashes are drawn.
+type LineCap int
+
+const (
+	// ButtCap ends strokes exactly at their end points.
+	ButtCap LineCap = iota
+	// RoundCap ends strokes with a half disc.
+	RoundCap
+	// SquareCap extends strokes by half their width beyond their end points.
+	SquareCap
+)
+
+// LineJoin selects how the corners of strokes are drawn.
+type LineJoin int
+
+const (
+	// MiterJoin extends the outer edges of the segments until they meet, falling back to
+	// BevelJoin on corners sharper than the miter limit.
+	MiterJoin LineJoin = iota
+	// RoundJoin rounds corners with a disc.
+	RoundJoin
+	// BevelJoin cuts corners straight.
+	BevelJoin
+)
+
+// miterLimit is the maximum ratio between the miter length and the stroke width.
+const miterLimit = 4
+
+// StrokeStyle describes how lines are drawn by the Styled drawing methods.
+type StrokeStyle struct {
+	// Width is the thickness of the stroke in pixels; 0 and 1 both draw one-pixel lines.
+	Width int
+	// Dash alternates the lengths, in pixels, of the drawn and skipped parts of the stroke,
+	// starting with a drawn part; the pattern continues across corners. Nil draws solid
+	// lines; {1, 2} draws dots.
+	Dash []int
+	// Cap and Join only apply to strokes wider than one pixel.
+	Cap  LineCap
+	Join LineJoin
+}
+
+// dashPeriod returns the length of the dash pattern, or 0 for solid strokes.
+func (s StrokeStyle) dashPeriod() int {
+	var period int
+	for _, d := range s.Dash {
+		if d > 0 {
+			period += d
+		}
+	}
+	return period
+}
+
+// dashOn reports whether the stroke is drawn at the given distance along the path.
+func (s StrokeStyle) dashOn(distance float64) bool {
+	period := s.dashPeriod()
+	if period == 0 {
+		return true
+	}
+	d := math.Mod(distance, float64(period))
+	for i, length := range s.Dash {
+		if length <= 0 {
+			continue
+		}
+		if d < float64(length) {
+			return i%2 == 0
+		}
+		d -= float64(length)
+	}
+	return true
+}
+
+// dashIntervals returns the drawn parts [start, end] of the path between the distances
+// from and to.
+func (s StrokeStyle) dashIntervals(from, to float64) [][2]float64 {
+	period := s.dashPeriod()
+	if period == 0 {
+		return [][2]float64{{from, to}}
+	}
+	var intervals [][2]float64
+	base := math.Floor(from/float64(period)) * float64(period)
+	for base < to {
+		start := base
+		for i, length := range s.Dash {
+			if length <= 0 {
+				continue
+			}
+			end := start + float64(length)
+			if i%2 == 0 && end > from && start < to {
+				intervals = append(intervals, [2]float64{math.Max(start, from), math.Min(end, to)})
+			}
+			start = end
+		}
+		base += float64(period)
+	}
+	return intervals
+}
+
+// DrawLineStyled draws a line on the PPM image between two points with the given stroke
+// style and color.
+func (ppm *PPM) DrawLineStyled(p1, p2 Point, style StrokeStyle, color Pixel) {
+	ppm.strokePath([]Point{p1, p2}, false, style, color)
+}
+
+// DrawRectangleStyled draws the outline of a rectangle on the PPM image with the given
+// stroke style and color.
+func (ppm *PPM) DrawRectangleStyled(p1 Point, width, height int, style StrokeStyle, color Pixel) {
+	ppm.strokePath([]Point{p1, {p1.X + width, p1.Y}, {p1.X + width, p1.Y + height}, {p1.X, p1.Y + height}}, true, style, color)
+}
+
+// DrawPolygonStyled draws the outline of a polygon on the PPM image with the given stroke
+// style and color.
+func (ppm *PPM) DrawPolygonStyled(points []Point, style StrokeStyle, color Pixel) {
+	ppm.strokePath(points, true, style, color)
+}
+
+// DrawPolylineStyled draws connected line segments through points on the PPM image with
+// the given stroke style and color, without closing the path.
+func (ppm *PPM) DrawPolylineStyled(points []Point, style StrokeStyle, color Pixel) {
+	ppm.strokePath(points, false, style, color)
+}
+
+// strokePath draws the path through points, closing it if requested.
+func (ppm *PPM) strokePath(points []Point, closed bool, style StrokeStyle, color Pixel) {
+	if len(points) == 0 {
+		return
+	}
+	plot := ppm.plotter(color)
+	if style.Width <= 1 {
+		strokeThin(points, closed, style, plot)
+		return
+	}
+	strokeWide(points, closed, style, plot)
+}
+
+// strokeThin draws a one-pixel path with Bresenham lines, counting the dash pattern in
+// pixels. Corners are plotted once.
+func strokeThin(points []Point, closed bool, style StrokeStyle, plot func(x, y int)) {
+	if len(points) == 1 {
+		plot(points[0].X, points[0].Y)
+		return
+	}
+	segments := len(points) - 1
+	if closed {
+		segments++
+	}
+	distance := 0
+	for i := 0; i < segments; i++ {
+		first := true
+		bresenham(points[i], points[(i+1)%len(points)], func(x, y int) {
+			// The first pixel of a segment is the last of the previous one.
+			if first && i > 0 {
+				first = false
+				return
+			}
+			first = false
+			if style.dashOn(float64(distance)) {
+				plot(x, y)
+			}
+			distance++
+		})
+	}
+}
+
+// strokeWide draws a path wider than one pixel by filling the outline of every dash,
+// with caps at dash ends and joins at the corners where the stroke is drawn.
+func strokeWide(points []Point, closed bool, style StrokeStyle, plot func(x, y int)) {
+	hw := float64(style.Width) / 2
+	type vec = [2]float64
+	pts := make([]vec, len(points))
+	for i, p := range points {
+		pts[i] = vec{float64(p.X), float64(p.Y)}
+	}
+	if len(pts) == 1 {
+		if style.Cap != ButtCap {
+			fillDisc(pts[0], hw, plot)
+		}
+		return
+	}
+
+	segments := len(pts) - 1
+	if closed {
+		segments++
+	}
+	var distance float64
+	for i := 0; i < segments; i++ {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		length := math.Hypot(b[0]-a[0], b[1]-a[1])
+		if length == 0 {
+			continue
+		}
+		d := vec{(b[0] - a[0]) / length, (b[1] - a[1]) / length}
+		n := vec{-d[1] * hw, d[0] * hw}
+		for _, interval := range style.dashIntervals(distance, distance+length) {
+			s, e := interval[0]-distance, interval[1]-distance
+			p := vec{a[0] + d[0]*s, a[1] + d[1]*s}
+			q := vec{a[0] + d[0]*e, a[1] + d[1]*e}
+			fillPolygonF([]vec{{p[0] + n[0], p[1] + n[1]}, {q[0] + n[0], q[1] + n[1]}, {q[0] - n[0], q[1] - n[1]}, {p[0] - n[0], p[1] - n[1]}}, plot)
+			// Interval ends at corners are covered by joins, others get caps.
+			if s > 0 || (i == 0 && !closed) {
+				drawCap(p, vec{-d[0], -d[1]}, hw, style.Cap, plot)
+			}
+			if e < length || (i == segments-1 && !closed) {
+				drawCap(q, d, hw, style.Cap, plot)
+			}
+		}
+		distance += length
+
+		// Join with the next segment if the stroke is drawn at the corner.
+		if (i < segments-1 || closed) && style.dashOn(distance) {
+			next := pts[(i+2)%len(pts)]
+			drawJoin(b, d, vec{next[0] - b[0], next[1] - b[1]}, hw, style.Join, plot)
+		}
+	}
+}
+
+// drawCap draws the cap of a stroke ending at p in the direction u.
+func drawCap(p, u [2]float64, hw float64, lineCap LineCap, plot func(x, y int)) {
+	switch lineCap {
+	case RoundCap:
+		fillDisc(p, hw, plot)
+	case SquareCap:
+		n := [2]float64{-u[1] * hw, u[0] * hw}
+		e := [2]float64{p[0] + u[0]*hw, p[1] + u[1]*hw}
+		fillPolygonF([][2]float64{{p[0] + n[0], p[1] + n[1]}, {e[0] + n[0], e[1] + n[1]}, {e[0] - n[0], e[1] - n[1]}, {p[0] - n[0], p[1] - n[1]}}, plot)
+	}
+}
+
+// drawJoin draws the join at corner v between a segment of direction d1 (normalized) and
+// the next one, of direction d2.
+func drawJoin(v, d1, d2 [2]float64, hw float64, join LineJoin, plot func(x, y int)) {
+	l := math.Hypot(d2[0], d2[1])
+	if l == 0 {
+		return
+	}
+	d2 = [2]float64{d2[0] / l, d2[1] / l}
+	if join == RoundJoin {
+		fillDisc(v, hw, plot)
+		return
+	}
+	n1 := [2]float64{-d1[1], d1[0]}
+	n2 := [2]float64{-d2[1], d2[0]}
+	turn := d2[0]*n1[0] + d2[1]*n1[1]
+	if turn == 0 {
+		return
+	}
+	// The outer side of the corner is opposite to the turn.
+	side := -1.0
+	if turn < 0 {
+		side = 1
+	}
+	p1 := [2]float64{v[0] + side*n1[0]*hw, v[1] + side*n1[1]*hw}
+	p2 := [2]float64{v[0] + side*n2[0]*hw, v[1] + side*n2[1]*hw}
+	if join == MiterJoin {
+		m := [2]float64{n1[0] + n2[0], n1[1] + n2[1]}
+		ml := math.Hypot(m[0], m[1])
+		// ml/2 is the cosine of half the angle between the normals.
+		if ml > 0 && 2/ml <= miterLimit {
+			scale := side * hw * 2 / (ml * ml)
+			tip := [2]float64{v[0] + m[0]*scale, v[1] + m[1]*scale}
+			fillPolygonF([][2]float64{v, p1, tip, p2}, plot)
+			return
+		}
+	}
+	fillPolygonF([][2]float64{v, p1, p2}, plot)
+}
+
+// fillPolygonF calls plot for every pixel whose center is inside the polygon with
+// floating-point vertices, according to the even–odd rule.
+func fillPolygonF(points [][2]float64, plot func(x, y int)) {
+	if len(points) < 3 {
+		return
+	}
+	minX, minY := points[0][0], points[0][1]
+	maxX, maxY := minX, minY
+	for _, p := range points[1:] {
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	for y := int(math.Floor(minY)); y <= int(math.Ceil(maxY)); y++ {
+		for x := int(math.Floor(minX)); x <= int(math.Ceil(maxX)); x++ {
+			if insidePolygon(points, float64(x), float64(y)) {
+				plot(x, y)
+			}
+		}
+	}
+}
+
+// fillDisc calls plot for every pixel whose center is inside the disc.
+func fillDisc(c [2]float64, r float64, plot func(x, y int)) {
+	for y := int(math.Floor(c[1] - r)); y <= int(math.Ceil(c[1]+r)); y++ {
+		for x := int(math.Floor(c[0] - r)); x <= int(math.Ceil(c[0]+r)); x++ {
+			dx, dy := float64(x)-c[0], float64(y)-c[1]
+			if dx*dx+dy*dy <= r*r {
+				plot(x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package Netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"time"
+)
+
+// RenderANSI draws the src region of the PPM image (the whole image when src is empty) on a
+// 24-bit color terminal, using cols x rows character cells. Each cell shows two pixels
+// stacked vertically with the upper half block character, so pixels keep a square aspect.
+// The region is sampled with nearest neighbor, enlarging or reducing it to fit. Lines end
+// with "\r\n" so the output is also correct when the terminal is in raw mode.
+func (ppm *PPM) RenderANSI(w io.Writer, src image.Rectangle, cols, rows int) error {
+	if cols < 1 || rows < 1 {
+		return fmt.Errorf("invalid terminal size: %dx%d", cols, rows)
+	}
+	region := regionOf(src, ppm.width, ppm.height)
+	writer := bufio.NewWriter(w)
+	scale := func(v uint8) int {
+		if ppm.max == 0 {
+			return 0
+		}
+		return int(v) * 255 / int(ppm.max)
+	}
+	sample := func(col, py int) (Pixel, bool) {
+		if region.Empty() {
+			return Pixel{}, false
+		}
+		x := region.Min.X + col*region.Dx()/cols
+		y := region.Min.Y + py*region.Dy()/(2*rows)
+		return ppm.data[y*ppm.width+x], true
+	}
+
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			top, ok := sample(col, 2*row)
+			if !ok {
+				writer.WriteString(" ")
+				continue
+			}
+			bottom, _ := sample(col, 2*row+1)
+			fmt.Fprintf(writer, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				scale(top.R), scale(top.G), scale(top.B), scale(bottom.R), scale(bottom.G), scale(bottom.B))
+		}
+		writer.WriteString("\x1b[0m\r\n")
+	}
+	return writer.Flush()
+}
+
+// sixelColors is the number of color registers used by RenderSixel, which most sixel
+// terminals provide.
+const sixelColors = 256
+
+// RenderSixel draws the src region of the PPM image (the whole image when src is empty) as
+// a sixel graphic of width x height pixels, for terminals such as xterm -ti vt340, mlterm
+// or foot which display images at full resolution. The region is sampled with nearest
+// neighbor and its colors reduced to a palette of 256 colors by median cut.
+func (ppm *PPM) RenderSixel(w io.Writer, src image.Rectangle, width, height int) error {
+	if width < 1 || height < 1 {
+		return fmt.Errorf("invalid sixel size: %dx%d", width, height)
+	}
+	region := regionOf(src, ppm.width, ppm.height)
+	if region.Empty() {
+		return fmt.Errorf("region outside image bounds %dx%d", ppm.width, ppm.height)
+	}
+	sampled := newPPM(width, height, "P3", ppm.max)
+	for y := 0; y < height; y++ {
+		row := ppm.row(region.Min.Y + y*region.Dy()/height)
+		for x := 0; x < width; x++ {
+			sampled.data[y*width+x] = row[region.Min.X+x*region.Dx()/width]
+		}
+	}
+	palette := sampled.medianCut(sixelColors)
+	sampled.mapToPalette(palette, false)
+
+	writer := bufio.NewWriter(w)
+	// Start the sixel data with square pixels and the size of the image.
+	fmt.Fprintf(writer, "\x1bP0;1q\"1;1;%d;%d", width, height)
+	index := make(map[Pixel]int, len(palette))
+	percent := func(v uint8) int {
+		if ppm.max == 0 {
+			return 0
+		}
+		return (int(v)*100 + int(ppm.max)/2) / int(ppm.max)
+	}
+	for i, c := range palette {
+		if _, ok := index[c]; !ok {
+			index[c] = i
+		}
+		fmt.Fprintf(writer, "#%d;2;%d;%d;%d", i, percent(c.R), percent(c.G), percent(c.B))
+	}
+
+	// Every band of six rows is drawn color by color, each color setting its pixels in the
+	// columns of the band, one character per column.
+	sixels := make([][]byte, len(palette))
+	for band := 0; band < height; band += 6 {
+		for i := range sixels {
+			sixels[i] = nil
+		}
+		for dy := 0; dy < 6 && band+dy < height; dy++ {
+			for x, p := range sampled.row(band + dy) {
+				i := index[p]
+				if sixels[i] == nil {
+					sixels[i] = make([]byte, width)
+				}
+				sixels[i][x] |= 1 << dy
+			}
+		}
+		first := true
+		for i, columns := range sixels {
+			if columns == nil {
+				continue
+			}
+			if !first {
+				writer.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(writer, "#%d", i)
+			writeSixels(writer, columns)
+		}
+		writer.WriteByte('-')
+	}
+	writer.WriteString("\x1b\\")
+	return writer.Flush()
+}
+
+// writeSixels writes the columns of one color of a sixel band, compressing runs of the
+// same column and leaving out the empty columns at the end.
+func writeSixels(w *bufio.Writer, columns []byte) {
+	for len(columns) > 0 && columns[len(columns)-1] == 0 {
+		columns = columns[:len(columns)-1]
+	}
+	for i := 0; i < len(columns); {
+		n := 1
+		for i+n < len(columns) && columns[i+n] == columns[i] {
+			n++
+		}
+		char := '?' + columns[i]
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, char)
+		} else {
+			for k := 0; k < n; k++ {
+				w.WriteByte(char)
+			}
+		}
+		i += n
+	}
+}
+
+// asciiRamp orders characters from the sparsest to the densest.
+const asciiRamp = " .:-=+*#%@"
+
+// brailleDots gives the bit of the braille dot at column x and row y of a character cell.
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// luminanceSampler returns a function giving the normalized luminance of the pixel of the
+// region at (px, py) in a grid of gridW x gridH samples covering it.
+func (ppm *PPM) luminanceSampler(region image.Rectangle, gridW, gridH int) func(px, py int) float64 {
+	return func(px, py int) float64 {
+		if region.Empty() || ppm.max == 0 {
+			return 0
+		}
+		x := region.Min.X + px*region.Dx()/gridW
+		y := region.Min.Y + py*region.Dy()/gridH
+		return Rec601Luma(ppm.data[y*ppm.width+x]) / float64(ppm.max)
+	}
+}
+
+// RenderASCII draws the src region of the PPM image (the whole image when src is empty)
+// with cols x rows plain characters, denser characters standing for brighter pixels as
+// suits terminals with a dark background. Lines end with "\r\n".
+func (ppm *PPM) RenderASCII(w io.Writer, src image.Rectangle, cols, rows int) error {
+	if cols < 1 || rows < 1 {
+		return fmt.Errorf("invalid terminal size: %dx%d", cols, rows)
+	}
+	sample := ppm.luminanceSampler(regionOf(src, ppm.width, ppm.height), cols, rows)
+	writer := bufio.NewWriter(w)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			i := int(sample(col, row)*float64(len(asciiRamp)-1) + 0.5)
+			writer.WriteByte(asciiRamp[i])
+		}
+		writer.WriteString("\r\n")
+	}
+	return writer.Flush()
+}
+
+// RenderBraille draws the src region of the PPM image (the whole image when src is empty)
+// with cols x rows braille characters, each showing 2x4 pixels as dots which are raised
+// when the luminance of the pixel is at least threshold, between 0 and 1. This gives the
+// highest resolution available in a monochrome terminal. Lines end with "\r\n".
+func (ppm *PPM) RenderBraille(w io.Writer, src image.Rectangle, cols, rows int, threshold float64) error {
+	if cols < 1 || rows < 1 {
+		return fmt.Errorf("invalid terminal size: %dx%d", cols, rows)
+	}
+	sample := ppm.luminanceSampler(regionOf(src, ppm.width, ppm.height), 2*cols, 4*rows)
+	writer := bufio.NewWriter(w)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			char := rune(0x2800)
+			for dy, dots := range brailleDots {
+				for dx, dot := range dots {
+					if sample(2*col+dx, 4*row+dy) >= threshold {
+						char |= dot
+					}
+				}
+			}
+			writer.WriteRune(char)
+		}
+		writer.WriteString("\r\n")
+	}
+	return writer.Flush()
+}
+
+// TerminalMode selects the renderer used by a TerminalStream.
+type TerminalMode int
+
+const (
+	// TerminalColor renders with RenderANSI.
+	TerminalColor TerminalMode = iota
+	// TerminalASCII renders with RenderASCII.
+	TerminalASCII
+	// TerminalBraille renders with RenderBraille, with a threshold of 0.5.
+	TerminalBraille
+)
+
+// TerminalStream shows successive frames at the same place of a terminal, moving the
+// cursor back over the previous frame before drawing the next one, for live previews of
+// processing. Frames are scaled to the cols x rows cells of the stream.
+type TerminalStream struct {
+	w          io.Writer
+	mode       TerminalMode
+	cols, rows int
+	frames     int
+}
+
+// NewTerminalStream returns a stream drawing frames on w with the given renderer.
+func NewTerminalStream(w io.Writer, mode TerminalMode, cols, rows int) (*TerminalStream, error) {
+	if cols < 1 || rows < 1 {
+		return nil, fmt.Errorf("invalid terminal size: %dx%d", cols, rows)
+	}
+	if mode < TerminalColor || mode > TerminalBraille {
+		return nil, fmt.Errorf("invalid terminal mode: %d", mode)
+	}
+	return &TerminalStream{w: w, mode: mode, cols: cols, rows: rows}, nil
+}
+
+// WriteFrame draws frame over the previous one. The cursor is hidden until Close.
+func (s *TerminalStream) WriteFrame(frame *PPM) error {
+	if s.frames == 0 {
+		if _, err := io.WriteString(s.w, "\x1b[?25l"); err != nil {
+			return err
+		}
+	} else if _, err := fmt.Fprintf(s.w, "\x1b[%dA\r", s.rows); err != nil {
+		return err
+	}
+	s.frames++
+	switch s.mode {
+	case TerminalASCII:
+		return frame.RenderASCII(s.w, image.Rectangle{}, s.cols, s.rows)
+	case TerminalBraille:
+		return frame.RenderBraille(s.w, image.Rectangle{}, s.cols, s.rows, 0.5)
+	}
+	return frame.RenderANSI(s.w, image.Rectangle{}, s.cols, s.rows)
+}
+
+// Play draws the frames of seq in order, waiting delay between them.
+func (s *TerminalStream) Play(seq *Sequence, delay time.Duration) error {
+	for i := 0; i < seq.Len(); i++ {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		if err := s.WriteFrame(seq.Frame(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stream draws the frames received from frames as they arrive, until the channel is closed.
+func (s *TerminalStream) Stream(frames <-chan *PPM) error {
+	for frame := range frames {
+		if err := s.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close shows the cursor again. The last frame stays on the terminal.
+func (s *TerminalStream) Close() error {
+	_, err := io.WriteString(s.w, "\x1b[?25h")
+	return err
+}
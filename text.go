@@ -0,0 +1,43 @@
+package Netpbm
+
+import "strings"
+
+// MeasureText returns the width and height, in pixels, of text drawn with DrawText at the
+// given scale. Lines are separated by '\n'.
+func MeasureText(text string, scale int) (int, int) {
+	scale = max(scale, 1)
+	lines := strings.Split(text, "\n")
+	var columns int
+	for _, line := range lines {
+		columns = max(columns, len([]rune(line)))
+	}
+	// The spacing after the last character and below the last line is not drawn.
+	return max(columns*Font5x7.Advance()-1, 0) * scale, (len(lines)*Font5x7.LineHeight() - 1) * scale
+}
+
+// DrawText draws text on the PPM image with the built-in 5x7 font and the specified color,
+// every font pixel becoming a scale x scale square. p is the top-left corner of the first
+// character; '\n' starts a new line below p.
+func (ppm *PPM) DrawText(p Point, text string, color Pixel, scale int) {
+	drawText(p, text, scale, ppm.plotter(color))
+}
+
+// drawText plots the pixels of text drawn with the built-in font, see DrawText.
+func drawText(p Point, text string, scale int, plot func(x, y int)) {
+	scale = max(scale, 1)
+	x, y := p.X, p.Y
+	for _, r := range text {
+		if r == '\n' {
+			x, y = p.X, y+Font5x7.LineHeight()*scale
+			continue
+		}
+		for gy := 0; gy < Font5x7.height; gy++ {
+			for gx := 0; gx < Font5x7.width; gx++ {
+				if Font5x7.Pixel(r, gx, gy) {
+					filledRectangle(Point{x + gx*scale, y + gy*scale}, scale, scale, plot)
+				}
+			}
+		}
+		x += Font5x7.Advance() * scale
+	}
+}
@@ -0,0 +1,68 @@
+// Package ttf draws text with TrueType and OpenType fonts on Netpbm images, through the
+// font.Face interface of golang.org/x/image/font. Faces are typically created with
+// golang.org/x/image/font/opentype from a parsed font file.
+package ttf
+
+import (
+	"image"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+
+	Netpbm "nom_du_module"
+)
+
+// DrawTextTTF draws text on the PPM image with the given face and color, blending the
+// anti-aliased glyphs with the existing pixels. p is the left end of the baseline of the
+// text. Drawing is clipped like the other drawing methods of the image.
+func DrawTextTTF(img *Netpbm.PPM, p Netpbm.Point, text string, face font.Face, color Netpbm.Pixel) {
+	clip := img.Clip()
+	dot := fixed.P(p.X, p.Y)
+	prev := rune(-1)
+	for _, r := range text {
+		if prev >= 0 {
+			dot.X += face.Kern(prev, r)
+		}
+		dr, mask, maskp, advance, ok := face.Glyph(dot, r)
+		if !ok {
+			// Fall back to the glyph of the replacement character, if any.
+			dr, mask, maskp, advance, _ = face.Glyph(dot, '\ufffd')
+		}
+		if mask != nil {
+			blendMask(img, dr.Intersect(clip), dr.Min, mask, maskp, color)
+		}
+		dot.X += advance
+		prev = r
+	}
+}
+
+// MeasureTextTTF returns the horizontal advance of text drawn with face and its bounds
+// relative to the origin of the baseline.
+func MeasureTextTTF(text string, face font.Face) (int, image.Rectangle) {
+	bounds, advance := font.BoundString(face, text)
+	return advance.Ceil(), image.Rect(bounds.Min.X.Floor(), bounds.Min.Y.Floor(), bounds.Max.X.Ceil(), bounds.Max.Y.Ceil())
+}
+
+// blendMask mixes color into the pixels of area of the image, weighted by the alpha of the
+// mask, whose point maskp corresponds to origin.
+func blendMask(img *Netpbm.PPM, area image.Rectangle, origin image.Point, mask image.Image, maskp image.Point, color Netpbm.Pixel) {
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			_, _, _, a := mask.At(maskp.X+x-origin.X, maskp.Y+y-origin.Y).RGBA()
+			if a == 0 {
+				continue
+			}
+			old := img.At(x, y)
+			img.Set(x, y, Netpbm.Pixel{
+				R: mix(old.R, color.R, a),
+				G: mix(old.G, color.G, a),
+				B: mix(old.B, color.B, a),
+			})
+		}
+	}
+}
+
+// mix interpolates between a and b with the 16-bit alpha a16.
+func mix(a, b uint8, a16 uint32) uint8 {
+	return uint8((uint32(a)*(0xffff-a16) + uint32(b)*a16 + 0x7fff) / 0xffff)
+}
@@ -0,0 +1,110 @@
+package Netpbm
+
+import "fmt"
+
+// Anchor selects where an element is placed within an image.
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// position returns the top-left corner of a w x h element anchored in an image of the
+// given size, keeping margin pixels away from the edges it is anchored to.
+func (a Anchor) position(width, height, w, h, margin int) (x, y int) {
+	switch a % 3 {
+	case 0:
+		x = margin
+	case 1:
+		x = (width - w) / 2
+	case 2:
+		x = width - w - margin
+	}
+	switch a / 3 {
+	case 0:
+		y = margin
+	case 1:
+		y = (height - h) / 2
+	case 2:
+		y = height - h - margin
+	}
+	return x, y
+}
+
+// checkWatermark validates the parameters shared by the watermarking methods.
+func checkWatermark(mark *PPM, opacity float64) error {
+	if mark == nil || mark.width == 0 || mark.height == 0 {
+		return fmt.Errorf("empty watermark")
+	}
+	if opacity < 0 || opacity > 1 {
+		return fmt.Errorf("invalid opacity: %v", opacity)
+	}
+	return nil
+}
+
+// blendAt mixes mark over the PPM image with its top-left corner at (x, y). Parts outside
+// the image are ignored.
+func (ppm *PPM) blendAt(mark *PPM, x, y int, opacity float64) {
+	ppm.own()
+	for i := 0; i < mark.height; i++ {
+		if y+i < 0 || y+i >= ppm.height {
+			continue
+		}
+		for j := 0; j < mark.width; j++ {
+			if x+j < 0 || x+j >= ppm.width {
+				continue
+			}
+			ppm.data[(y+i)*ppm.width+x+j] = mixPixel(ppm.data[(y+i)*ppm.width+x+j], mark.data[i*mark.width+j], opacity)
+		}
+	}
+}
+
+// Watermark stamps mark, such as a logo, onto the PPM image at the given anchor, margin
+// pixels away from the edges, mixed with the given opacity: 0 leaves the image unchanged,
+// 1 pastes the mark as is. Parts of the mark outside the image are clipped.
+func (ppm *PPM) Watermark(mark *PPM, anchor Anchor, margin int, opacity float64) error {
+	if err := checkWatermark(mark, opacity); err != nil {
+		return err
+	}
+	if anchor < AnchorTopLeft || anchor > AnchorBottomRight {
+		return fmt.Errorf("invalid anchor: %d", anchor)
+	}
+	ppm.record("Watermark", "anchor", anchor, "margin", margin, "opacity", opacity)
+
+	x, y := anchor.position(ppm.width, ppm.height, mark.width, mark.height, margin)
+	ppm.blendAt(mark, x, y, opacity)
+	return nil
+}
+
+// WatermarkTiled repeats mark over the whole PPM image, leaving spacing pixels between
+// the copies, mixed with the given opacity. Every other row of copies is shifted by
+// half a tile so that the watermark is harder to crop out.
+func (ppm *PPM) WatermarkTiled(mark *PPM, spacing int, opacity float64) error {
+	if err := checkWatermark(mark, opacity); err != nil {
+		return err
+	}
+	if spacing < 0 {
+		return fmt.Errorf("invalid spacing: %d", spacing)
+	}
+	ppm.record("WatermarkTiled", "spacing", spacing, "opacity", opacity)
+
+	stepX, stepY := mark.width+spacing, mark.height+spacing
+	for row, y := 0, 0; y < ppm.height; row, y = row+1, y+stepY {
+		start := 0
+		if row%2 == 1 {
+			start = -stepX / 2
+		}
+		for x := start; x < ppm.width; x += stepX {
+			ppm.blendAt(mark, x, y, opacity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package Netpbm
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// floodPixel is a pixel waiting in the watershed flooding queue.
+type floodPixel struct {
+	level uint8
+	order int
+	x, y  int
+}
+
+// floodQueue is a priority queue of pixels by elevation, first in first out among pixels
+// of the same elevation so that plateaus are shared evenly between basins.
+type floodQueue []floodPixel
+
+// Len returns the number of queued pixels.
+func (q floodQueue) Len() int { return len(q) }
+
+// Less orders pixels by elevation, then by arrival.
+func (q floodQueue) Less(i, j int) bool {
+	if q[i].level != q[j].level {
+		return q[i].level < q[j].level
+	}
+	return q[i].order < q[j].order
+}
+
+// Swap exchanges two queued pixels.
+func (q floodQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+// Push adds a pixel to the queue, for container/heap.
+func (q *floodQueue) Push(v interface{}) { *q = append(*q, v.(floodPixel)) }
+
+// Pop removes the last pixel of the queue, for container/heap.
+func (q *floodQueue) Pop() interface{} {
+	old := *q
+	v := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return v
+}
+
+// Watershed segments the PGM image, seen as an elevation map such as a gradient computed
+// with MorphGradient, by flooding it from markers with Meyer's algorithm. Every non-zero
+// pixel of markers, which must have the same size, seeds the basin labeled by its value;
+// basins grow by increasing elevation until they meet, splitting touching objects along
+// the ridges between them. It returns the label of every pixel, 0 for the pixels no
+// marker reaches.
+func (pgm *PGM) Watershed(markers *PGM) ([][]int, error) {
+	if markers.width != pgm.width || markers.height != pgm.height {
+		return nil, fmt.Errorf("image sizes differ: %dx%d and %dx%d", pgm.width, pgm.height, markers.width, markers.height)
+	}
+	labels := make([][]int, pgm.height)
+	queued := make([][]bool, pgm.height)
+	for y := range labels {
+		labels[y] = make([]int, pgm.width)
+		queued[y] = make([]bool, pgm.width)
+	}
+
+	var q floodQueue
+	order := 0
+	push := func(x, y int) {
+		queued[y][x] = true
+		heap.Push(&q, floodPixel{pgm.data[y*pgm.width+x], order, x, y})
+		order++
+	}
+	neighbors := FourConnected.neighbors()
+	for y := 0; y < markers.height; y++ {
+		row := markers.row(y)
+		for x, v := range row {
+			if v != 0 {
+				labels[y][x] = int(v)
+			}
+		}
+	}
+	// Seed the queue with the unlabeled neighbors of the markers.
+	for y := range labels {
+		for x := range labels[y] {
+			if labels[y][x] == 0 {
+				continue
+			}
+			for _, d := range neighbors {
+				nx, ny := x+d.X, y+d.Y
+				if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height && labels[ny][nx] == 0 && !queued[ny][nx] {
+					push(nx, ny)
+				}
+			}
+		}
+	}
+
+	for q.Len() > 0 {
+		p := heap.Pop(&q).(floodPixel)
+		// The pixel joins the basin of its first labeled neighbor.
+		for _, d := range neighbors {
+			nx, ny := p.x+d.X, p.y+d.Y
+			if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height && labels[ny][nx] != 0 {
+				labels[p.y][p.x] = labels[ny][nx]
+				break
+			}
+		}
+		for _, d := range neighbors {
+			nx, ny := p.x+d.X, p.y+d.Y
+			if nx >= 0 && ny >= 0 && nx < pgm.width && ny < pgm.height && labels[ny][nx] == 0 && !queued[ny][nx] {
+				push(nx, ny)
+			}
+		}
+	}
+	return labels, nil
+}